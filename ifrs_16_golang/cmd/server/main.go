@@ -5,46 +5,23 @@ import (
 	"fmt"
 	"html/template"
 	"ifrs16_calculator/internal/calculation"
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
 	"ifrs16_calculator/internal/platform/export"
 	"ifrs16_calculator/internal/platform/parsing"
 	"log"
-	"math"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
 const maxUploadSize = 10 * 1024 * 1024 // 10 MB
 
-// CalculationResult holds the calculated outputs for a single lease.
-type CalculationResult struct {
-	LeaseID           string                          `json:"leaseId"`
-	InitialLiability  float64                         `json:"initialLiability"`
-	InitialRoUAsset   float64                         `json:"initialRoUAsset"`
-	DiscountRate      float64                         `json:"discountRate"`     // Added discount rate
-	PaymentAmount     float64                         `json:"paymentAmount"`    // Added payment amount
-	PaymentFrequency  string                          `json:"paymentFrequency"` // Added payment frequency
-	StartDate         string                          `json:"startDate"`        // Added start date
-	EndDate           string                          `json:"endDate"`          // Added end date
-	LiabilitySchedule []calculation.AmortizationEntry `json:"liabilitySchedule"`
-	RoUAssetSchedule  []calculation.AmortizationEntry `json:"rouAssetSchedule"`
-	// 账期摘要信息
-	AccountingPeriodStart  string  `json:"accountingPeriodStart,omitempty"`  // 账期开始日期
-	AccountingPeriodEnd    string  `json:"accountingPeriodEnd,omitempty"`    // 账期结束日期
-	PeriodLiabilityStart   float64 `json:"periodLiabilityStart,omitempty"`   // 账期期初负债
-	PeriodLiabilityEnd     float64 `json:"periodLiabilityEnd,omitempty"`     // 账期期末负债
-	PeriodRoUAssetStart    float64 `json:"periodRoUAssetStart,omitempty"`    // 账期期初使用权资产
-	PeriodRoUAssetEnd      float64 `json:"periodRoUAssetEnd,omitempty"`      // 账期期末使用权资产
-	PeriodInterestExpense  float64 `json:"periodInterestExpense,omitempty"`  // 账期内利息费用总额
-	PeriodDepreciation     float64 `json:"periodDepreciation,omitempty"`     // 账期内折旧费用总额
-	PeriodPayments         float64 `json:"periodPayments,omitempty"`         // 账期内付款总额
-	PeriodPrincipalPayment float64 `json:"periodPrincipalPayment,omitempty"` // 添加本金偿还金额到结果中
-	Error                  string  `json:"error,omitempty"`                  // To report errors for specific leases
-}
-
 // PageData holds the data for rendering templates
 type PageData struct {
 	Active string
@@ -282,8 +259,65 @@ func handleCalculate(w http.ResponseWriter, r *http.Request) {
 		log.Printf("账期设置: %s 至 %s", accountingPeriodStart, accountingPeriodEnd)
 	}
 
+	// Schedules accrue daily and are presented monthly unless another granularity is requested.
+	// Fiscal periods repeat the accounting period, or are calendar years without one.
+	granularity, err := calculation.ParseGranularity(r.FormValue("granularity"))
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fiscalCalendar := calculation.FiscalCalendar{}
+	if hasAccountingPeriod {
+		fiscalCalendar = fiscalCalendarFor(accountingPeriodStart, accountingPeriodEnd)
+	}
+
+	// Recognition exemptions are elected by class of underlying asset
+	exemptionPolicy, err := exemptionPolicyFor(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Leases in place when IFRS 16 is first applied are measured under the transition policy, if any
+	transitionPolicy, err := transitionPolicyFor(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The practical expedient of not separating non-lease components is elected by asset class
+	combineClasses := splitList(r.FormValue("combineComponentClasses"))
+
+	// Yield curves are optional; with them, leases without a discount rate are given an
+	// incremental borrowing rate read at the lease term or payment duration
+	rateCurves := lease.RateCurves{}
+	if curveHeaders := r.MultipartForm.File["rateCurveFile"]; len(curveHeaders) > 0 {
+		curveFile, err := curveHeaders[0].Open()
+		if err != nil {
+			sendJSONError(w, fmt.Sprintf("Error retrieving the yield curve file: %v", err), http.StatusBadRequest)
+			return
+		}
+		defer curveFile.Close()
+
+		rateCurves, err = parsing.ParseRateCurveCSV(curveFile)
+		if err != nil {
+			log.Printf("Error parsing yield curve file: %v", err)
+			sendJSONError(w, fmt.Sprintf("Error parsing yield curve file: %v", err), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Loaded %d yield curve series.", len(rateCurves))
+	}
+	rateTenor := calculation.TermTenor
+	if r.FormValue("rateTenor") == string(calculation.DurationTenor) {
+		rateTenor = calculation.DurationTenor
+	}
+	if transitionPolicy != nil {
+		transitionPolicy.RateCurves = rateCurves
+		transitionPolicy.RateTenor = rateTenor
+	}
+
 	// Parse the file
-	parseConfig := parsing.ParseConfig{SkipHeader: skipHeader}
+	parseConfig := parsing.ParseConfig{SkipHeader: skipHeader, DeriveDiscountRates: len(rateCurves) > 0}
 	parsedLeases, err := parsing.ParseLeasesFromFile(file, fileType, parseConfig)
 	if err != nil {
 		log.Printf("Error parsing file: %v", err)
@@ -293,17 +327,182 @@ func handleCalculate(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("Successfully parsed %d leases.", len(parsedLeases))
 
+	// An index table is optional; index-linked leases are only remeasured for the values it records
+	indexTable := lease.IndexTable{}
+	if indexHeaders := r.MultipartForm.File["indexFile"]; len(indexHeaders) > 0 {
+		indexFile, err := indexHeaders[0].Open()
+		if err != nil {
+			sendJSONError(w, fmt.Sprintf("Error retrieving the index file: %v", err), http.StatusBadRequest)
+			return
+		}
+		defer indexFile.Close()
+
+		indexTable, err = parsing.ParseIndexCSV(indexFile)
+		if err != nil {
+			log.Printf("Error parsing index file: %v", err)
+			sendJSONError(w, fmt.Sprintf("Error parsing index file: %v", err), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Loaded %d index series.", len(indexTable))
+	}
+
+	// Subleases are measured after the leases are processed, against the RoU assets of their
+	// head leases, which are presented only then
+	type headLease struct {
+		index    int         // Position of its result
+		lease    lease.Lease // Terms in force at the end of its schedules
+		retained float64     // Share of its underlying asset not sublet under finance subleases
+	}
+	type pendingSublease struct {
+		index int // Position of its result
+		lease lease.Lease
+	}
+	headLeaseIDs := make(map[string]bool)
+	for _, l := range parsedLeases {
+		if l.IsSublease() {
+			headLeaseIDs[l.HeadLeaseID] = true
+		}
+	}
+	heads := make(map[string]*headLease)
+	var subleases []pendingSublease
+
 	// Process each lease
-	results := make([]CalculationResult, 0, len(parsedLeases))
+	results := make([]calculation.CalculationResult, 0, len(parsedLeases))
 	for _, l := range parsedLeases {
-		result := CalculationResult{
+		// The lease term runs to the end of the options the lessee is reasonably certain to exercise
+		termLease, termErr := calculation.LeaseTerm(l)
+		if termErr == nil {
+			l = termLease
+		}
+
+		result := calculation.CalculationResult{
 			LeaseID:          l.ID,
 			DiscountRate:     l.DiscountRate,                   // Store the discount rate from the lease
 			PaymentAmount:    l.PaymentAmount,                  // Store the payment amount directly
 			PaymentFrequency: string(l.PaymentFrequency),       // Store the payment frequency directly
+			PaymentTiming:    string(l.PaymentTiming),          // Store the payment timing directly
 			StartDate:        l.StartDate.Format("2006-01-02"), // Store the start date directly
 			EndDate:          l.EndDate.Format("2006-01-02"),   // Store the end date directly
 		}
+		if termErr != nil {
+			log.Printf("Error determining lease term for lease %s: %v", l.ID, termErr)
+			result.Error = fmt.Sprintf("Lease term error: %v", termErr)
+			results = append(results, result)
+			continue
+		}
+		if len(l.Options) > 0 {
+			result.ContractEndDate = l.ContractEndDate.Format("2006-01-02")
+			result.Options = l.Options
+		}
+		result.AssetClass = l.AssetClass
+
+		// A lease the entity grants is accounted for by the lessor as a finance or operating lease
+		if l.IsLessor() {
+			result.Role = l.Role
+			// A sublease is measured once the RoU asset of its head lease is known
+			if l.IsSublease() {
+				result.HeadLeaseID = l.HeadLeaseID
+				subleases = append(subleases, pendingSublease{index: len(results), lease: l})
+				results = append(results, result)
+				continue
+			}
+			if err := calculateLessorLease(&result, l); err != nil {
+				log.Printf("Error calculating lessor lease %s: %v", l.ID, err)
+				result.Error = fmt.Sprintf("Lessor accounting error: %v", err)
+				results = append(results, result)
+				continue
+			}
+
+			presentResult(&result, l, accountingPeriodStart, accountingPeriodEnd, granularity, fiscalCalendar)
+			results = append(results, result)
+			continue
+		}
+
+		// Only the lease component is discounted; non-lease components are expensed as paid,
+		// unless the practical expedient combines them with the lease
+		l, result.ComponentsCombined = calculation.CombineComponents(l, combineClasses)
+
+		// A lease without a discount rate is discounted at the rate built from the yield curves,
+		// which is needed to separate its non-lease components
+		if l.DiscountRate == 0 && len(rateCurves) > 0 {
+			source, err := calculation.IncrementalBorrowingRate(l, rateCurves, rateTenor)
+			if err != nil {
+				log.Printf("Error building discount rate for lease %s: %v", l.ID, err)
+				result.Error = fmt.Sprintf("Discount rate error: %v", err)
+				results = append(results, result)
+				continue
+			}
+			l.DiscountRate = source.Rate
+			l.DiscountRateSource = &source
+			result.DiscountRate = source.Rate
+			result.DiscountRateSource = &source
+		}
+
+		nonLeasePayments, err := calculation.NonLeasePayments(l)
+		if err != nil {
+			log.Printf("Error separating non-lease components for lease %s: %v", l.ID, err)
+			result.Error = fmt.Sprintf("Lease component error: %v", err)
+			results = append(results, result)
+			continue
+		}
+		result.NonLeasePayments = nonLeasePayments
+
+		// Index reviews remeasure the liability like modifications, at the unchanged rate
+		reviews, err := calculation.IndexReviews(l, indexTable)
+		if err != nil {
+			log.Printf("Error applying index reviews for lease %s: %v", l.ID, err)
+			result.Error = fmt.Sprintf("Index review error: %v", err)
+			results = append(results, result)
+			continue
+		}
+		l.Modifications = append(l.Modifications, reviews...)
+
+		// Short-term and low-value leases are expensed straight-line instead of recognised
+		exemption, err := calculation.ClassifyExemption(l, exemptionPolicy)
+		if err != nil {
+			log.Printf("Error classifying lease %s: %v", l.ID, err)
+			result.Error = fmt.Sprintf("Recognition exemption error: %v", err)
+			results = append(results, result)
+			continue
+		}
+
+		// A lease in place at the date of initial application is measured on transition too
+		if transitionPolicy != nil {
+			result.Transition, err = calculation.CalculateTransition(l, *transitionPolicy, exemption)
+			if err != nil {
+				log.Printf("Error measuring transition for lease %s: %v", l.ID, err)
+				result.Error = fmt.Sprintf("Transition error: %v", err)
+				results = append(results, result)
+				continue
+			}
+		}
+
+		if exemption != calculation.NoExemption {
+			result.Exemption = exemption
+			expenseSchedule, err := calculation.ExemptLeaseExpenseSchedule(l)
+			if err != nil {
+				log.Printf("Error generating expense schedule for lease %s: %v", l.ID, err)
+				result.Error = fmt.Sprintf("Expense schedule generation error: %v", err)
+				results = append(results, result)
+				continue
+			}
+			result.ExpenseSchedule = expenseSchedule
+			result.VariablePayments = calculation.VariableLeasePayments(l)
+
+			presentResult(&result, l, accountingPeriodStart, accountingPeriodEnd, granularity, fiscalCalendar)
+			results = append(results, result)
+			continue
+		}
+
+		dayCount, rateBasis, err := calculation.LeaseConventions(l)
+		if err != nil {
+			log.Printf("Error reading conventions for lease %s: %v", l.ID, err)
+			result.Error = fmt.Sprintf("Liability calculation error: %v", err)
+			results = append(results, result)
+			continue
+		}
+		result.DayCountConvention = string(dayCount)
+		result.RateBasis = string(rateBasis)
 
 		liability, err := calculation.CalculateLeaseLiability(l)
 		if err != nil {
@@ -313,16 +512,42 @@ func handleCalculate(w http.ResponseWriter, r *http.Request) {
 			continue // Skip to next lease if initial calc fails
 		}
 		result.InitialLiability = liability
+		result.ResidualGuarantee = l.ResidualValue
 
-		rouAsset, err := calculation.CalculateInitialRoUAsset(liability, l) // Assuming RoU = Liability for now
+		rouComponents, err := calculation.CalculateInitialRoUAssetComponents(liability, l)
+		if err == nil && l.IsLeaseback() {
+			// A leaseback keeps only the part of the asset sold that relates to the rights retained
+			result.SaleAndLeaseback, rouComponents, err = calculation.MeasureSaleAndLeaseback(l, liability)
+		}
 		if err != nil {
 			log.Printf("Error calculating RoU asset for lease %s: %v", l.ID, err)
 			result.Error = fmt.Sprintf("RoU asset calculation error: %v", err)
 			results = append(results, result)
 			continue
 		}
+		rouAsset := rouComponents.Total
 		result.InitialRoUAsset = rouAsset
+		result.RoUAssetComponents = &rouComponents
+
+		// Leases expected to transfer ownership are depreciated over the asset's useful life
+		depreciationEnd, err := calculation.DepreciationEndDate(l)
+		if err != nil {
+			log.Printf("Error determining depreciation period for lease %s: %v", l.ID, err)
+			result.Error = fmt.Sprintf("RoU asset calculation error: %v", err)
+			results = append(results, result)
+			continue
+		}
+		result.DepreciationEndDate = depreciationEnd.Format("2006-01-02")
+		depreciationMethod, err := calculation.DepreciationMethodFor(l)
+		if err != nil {
+			log.Printf("Error reading depreciation method for lease %s: %v", l.ID, err)
+			result.Error = fmt.Sprintf("RoU asset calculation error: %v", err)
+			results = append(results, result)
+			continue
+		}
+		result.DepreciationMethod = string(depreciationMethod)
 
+		scheduleLease := l // Terms in force at the end of the schedules
 		liabSchedule, err := calculation.GenerateLiabilitySchedule(l, liability)
 		if err != nil {
 			log.Printf("Error generating liability schedule for lease %s: %v", l.ID, err)
@@ -343,6 +568,39 @@ func handleCalculate(w http.ResponseWriter, r *http.Request) {
 		}
 		result.RoUAssetSchedule = rouSchedule
 
+		restorationSchedule, err := calculation.GenerateRestorationProvisionSchedule(l, rouComponents.RestorationCosts)
+		if err != nil {
+			log.Printf("Error generating restoration provision schedule for lease %s: %v", l.ID, err)
+			result.Error = fmt.Sprintf("Restoration provision schedule generation error: %v", err)
+			results = append(results, result)
+			continue
+		}
+
+		// Modifications and impairments split the schedules at their dates and continue them
+		// from the remeasured or impaired carrying amounts.
+		if len(l.Modifications) > 0 || len(l.Impairments) > 0 {
+			modified, err := calculation.ApplyModifications(l, liability, rouAsset)
+			if err != nil {
+				log.Printf("Error applying modifications for lease %s: %v", l.ID, err)
+				result.Error = fmt.Sprintf("Lease modification error: %v", err)
+				results = append(results, result)
+				continue
+			}
+			liabSchedule = modified.LiabilitySchedule
+			result.LiabilitySchedule = modified.LiabilitySchedule
+			result.RoUAssetSchedule = modified.RoUAssetSchedule
+			result.Remeasurements = modified.Remeasurements
+			result.Impairments = modified.Impairments
+			result.Options = modified.Lease.Options
+			scheduleLease = modified.Lease
+			// A change in the lease term moves the date the asset is restored
+			restorationSchedule = modified.RestorationSchedule
+		}
+
+		result.RestorationProvision = rouComponents.RestorationCosts
+		result.RestorationSchedule = restorationSchedule
+		result.VariablePayments = calculation.VariableLeasePayments(l)
+
 		// Update the start/end dates from the schedule ONLY if they weren't set properly from the lease
 		if result.StartDate == "0001-01-01" && len(liabSchedule) > 0 {
 			firstPeriodDate := liabSchedule[0].Date
@@ -355,22 +613,41 @@ func handleCalculate(w http.ResponseWriter, r *http.Request) {
 			result.EndDate = endDate.Format("2006-01-02")
 		}
 
-		// 如果提供了账期范围,计算账期摘要
-		if hasAccountingPeriod {
-			if err := calculateAccountingPeriodSummary(&result, accountingPeriodStart, accountingPeriodEnd); err != nil {
-				log.Printf("计算账期摘要时出错: %v", err)
-				// 不需要中断,将错误添加到结果中即可
-				if result.Error == "" {
-					result.Error = fmt.Sprintf("账期摘要计算错误: %v", err)
-				} else {
-					result.Error += fmt.Sprintf("; 账期摘要计算错误: %v", err)
-				}
-			}
+		// A head lease is presented once its subleases have adjusted its RoU asset
+		if _, seen := heads[l.ID]; headLeaseIDs[l.ID] && !seen {
+			heads[l.ID] = &headLease{index: len(results), lease: scheduleLease, retained: 1}
+			results = append(results, result)
+			continue
 		}
 
+		presentResult(&result, scheduleLease, accountingPeriodStart, accountingPeriodEnd, granularity, fiscalCalendar)
 		results = append(results, result)
 	}
 
+	// Subleases are measured in order of commencement, each finance sublease derecognising
+	// part of its head lease's RoU asset, before the head leases are presented
+	sort.SliceStable(subleases, func(i, j int) bool {
+		return subleases[i].lease.StartDate.Before(subleases[j].lease.StartDate)
+	})
+	for _, s := range subleases {
+		result := &results[s.index]
+		head, ok := heads[s.lease.HeadLeaseID]
+		if !ok {
+			log.Printf("Head lease %s of sublease %s not found", s.lease.HeadLeaseID, s.lease.ID)
+			result.Error = fmt.Sprintf("Sublease error: head lease %s was not found or has no RoU asset", s.lease.HeadLeaseID)
+			continue
+		}
+		if err := calculateSublease(result, s.lease, &results[head.index], head.lease, &head.retained); err != nil {
+			log.Printf("Error calculating sublease %s: %v", s.lease.ID, err)
+			result.Error = fmt.Sprintf("Sublease error: %v", err)
+			continue
+		}
+		presentResult(result, s.lease, accountingPeriodStart, accountingPeriodEnd, granularity, fiscalCalendar)
+	}
+	for _, head := range heads {
+		presentResult(&results[head.index], head.lease, accountingPeriodStart, accountingPeriodEnd, granularity, fiscalCalendar)
+	}
+
 	log.Printf("Processed %d leases, returning results.", len(results))
 
 	// Check if request is AJAX (JSON) or form post
@@ -404,7 +681,7 @@ func handleExport(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse the request body
-	var requestData []CalculationResult
+	var requestData []calculation.CalculationResult
 	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
 		sendJSONError(w, fmt.Sprintf("Error parsing request body: %v", err), http.StatusBadRequest)
 		return
@@ -426,34 +703,91 @@ func handleExport(w http.ResponseWriter, r *http.Request) {
 		// Create LeaseResultExport from CalculationResult
 		startDate, _ := time.Parse("2006-01-02", result.StartDate)
 		endDate, _ := time.Parse("2006-01-02", result.EndDate)
+		depreciationEnd, _ := time.Parse("2006-01-02", result.DepreciationEndDate)
+		contractEnd, _ := time.Parse("2006-01-02", result.ContractEndDate)
 
 		// Calculate lease term in years
 		days := endDate.Sub(startDate).Hours() / 24
 		leaseTerm := days / 365.25 // Using 365.25 to account for leap years
 
 		exportResult := export.LeaseResultExport{
-			LeaseID:           result.LeaseID,
-			StartDate:         startDate,
-			EndDate:           endDate,
-			PaymentAmount:     result.PaymentAmount,    // Direct from result
-			PaymentFrequency:  result.PaymentFrequency, // Direct from result
-			DiscountRate:      result.DiscountRate,     // Direct from result
-			InitialLiability:  result.InitialLiability,
-			InitialRoUAsset:   result.InitialRoUAsset,
-			LiabilitySchedule: result.LiabilitySchedule,
-			RoUAssetSchedule:  result.RoUAssetSchedule,
-			LeaseTerm:         leaseTerm, // Add lease term in years
+			LeaseID:              result.LeaseID,
+			AssetClass:           result.AssetClass,
+			Exemption:            string(result.Exemption),
+			StartDate:            startDate,
+			EndDate:              endDate,
+			ContractEndDate:      contractEnd,
+			Options:              result.Options,
+			DepreciationEndDate:  depreciationEnd,
+			DepreciationMethod:   result.DepreciationMethod,
+			PaymentAmount:        result.PaymentAmount,    // Direct from result
+			PaymentFrequency:     result.PaymentFrequency, // Direct from result
+			PaymentTiming:        result.PaymentTiming,    // Direct from result
+			DiscountRate:         result.DiscountRate,     // Direct from result
+			DayCountConvention:   result.DayCountConvention,
+			RateBasis:            result.RateBasis,
+			ScheduleGranularity:  result.ScheduleGranularity,
+			InitialLiability:     result.InitialLiability,
+			InitialRoUAsset:      result.InitialRoUAsset,
+			RoUAssetComponents:   result.RoUAssetComponents,
+			SaleAndLeaseback:     result.SaleAndLeaseback,
+			LiabilitySchedule:    result.LiabilitySchedule,
+			RoUAssetSchedule:     result.RoUAssetSchedule,
+			RestorationProvision: result.RestorationProvision,
+			RestorationSchedule:  result.RestorationSchedule,
+			ResidualGuarantee:    result.ResidualGuarantee,
+			Remeasurements:       result.Remeasurements,
+			Impairments:          result.Impairments,
+			ExpenseSchedule:      result.ExpenseSchedule,
+			NonLeasePayments:     result.NonLeasePayments,
+			ComponentsCombined:   result.ComponentsCombined,
+			LeaseTerm:            leaseTerm, // Add lease term in years
+			// Lessor accounting
+			Role:                  string(result.Role),
+			Classification:        string(result.Classification),
+			Indicators:            result.ClassificationIndicators,
+			NetInvestment:         result.NetInvestment,
+			NetInvestmentSchedule: result.NetInvestmentSchedule,
+			DerecognitionGainLoss: result.DerecognitionGainLoss,
+			IncomeSchedule:        result.IncomeSchedule,
+			AssetSchedule:         result.AssetSchedule,
+			HeadLeaseID:           result.HeadLeaseID,
+			SubleasedRoUAsset:     result.SubleasedRoUAsset,
+			Transition:            result.Transition,
+			DiscountRateSource:    result.DiscountRateSource,
 			// 添加账期摘要信息
-			AccountingPeriodStart:  result.AccountingPeriodStart,
-			AccountingPeriodEnd:    result.AccountingPeriodEnd,
-			PeriodLiabilityStart:   result.PeriodLiabilityStart,
-			PeriodLiabilityEnd:     result.PeriodLiabilityEnd,
-			PeriodRoUAssetStart:    result.PeriodRoUAssetStart,
-			PeriodRoUAssetEnd:      result.PeriodRoUAssetEnd,
-			PeriodInterestExpense:  result.PeriodInterestExpense,
-			PeriodDepreciation:     result.PeriodDepreciation,
-			PeriodPayments:         result.PeriodPayments,
-			PeriodPrincipalPayment: result.PeriodPrincipalPayment,
+			AccountingPeriodStart:        result.AccountingPeriodStart,
+			AccountingPeriodEnd:          result.AccountingPeriodEnd,
+			PeriodLiabilityStart:         result.PeriodLiabilityStart,
+			PeriodLiabilityEnd:           result.PeriodLiabilityEnd,
+			PeriodRoUAssetStart:          result.PeriodRoUAssetStart,
+			PeriodRoUAssetEnd:            result.PeriodRoUAssetEnd,
+			PeriodInterestExpense:        result.PeriodInterestExpense,
+			PeriodDepreciation:           result.PeriodDepreciation,
+			PeriodPayments:               result.PeriodPayments,
+			PeriodPrincipalPayment:       result.PeriodPrincipalPayment,
+			PeriodInterestPaid:           result.PeriodInterestPaid,
+			PeriodVariablePayments:       result.PeriodVariablePayments,
+			PeriodExemptLeaseExpense:     result.PeriodExemptLeaseExpense,
+			PeriodNonLeaseExpense:        result.PeriodNonLeaseExpense,
+			PeriodProvisionStart:         result.PeriodProvisionStart,
+			PeriodProvisionEnd:           result.PeriodProvisionEnd,
+			PeriodProvisionUnwinding:     result.PeriodProvisionUnwinding,
+			PeriodLiabilityRemeasurement: result.PeriodLiabilityRemeasurement,
+			PeriodRoUAssetRemeasurement:  result.PeriodRoUAssetRemeasurement,
+			PeriodRemeasurementGainLoss:  result.PeriodRemeasurementGainLoss,
+			PeriodLeasebackGainLoss:      result.PeriodLeasebackGainLoss,
+			PeriodImpairment:             result.PeriodImpairment,
+			PeriodImpairmentStart:        result.PeriodImpairmentStart,
+			PeriodImpairmentEnd:          result.PeriodImpairmentEnd,
+			PeriodNetInvestmentStart:     result.PeriodNetInvestmentStart,
+			PeriodNetInvestmentEnd:       result.PeriodNetInvestmentEnd,
+			PeriodFinanceIncome:          result.PeriodFinanceIncome,
+			PeriodOperatingLeaseIncome:   result.PeriodOperatingLeaseIncome,
+			PeriodLeaseReceipts:          result.PeriodLeaseReceipts,
+			PeriodAssetStart:             result.PeriodAssetStart,
+			PeriodAssetEnd:               result.PeriodAssetEnd,
+			PeriodAssetDepreciation:      result.PeriodAssetDepreciation,
 		}
 
 		exportResults = append(exportResults, exportResult)
@@ -475,177 +809,220 @@ func handleExport(w http.ResponseWriter, r *http.Request) {
 	w.Write(excelBytes)
 }
 
-// sendJSONError is a helper to return errors as JSON responses.
-func sendJSONError(w http.ResponseWriter, message string, status int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	errorResponse := map[string]string{"error": message}
-	if err := json.NewEncoder(w).Encode(errorResponse); err != nil {
-		// Log error internally if we can't even send the JSON error message
-		log.Printf("Error encoding JSON error response: %v", err)
+// presentResult adds the accounting period summary to a result, if a period is given, and
+// then rolls its daily schedules up to the requested granularity. The summary is taken from
+// the daily schedules before they are rolled up. The lease supplies the payment periods and
+// carries the terms in force at the end of the schedules.
+func presentResult(result *calculation.CalculationResult, l lease.Lease, periodStart, periodEnd string, granularity calculation.Granularity, fiscal calculation.FiscalCalendar) {
+	// 如果提供了账期范围,计算账期摘要
+	if periodStart != "" && periodEnd != "" {
+		if err := calculation.CalculateAccountingPeriodSummary(result, periodStart, periodEnd); err != nil {
+			log.Printf("计算账期摘要时出错: %v", err)
+			// 不需要中断,将错误添加到结果中即可
+			if result.Error == "" {
+				result.Error = fmt.Sprintf("账期摘要计算错误: %v", err)
+			} else {
+				result.Error += fmt.Sprintf("; 账期摘要计算错误: %v", err)
+			}
+		}
 	}
+
+	schedules := []*[]calculation.AmortizationEntry{&result.LiabilitySchedule, &result.RoUAssetSchedule, &result.RestorationSchedule, &result.ExpenseSchedule,
+		&result.NetInvestmentSchedule, &result.IncomeSchedule, &result.AssetSchedule}
+	for _, schedule := range schedules {
+		if len(*schedule) == 0 {
+			continue
+		}
+		rolled, err := calculation.RollUpSchedule(l, *schedule, granularity, fiscal)
+		if err != nil {
+			log.Printf("Error rolling up schedules for lease %s: %v", l.ID, err)
+			result.Error = fmt.Sprintf("Schedule granularity error: %v", err)
+			break
+		}
+		*schedule = rolled
+	}
+	result.ScheduleGranularity = string(granularity)
 }
 
-// calculateAccountingPeriodSummary 计算指定账期的摘要数据
-func calculateAccountingPeriodSummary(result *CalculationResult, periodStart, periodEnd string) error {
-	// 解析日期
-	start, err := time.Parse("2006-01-02", periodStart)
+// calculateLessorLease classifies a lease the entity grants and adds the lessor's measurement
+// to the result.
+func calculateLessorLease(result *calculation.CalculationResult, l lease.Lease) error {
+	classification, indicators, err := calculation.ClassifyLessorLease(l)
 	if err != nil {
-		return fmt.Errorf("无效的账期开始日期: %v", err)
+		return err
 	}
+	result.Classification = classification
+	result.ClassificationIndicators = indicators
+	return measureLessorLease(result, l)
+}
 
-	end, err := time.Parse("2006-01-02", periodEnd)
+// calculateSublease classifies a sublease by reference to the RoU asset of its head lease and
+// adds the intermediate lessor's measurement to the result. A finance sublease derecognises
+// the part of the head lease's RoU asset it sublets, which adjusts the head lease's result
+// and reduces retained, the share of its asset not yet sublet; the gain or loss is measured
+// against the carrying amount derecognised. Under an operating sublease the head lease's RoU
+// asset is kept and depreciated there.
+func calculateSublease(result *calculation.CalculationResult, sub lease.Lease, head *calculation.CalculationResult, headLease lease.Lease, retained *float64) error {
+	classification, indicators, err := calculation.ClassifySublease(sub, headLease)
 	if err != nil {
-		return fmt.Errorf("无效的账期结束日期: %v", err)
+		return err
 	}
+	result.Classification = classification
+	result.ClassificationIndicators = indicators
 
-	// 验证日期范围
-	if end.Before(start) {
-		return fmt.Errorf("账期结束日期不能早于开始日期")
+	sub.CarryingAmount = 0 // The asset is the head lease's RoU asset
+	if err := measureLessorLease(result, sub); err != nil {
+		return err
 	}
-
-	// 设置账期信息
-	result.AccountingPeriodStart = periodStart
-	result.AccountingPeriodEnd = periodEnd
-
-	// 处理租赁负债表
-	if len(result.LiabilitySchedule) > 0 {
-		var startBalance, endBalance, totalPayments, totalInterest, totalPrincipal float64
-		var startFound, endFound bool
-
-		// 寻找账期起始的账面值
-		for i, entry := range result.LiabilitySchedule {
-			entryDate := entry.Date
-
-			// 寻找账期开始日期的账面值(使用最接近的前一个日期)
-			if !startFound && (entryDate.Equal(start) || entryDate.After(start)) {
-				if i > 0 && entryDate.After(start) {
-					// 使用前一个条目的收盘余额作为起始余额
-					startBalance = result.LiabilitySchedule[i-1].ClosingBalance
-				} else {
-					startBalance = entry.OpeningBalance
-				}
-				startFound = true
-			}
-
-			// 累计账期内的数据
-			if (entryDate.Equal(start) || entryDate.After(start)) &&
-				(entryDate.Equal(end) || entryDate.Before(end)) {
-
-				// 累计利息费用（每天都要累计）
-				totalInterest += entry.InterestExpense
-
-				// 只在付款日累计付款和本金
-				if entry.Payment > 0 {
-					totalPayments += entry.Payment
-					totalPrincipal += entry.PrincipalRepayment
-				}
-			}
-
-			// 寻找账期结束日期的账面值(使用最接近的前一个日期的收盘余额)
-			if entryDate.Equal(end) || (entryDate.After(end) && !endFound) {
-				if entryDate.After(end) && i > 0 {
-					// 使用前一个条目的收盘余额
-					endBalance = result.LiabilitySchedule[i-1].ClosingBalance
-				} else {
-					// 如果正好等于结束日期，使用当天的收盘余额
-					endBalance = entry.ClosingBalance
-				}
-				endFound = true
-			}
-		}
-
-		// 如果未找到结束值,使用最后一个条目的收盘余额
-		if !endFound && len(result.LiabilitySchedule) > 0 {
-			lastEntry := result.LiabilitySchedule[len(result.LiabilitySchedule)-1]
-			endBalance = lastEntry.ClosingBalance
-		}
-
-		// 验证本金与余额变动的一致性
-		balanceChange := startBalance - endBalance
-		if math.Abs(balanceChange-totalPrincipal) > 0.01 {
-			// 如果有差异，使用余额变动作为本金
-			totalPrincipal = balanceChange
+	if classification == lease.FinanceLease {
+		derecognised, remaining, err := calculation.DerecogniseSubleasedRoUAsset(head, sub, *retained)
+		if err != nil {
+			return err
 		}
+		*retained = remaining
+		sub.CarryingAmount = derecognised
+		result.SubleasedRoUAsset = derecognised
+		result.DerecognitionGainLoss = calculation.FinanceLeaseGainLoss(sub, result.NetInvestment)
+	}
+	return nil
+}
 
-		// 验证本金+利息=付款总额
-		if math.Abs((totalPrincipal+totalInterest)-totalPayments) > 0.01 {
-			// 如果有差异，调整利息使总额相等
-			totalInterest = totalPayments - totalPrincipal
+// measureLessorLease adds a lessor's measurement of a classified lease to the result: the net
+// investment and its schedule for a finance lease, or the straight-line income and the
+// depreciation of the underlying asset for an operating lease.
+func measureLessorLease(result *calculation.CalculationResult, l lease.Lease) error {
+	if result.Classification == lease.OperatingLease {
+		incomeSchedule, err := calculation.OperatingLeaseIncomeSchedule(l)
+		if err != nil {
+			return err
 		}
-
-		// 使用math.Round来四舍五入数值到2位小数
-		roundTo2Decimals := func(val float64) float64 {
-			return math.Round(val*100) / 100
+		assetSchedule, err := calculation.UnderlyingAssetSchedule(l)
+		if err != nil {
+			return err
 		}
-
-		result.PeriodLiabilityStart = roundTo2Decimals(startBalance)
-		result.PeriodLiabilityEnd = roundTo2Decimals(endBalance)
-		result.PeriodInterestExpense = roundTo2Decimals(totalInterest)
-		result.PeriodPayments = roundTo2Decimals(totalPayments)
-		result.PeriodPrincipalPayment = roundTo2Decimals(totalPrincipal)
+		result.IncomeSchedule = incomeSchedule
+		result.AssetSchedule = assetSchedule
+		return nil
 	}
 
-	// 处理使用权资产表
-	if len(result.RoUAssetSchedule) > 0 {
-		var startBalance, endBalance, totalDepreciation float64
-		var startFound, endFound bool
+	dayCount, rateBasis, err := calculation.LeaseConventions(l)
+	if err != nil {
+		return err
+	}
+	result.DayCountConvention = string(dayCount)
+	result.RateBasis = string(rateBasis)
 
-		// 寻找账期起始的账面值
-		for i, entry := range result.RoUAssetSchedule {
-			entryDate := entry.Date
+	rate, err := calculation.ImplicitRate(l)
+	if err != nil {
+		return err
+	}
+	netInvestment, err := calculation.CalculateNetInvestment(l, rate)
+	if err != nil {
+		return err
+	}
+	schedule, err := calculation.GenerateNetInvestmentSchedule(l, rate, netInvestment)
+	if err != nil {
+		return err
+	}
+	result.DiscountRate = rate // The rate implicit in the lease
+	result.NetInvestment = netInvestment
+	result.NetInvestmentSchedule = schedule
+	if l.CarryingAmount > 0 {
+		result.DerecognitionGainLoss = calculation.FinanceLeaseGainLoss(l, netInvestment)
+	}
+	return nil
+}
 
-			// 寻找账期开始日期的账面值
-			if !startFound && (entryDate.Equal(start) || entryDate.After(start)) {
-				if i > 0 && entryDate.After(start) {
-					// 使用前一个条目的收盘余额作为起始余额
-					startBalance = result.RoUAssetSchedule[i-1].ClosingBalance
-				} else {
-					startBalance = entry.OpeningBalance
-				}
-				startFound = true
-			}
+// exemptionPolicyFor reads the recognition exemptions elected on the calculation form. Asset
+// classes are comma-separated; "*" elects an exemption for every class.
+func exemptionPolicyFor(r *http.Request) (calculation.ExemptionPolicy, error) {
+	policy := calculation.ExemptionPolicy{
+		ShortTermClasses: splitList(r.FormValue("shortTermClasses")),
+		LowValueClasses:  splitList(r.FormValue("lowValueClasses")),
+		LowValueCurrency: strings.ToUpper(strings.TrimSpace(r.FormValue("lowValueCurrency"))),
+	}
+	if threshold := strings.TrimSpace(r.FormValue("lowValueThreshold")); threshold != "" {
+		amount, err := money.Parse(threshold)
+		if err != nil || amount <= 0 {
+			return policy, fmt.Errorf("invalid low-value threshold: %s", threshold)
+		}
+		policy.LowValueThreshold = amount
+	}
+	return policy, nil
+}
 
-			// 累计账期内的折旧
-			if (entryDate.Equal(start) || entryDate.After(start)) &&
-				(entryDate.Equal(end) || entryDate.Before(end)) {
-				totalDepreciation += entry.Depreciation
-			}
+// transitionPolicyFor reads the transition approach and practical expedients chosen on the
+// calculation form; there is no policy when no approach is chosen. Portfolio rates are given
+// as comma-separated CLASS=RATE pairs, a class of "*" covering every class.
+func transitionPolicyFor(r *http.Request) (*calculation.TransitionPolicy, error) {
+	method := strings.TrimSpace(r.FormValue("transitionMethod"))
+	if method == "" {
+		return nil, nil
+	}
+	date := strings.TrimSpace(r.FormValue("initialApplicationDate"))
+	initialApplication, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date of initial application: %q", date)
+	}
 
-			// 寻找账期结束日期的账面值
-			if entryDate.Equal(end) || (entryDate.After(end) && !endFound) {
-				if entryDate.After(end) && i > 0 {
-					// 使用前一个条目的收盘余额
-					endBalance = result.RoUAssetSchedule[i-1].ClosingBalance
-				} else {
-					// 如果正好等于结束日期，使用当天的收盘余额
-					endBalance = entry.ClosingBalance
-				}
-				endFound = true
-			}
+	policy := calculation.TransitionPolicy{
+		Method:                    calculation.TransitionMethod(method),
+		InitialApplication:        initialApplication,
+		ExcludeInitialDirectCosts: r.FormValue("excludeInitialDirectCosts") == "on",
+		Hindsight:                 r.FormValue("hindsight") == "on",
+	}
+	for _, item := range splitList(r.FormValue("portfolioRates")) {
+		class, value, ok := strings.Cut(item, "=")
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || strings.TrimSpace(class) == "" || err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid portfolio rate: %s", item)
 		}
-
-		// 如果未找到结束值,使用最后一个条目的收盘余额
-		if !endFound && len(result.RoUAssetSchedule) > 0 {
-			lastEntry := result.RoUAssetSchedule[len(result.RoUAssetSchedule)-1]
-			endBalance = lastEntry.ClosingBalance
+		if policy.PortfolioRates == nil {
+			policy.PortfolioRates = make(map[string]float64)
 		}
+		policy.PortfolioRates[strings.TrimSpace(class)] = rate
+	}
+	return &policy, nil
+}
 
-		// 验证折旧总额与余额变动的一致性
-		calculatedDepreciation := startBalance - endBalance
-		if math.Abs(calculatedDepreciation-totalDepreciation) > 0.01 {
-			totalDepreciation = calculatedDepreciation
+// splitList splits a comma-separated form value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
+	}
+	return items
+}
 
-		// 使用math.Round来四舍五入数值到2位小数
-		roundTo2Decimals := func(val float64) float64 {
-			return math.Round(val*100) / 100
-		}
+// fiscalCalendarFor returns fiscal periods repeating the accounting period from its start
+// date. A period that is not a whole number of months falls back to years from its start.
+func fiscalCalendarFor(periodStart, periodEnd string) calculation.FiscalCalendar {
+	start, err := time.Parse("2006-01-02", periodStart)
+	if err != nil {
+		return calculation.FiscalCalendar{}
+	}
+	end, err := time.Parse("2006-01-02", periodEnd)
+	if err != nil {
+		return calculation.FiscalCalendar{Start: start}
+	}
 
-		result.PeriodRoUAssetStart = roundTo2Decimals(startBalance)
-		result.PeriodRoUAssetEnd = roundTo2Decimals(endBalance)
-		result.PeriodDepreciation = roundTo2Decimals(totalDepreciation)
+	next := end.AddDate(0, 0, 1)
+	months := (next.Year()-start.Year())*12 + int(next.Month()) - int(start.Month())
+	if months <= 0 || !start.AddDate(0, months, 0).Equal(next) {
+		months = 12
 	}
+	return calculation.FiscalCalendar{Start: start, Months: months}
+}
 
-	return nil
+// sendJSONError is a helper to return errors as JSON responses.
+func sendJSONError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	errorResponse := map[string]string{"error": message}
+	if err := json.NewEncoder(w).Encode(errorResponse); err != nil {
+		// Log error internally if we can't even send the JSON error message
+		log.Printf("Error encoding JSON error response: %v", err)
+	}
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"ifrs16_calculator/internal/calculation"
+	"ifrs16_calculator/internal/money"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleCalculateRateFromCurves(t *testing.T) {
+	leases := `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,Currency,Entity,NonLeaseComponents
+L-SVC,2024-01-01,2026-12-31,1000,Monthly,,USD,Acme Ltd,Service charge:200:
+L-OWN,2024-01-01,2026-12-31,1000,Monthly,0.08,USD,Acme Ltd,Service charge:200:`
+	curves := `Component,Name,Currency,Date,Tenor,Rate
+RiskFree,,USD,2023-12-29,3Y,4%
+Spread,Acme Ltd,USD,2023-12-29,,1.5%`
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for field, content := range map[string]string{"leaseFile": leases, "rateCurveFile": curves} {
+		part, err := form.CreateFormFile(field, field+".csv")
+		if err != nil {
+			t.Fatalf("CreateFormFile() error = %v", err)
+		}
+		part.Write([]byte(content))
+	}
+	form.WriteField("skipHeader", "on")
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	handleCalculate(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("handleCalculate() status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	var results []calculation.CalculationResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("handleCalculate() returned %d results, want 2", len(results))
+	}
+
+	// The blank rate is built from the curves before the service charge is separated
+	derived := results[0]
+	if derived.Error != "" {
+		t.Fatalf("Lease %s error = %s", derived.LeaseID, derived.Error)
+	}
+	if derived.DiscountRate != 0.055 || derived.DiscountRateSource == nil {
+		t.Errorf("Lease %s rate = %v (source %+v), want 5.5%% from the curves", derived.LeaseID, derived.DiscountRate, derived.DiscountRateSource)
+	}
+	if len(derived.NonLeasePayments) != 36 || derived.NonLeasePayments[0].Amount != 200*money.Unit {
+		t.Errorf("Lease %s non-lease payments = %+v, want 36 of 200", derived.LeaseID, derived.NonLeasePayments)
+	}
+
+	// A rate given with the lease is kept
+	given := results[1]
+	if given.Error != "" || given.DiscountRate != 0.08 || given.DiscountRateSource != nil {
+		t.Errorf("Lease %s = rate %v, source %+v, error %q, want its own 8%%", given.LeaseID, given.DiscountRate, given.DiscountRateSource, given.Error)
+	}
+}
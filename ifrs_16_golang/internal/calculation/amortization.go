@@ -3,6 +3,7 @@ package calculation
 import (
 	"fmt"
 	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
 	"math"
 	"sort"
 	"time"
@@ -10,262 +11,376 @@ import (
 
 // AmortizationEntry represents a single period's entry in an amortization schedule.
 type AmortizationEntry struct {
-	Period             int       `json:"period"`                       // Period number (1, 2, ...)
-	Date               time.Time `json:"date"`                         // Date of the period end/payment
-	OpeningBalance     float64   `json:"openingBalance"`               // Liability/Asset value at the start of the period
-	Payment            float64   `json:"payment,omitempty"`            // Payment made (relevant for liability schedule)
-	InterestExpense    float64   `json:"interestExpense,omitempty"`    // Interest expense for the period (liability schedule)
-	Depreciation       float64   `json:"depreciation,omitempty"`       // Depreciation expense for the period (RoU asset schedule)
-	PrincipalRepayment float64   `json:"principalRepayment,omitempty"` // Principal portion of the payment (liability schedule)
-	ClosingBalance     float64   `json:"closingBalance"`               // Liability/Asset value at the end of the period
+	Period             int          `json:"period"`                       // Period number (1, 2, ...)
+	Date               time.Time    `json:"date"`                         // Date of the period end/payment
+	OpeningBalance     money.Amount `json:"openingBalance"`               // Liability/Asset value at the start of the period
+	Payment            money.Amount `json:"payment,omitempty"`            // Payment made (relevant for liability schedule)
+	ResidualValue      money.Amount `json:"residualValue,omitempty"`      // Part of the payment made under a residual value guarantee (liability schedule)
+	InterestExpense    money.Amount `json:"interestExpense,omitempty"`    // Interest expense for the period (liability schedule)
+	Depreciation       money.Amount `json:"depreciation,omitempty"`       // Depreciation expense for the period (RoU asset schedule)
+	Expense            money.Amount `json:"expense,omitempty"`            // Straight-line lease expense for the period (exempt lease expense schedule)
+	Income             money.Amount `json:"income,omitempty"`             // Finance income or straight-line lease income for the period (lessor schedules)
+	PrincipalRepayment money.Amount `json:"principalRepayment,omitempty"` // Principal portion of the payment (liability schedule)
+	ClosingBalance     money.Amount `json:"closingBalance"`               // Liability/Asset value at the end of the period
+	Remeasurement      money.Amount `json:"remeasurement,omitempty"`      // Remeasurement or modification adjustment booked at the start of the period
+	Impairment         money.Amount `json:"impairment,omitempty"`         // Impairment loss (positive) or reversal (negative) booked at the start of the period (RoU asset schedule)
+	UnitsUsed          float64      `json:"unitsUsed,omitempty"`          // Forecast use of the asset (units-of-production RoU asset schedule)
 }
 
 // CalculationResult holds the calculated outputs for a single lease.
 type CalculationResult struct {
-	LeaseID                string              `json:"leaseId"`
-	InitialLiability       float64             `json:"initialLiability"`
-	InitialRoUAsset        float64             `json:"initialRoUAsset"`
-	DiscountRate           float64             `json:"discountRate"`
-	PaymentAmount          float64             `json:"paymentAmount"`
-	PaymentFrequency       string              `json:"paymentFrequency"`
-	StartDate              string              `json:"startDate"`
-	EndDate                string              `json:"endDate"`
-	LiabilitySchedule      []AmortizationEntry `json:"liabilitySchedule"`
-	RoUAssetSchedule       []AmortizationEntry `json:"rouAssetSchedule"`
-	Error                  string              `json:"error,omitempty"`
-	AccountingPeriodStart  string              `json:"accountingPeriodStart,omitempty"`
-	AccountingPeriodEnd    string              `json:"accountingPeriodEnd,omitempty"`
-	PeriodLiabilityStart   float64             `json:"periodLiabilityStart,omitempty"`
-	PeriodLiabilityEnd     float64             `json:"periodLiabilityEnd,omitempty"`
-	PeriodRoUAssetStart    float64             `json:"periodRoUAssetStart,omitempty"`
-	PeriodRoUAssetEnd      float64             `json:"periodRoUAssetEnd,omitempty"`
-	PeriodInterestExpense  float64             `json:"periodInterestExpense,omitempty"`
-	PeriodDepreciation     float64             `json:"periodDepreciation,omitempty"`
-	PeriodPayments         float64             `json:"periodPayments,omitempty"`
-	PeriodPrincipalPayment float64             `json:"periodPrincipalPayment,omitempty"`
+	LeaseID              string                 `json:"leaseId"`
+	InitialLiability     money.Amount           `json:"initialLiability"`
+	InitialRoUAsset      money.Amount           `json:"initialRoUAsset"`
+	RoUAssetComponents   *RoUAssetComponents    `json:"rouAssetComponents,omitempty"` // How the initial RoU asset was derived
+	SaleAndLeaseback     *SaleAndLeaseback      `json:"saleAndLeaseback,omitempty"`   // Sale of the asset a leaseback leases back
+	DiscountRate         float64                `json:"discountRate"`
+	PaymentAmount        money.Amount           `json:"paymentAmount"`
+	PaymentFrequency     string                 `json:"paymentFrequency"`
+	PaymentTiming        string                 `json:"paymentTiming,omitempty"`
+	DayCountConvention   string                 `json:"dayCountConvention,omitempty"` // Day count the lease was measured on
+	RateBasis            string                 `json:"rateBasis,omitempty"`          // Whether DiscountRate is nominal or effective annual
+	StartDate            string                 `json:"startDate"`
+	EndDate              string                 `json:"endDate"`
+	ContractEndDate      string                 `json:"contractEndDate,omitempty"`     // End date before extension and termination options
+	Options              []lease.Option         `json:"options,omitempty"`             // Extension and termination options as last assessed
+	DepreciationEndDate  string                 `json:"depreciationEndDate,omitempty"` // Last day the RoU asset is depreciated
+	DepreciationMethod   string                 `json:"depreciationMethod,omitempty"`  // How the RoU asset is depreciated
+	LiabilitySchedule    []AmortizationEntry    `json:"liabilitySchedule"`
+	RoUAssetSchedule     []AmortizationEntry    `json:"rouAssetSchedule"`
+	RestorationProvision money.Amount           `json:"restorationProvision,omitempty"` // Initial IAS 37 restoration provision
+	RestorationSchedule  []AmortizationEntry    `json:"restorationSchedule,omitempty"`  // Unwinding of the restoration provision
+	ResidualGuarantee    money.Amount           `json:"residualGuarantee,omitempty"`    // Amount initially expected to be payable under a residual value guarantee
+	VariablePayments     []lease.ExtraPayment   `json:"variablePayments,omitempty"`     // Variable payments expensed as incurred
+	Remeasurements       []Remeasurement        `json:"remeasurements,omitempty"`       // Modifications and other remeasurements applied to the schedules
+	Impairments          []ImpairmentAdjustment `json:"impairments,omitempty"`          // IAS 36 impairment losses and reversals of the RoU asset
+	ScheduleGranularity  string                 `json:"scheduleGranularity,omitempty"`  // Span covered by each schedule entry
+	AssetClass           string                 `json:"assetClass,omitempty"`           // Class of underlying asset
+	Exemption            Exemption              `json:"exemption,omitempty"`            // Recognition exemption applied instead of the liability and RoU asset
+	ExpenseSchedule      []AmortizationEntry    `json:"expenseSchedule,omitempty"`      // Straight-line expense of an exempt lease
+	// Regular payments are split into the lease component and the non-lease components,
+	// which are expensed (IFRS 16.12); ComponentsCombined is set when the practical expedient
+	// of IFRS 16.15 is elected instead.
+	NonLeasePayments   []lease.ScheduledPayment `json:"nonLeasePayments,omitempty"`
+	ComponentsCombined bool                     `json:"componentsCombined,omitempty"`
+	// A lessor classifies the lease (IFRS 16.61-66). A finance lease is measured as a net
+	// investment earning finance income at the implicit rate, held in DiscountRate; under an
+	// operating lease income is recognised straight-line and the underlying asset depreciated.
+	Role                     lease.Role           `json:"role,omitempty"`
+	Classification           lease.Classification `json:"classification,omitempty"`
+	ClassificationIndicators []string             `json:"classificationIndicators,omitempty"` // Indicators of a finance lease met
+	NetInvestment            money.Amount         `json:"netInvestment,omitempty"`
+	NetInvestmentSchedule    []AmortizationEntry  `json:"netInvestmentSchedule,omitempty"`
+	DerecognitionGainLoss    money.Amount         `json:"derecognitionGainLoss,omitempty"` // Gain or loss on derecognising the asset of a finance lease
+	IncomeSchedule           []AmortizationEntry  `json:"incomeSchedule,omitempty"`        // Straight-line income of an operating lease
+	AssetSchedule            []AmortizationEntry  `json:"assetSchedule,omitempty"`         // Depreciation of the asset under an operating lease
+	// A sublease is classified by reference to the RoU asset of its head lease; a finance
+	// sublease derecognises the part of that asset it sublets (IFRS 16.B58).
+	HeadLeaseID       string       `json:"headLeaseId,omitempty"`
+	SubleasedRoUAsset money.Amount `json:"subleasedRoUAsset,omitempty"` // Carrying amount of the head lease's RoU asset derecognised
+	// A lease in place at the date of initial application of IFRS 16 is also measured under
+	// the transition provisions (IFRS 16.C5-C10), giving its opening retained earnings adjustment.
+	Transition *TransitionAdjustment `json:"transition,omitempty"`
+	// A discount rate built from yield curves records the rates it was built from.
+	DiscountRateSource *lease.RateProvenance `json:"discountRateSource,omitempty"`
+	// 账期摘要信息
+	AccountingPeriodStart        string       `json:"accountingPeriodStart,omitempty"`        // 账期开始日期
+	AccountingPeriodEnd          string       `json:"accountingPeriodEnd,omitempty"`          // 账期结束日期
+	PeriodLiabilityStart         money.Amount `json:"periodLiabilityStart,omitempty"`         // 账期期初负债
+	PeriodLiabilityEnd           money.Amount `json:"periodLiabilityEnd,omitempty"`           // 账期期末负债
+	PeriodRoUAssetStart          money.Amount `json:"periodRoUAssetStart,omitempty"`          // 账期期初使用权资产
+	PeriodRoUAssetEnd            money.Amount `json:"periodRoUAssetEnd,omitempty"`            // 账期期末使用权资产
+	PeriodInterestExpense        money.Amount `json:"periodInterestExpense,omitempty"`        // 账期内利息费用总额
+	PeriodDepreciation           money.Amount `json:"periodDepreciation,omitempty"`           // 账期内折旧费用总额
+	PeriodPayments               money.Amount `json:"periodPayments,omitempty"`               // 账期内付款总额
+	PeriodPrincipalPayment       money.Amount `json:"periodPrincipalPayment,omitempty"`       // 账期内本金偿还总额
+	PeriodInterestPaid           money.Amount `json:"periodInterestPaid,omitempty"`           // 账期内付款中支付的利息
+	PeriodVariablePayments       money.Amount `json:"periodVariablePayments,omitempty"`       // 账期内计入费用的可变租赁付款额
+	PeriodExemptLeaseExpense     money.Amount `json:"periodExemptLeaseExpense,omitempty"`     // 账期内豁免租赁按直线法确认的租赁费用
+	PeriodNonLeaseExpense        money.Amount `json:"periodNonLeaseExpense,omitempty"`        // 账期内非租赁组成部分(服务费、维护费等)计入的经营费用
+	PeriodProvisionStart         money.Amount `json:"periodProvisionStart,omitempty"`         // 账期期初复原准备金
+	PeriodProvisionEnd           money.Amount `json:"periodProvisionEnd,omitempty"`           // 账期期末复原准备金
+	PeriodProvisionUnwinding     money.Amount `json:"periodProvisionUnwinding,omitempty"`     // 账期内复原准备金折现摊销(财务费用)
+	PeriodLiabilityRemeasurement money.Amount `json:"periodLiabilityRemeasurement,omitempty"` // 账期内租赁负债重新计量调整
+	PeriodRoUAssetRemeasurement  money.Amount `json:"periodRoUAssetRemeasurement,omitempty"`  // 账期内使用权资产调整
+	PeriodRemeasurementGainLoss  money.Amount `json:"periodRemeasurementGainLoss,omitempty"`  // 账期内租赁变更确认的损益
+	PeriodLeasebackGainLoss      money.Amount `json:"periodLeasebackGainLoss,omitempty"`      // 账期内售后租回转让权利的利得或损失
+	PeriodImpairment             money.Amount `json:"periodImpairment,omitempty"`             // 账期内使用权资产减值损失(转回为负数)
+	PeriodImpairmentStart        money.Amount `json:"periodImpairmentStart,omitempty"`        // 账期期初累计减值准备
+	PeriodImpairmentEnd          money.Amount `json:"periodImpairmentEnd,omitempty"`          // 账期期末累计减值准备
+	PeriodNetInvestmentStart     money.Amount `json:"periodNetInvestmentStart,omitempty"`     // 账期期初融资租赁投资净额
+	PeriodNetInvestmentEnd       money.Amount `json:"periodNetInvestmentEnd,omitempty"`       // 账期期末融资租赁投资净额
+	PeriodFinanceIncome          money.Amount `json:"periodFinanceIncome,omitempty"`          // 账期内融资租赁利息收入
+	PeriodOperatingLeaseIncome   money.Amount `json:"periodOperatingLeaseIncome,omitempty"`   // 账期内经营租赁按直线法确认的租赁收入
+	PeriodLeaseReceipts          money.Amount `json:"periodLeaseReceipts,omitempty"`          // 账期内收到的租赁收款额
+	PeriodAssetStart             money.Amount `json:"periodAssetStart,omitempty"`             // 账期期初经营租出资产账面价值
+	PeriodAssetEnd               money.Amount `json:"periodAssetEnd,omitempty"`               // 账期期末经营租出资产账面价值
+	PeriodAssetDepreciation      money.Amount `json:"periodAssetDepreciation,omitempty"`      // 账期内经营租出资产折旧
+	Error                        string       `json:"error,omitempty"`                        // To report errors for specific leases
 }
 
-// GenerateLiabilitySchedule creates the amortization schedule for the lease liability.
-func GenerateLiabilitySchedule(l lease.Lease, initialLiability float64) ([]AmortizationEntry, error) {
-	// Get the standard periods first (for payment dates calculation)
-	originalPeriods, _, err := getPeriodsAndRate(l)
+// liabilityCashFlow is a single dated payment settled against the lease liability.
+type liabilityCashFlow struct {
+	date          time.Time
+	position      float64 // Payment periods elapsed since commencement
+	amount        money.Amount
+	nonLease      money.Amount // Part of a regular payment for non-lease components, not settled against the liability
+	residualValue bool         // Payable under a residual value guarantee
+}
+
+// liabilityCashFlows returns the payments of a lease settled against its liability (see
+// paymentCashFlows), together with the number of regular periods and the periodic discount
+// rate.
+func liabilityCashFlows(l lease.Lease) ([]liabilityCashFlow, int, float64, error) {
+	periods, periodicRate, err := getPeriodsAndRate(l)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get periods and rate for schedule: %w", err)
+		return nil, 0, 0, err
 	}
-
-	if originalPeriods == 0 {
-		return []AmortizationEntry{}, nil
+	flows, err := paymentCashFlows(l, periods)
+	if err != nil {
+		return nil, 0, 0, err
 	}
+	return flows, periods, periodicRate, nil
+}
 
-	// Calculate the daily interest rate
-	annualRate := l.DiscountRate
-	dailyRate := annualRate / 365.0
-
-	// Calculate total days between start and end date
-	totalDays := int(l.EndDate.Sub(l.StartDate).Hours()/24) + 1
-
-	if totalDays <= 0 {
-		return []AmortizationEntry{}, nil
+// paymentCashFlows returns the regular payments over a number of regular periods, the fixed
+// extra payments, the penalty of an exercised termination option, the exercise price of a
+// purchase option reasonably certain to be exercised and the amount expected to be payable
+// under a residual value guarantee of a lease in settlement order. Only the lease component of
+// the regular payments is included (IFRS 16.12). The payments do not depend on the discount
+// rate, so leases recognised straight-line need none.
+func paymentCashFlows(l lease.Lease, periods int) ([]liabilityCashFlow, error) {
+	if err := validateComponents(l); err != nil {
+		return nil, err
 	}
-
-	// Determine the original payment schedule for reference
-	var monthsPerPeriod int
-	switch l.PaymentFrequency {
-	case lease.Monthly:
-		monthsPerPeriod = 1
-	case lease.Quarterly:
-		monthsPerPeriod = 3
-	case lease.Annually:
-		monthsPerPeriod = 12
-	default:
-		return nil, fmt.Errorf("invalid frequency in schedule generation: %s", l.PaymentFrequency)
+	cycle, err := paymentCycleFor(l)
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate payment dates based on original schedule
-	type scheduledPayment struct {
-		date   time.Time
-		amount float64
+	// Regular payments in arrears fall at the end of periods 1..n. In advance they fall at
+	// the start of periods 0..n-1, and the commencement payment is not part of the liability.
+	last := periods
+	if l.PaysInAdvance() {
+		last = periods - 1
 	}
 
-	// Create a map to store all payments by date (regular + extra)
-	payments := make(map[time.Time]float64)
-
-	// Add regular payments
-	currentPaymentDate := l.StartDate
-	for i := 1; i <= originalPeriods; i++ {
-		currentPaymentDate = currentPaymentDate.AddDate(0, monthsPerPeriod, 0)
-		if currentPaymentDate.After(l.EndDate) {
-			currentPaymentDate = l.EndDate
+	flows := make([]liabilityCashFlow, 0, periods+len(l.ExtraPayments))
+	if cycle.exact {
+		// An irregular calendar is settled on its own dates; payments at or before
+		// commencement belong to the RoU asset.
+		last = 0
+		for _, scheduled := range l.PaymentSchedule {
+			if !scheduled.Date.After(l.StartDate) || scheduled.Amount == 0 {
+				continue
+			}
+			flow, err := regularCashFlow(l, scheduled.Date, periodPosition(l, cycle, periods, scheduled.Date), scheduled.Amount)
+			if err != nil {
+				return nil, err
+			}
+			flows = append(flows, flow)
 		}
-
-		// If we already have a payment on this date, add to it
-		payments[currentPaymentDate] += l.PaymentAmount
+	}
+	for i := 1; i <= last; i++ {
+		paymentDate := cycle.date(l.StartDate, i)
+		if paymentDate.After(l.EndDate) {
+			paymentDate = l.EndDate
+		}
+		amount := regularPayment(l, cycle, i)
+		if amount == 0 {
+			continue // Rent-free period
+		}
+		flow, err := regularCashFlow(l, paymentDate, periodPosition(l, cycle, periods, paymentDate), amount)
+		if err != nil {
+			return nil, err
+		}
+		flows = append(flows, flow)
 	}
 
-	// Add extra payments from the lease model if available
 	for _, extra := range l.ExtraPayments {
-		if !extra.Date.Before(l.StartDate) && !extra.Date.After(l.EndDate) {
-			payments[extra.Date] += extra.Amount
+		if !extra.InLiability(l.StartDate, l.EndDate) {
+			continue
 		}
+		flows = append(flows, liabilityCashFlow{
+			date:     extra.Date,
+			position: periodPosition(l, cycle, periods, extra.Date),
+			amount:   extra.Amount,
+		})
+	}
+
+	// A termination penalty is payable when the lease term reflects exercising the option
+	// (IFRS 16.27(e))
+	if termination, ok := l.ExercisedTermination(); ok && termination.Penalty > 0 && termination.EndDate.Equal(l.EndDate) {
+		flows = append(flows, liabilityCashFlow{
+			date:     l.EndDate,
+			position: periodPosition(l, cycle, periods, l.EndDate),
+			amount:   termination.Penalty,
+		})
 	}
 
-	// Special case for the example data in screenshot
-	// Add $100,000 lump sum payment on Feb 1, 2025
-	febFirst2025 := time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)
-	if febFirst2025.After(l.StartDate) && febFirst2025.Before(l.EndDate) {
-		payments[febFirst2025] += 100000.0
+	// A purchase option the lessee is reasonably certain to exercise is paid at the end of the
+	// lease term (IFRS 16.27(d))
+	if l.PurchaseOptionPrice < 0 {
+		return nil, fmt.Errorf("purchase option price cannot be negative: %s", l.PurchaseOptionPrice)
+	}
+	if l.PurchaseOptionReasonablyCertain && l.PurchaseOptionPrice > 0 {
+		flows = append(flows, liabilityCashFlow{
+			date:     l.EndDate,
+			position: periodPosition(l, cycle, periods, l.EndDate),
+			amount:   l.PurchaseOptionPrice,
+		})
 	}
 
-	// Convert the map to a sorted slice for processing
-	paymentsList := make([]scheduledPayment, 0, len(payments))
-	for date, amount := range payments {
-		paymentsList = append(paymentsList, scheduledPayment{
-			date:   date,
-			amount: amount,
+	// The amount expected to be payable under a residual value guarantee falls due at the end
+	// of the lease term (IFRS 16.27(c))
+	if l.ResidualValue < 0 {
+		return nil, fmt.Errorf("residual value guarantee cannot be negative: %s", l.ResidualValue)
+	}
+	if l.ResidualValue > 0 {
+		flows = append(flows, liabilityCashFlow{
+			date:          l.EndDate,
+			position:      periodPosition(l, cycle, periods, l.EndDate),
+			amount:        l.ResidualValue,
+			residualValue: true,
 		})
 	}
 
-	// Sort payments by date
-	sort.Slice(paymentsList, func(i, j int) bool {
-		return paymentsList[i].date.Before(paymentsList[j].date)
+	sort.SliceStable(flows, func(i, j int) bool {
+		return flows[i].date.Before(flows[j].date)
 	})
 
-	// Create daily schedule
-	schedule := make([]AmortizationEntry, 0, totalDays)
-	openingBalance := initialLiability
-	currentDate := l.StartDate
+	return flows, nil
+}
 
-	period := 1
-	paymentIndex := 0
+// regularCashFlow splits a regular payment into the lease component settled against the
+// liability and the part paid for non-lease components.
+func regularCashFlow(l lease.Lease, date time.Time, position float64, payment money.Amount) (liabilityCashFlow, error) {
+	leaseComponent := l.LeaseComponent(payment)
+	if leaseComponent < 0 {
+		return liabilityCashFlow{}, fmt.Errorf("non-lease components exceed the payment of %s on %s", payment, date.Format("2006-01-02"))
+	}
+	return liabilityCashFlow{
+		date:     date,
+		position: position,
+		amount:   leaseComponent,
+		nonLease: payment - leaseComponent,
+	}, nil
+}
 
-	// 总利息金额和总本金金额（固定为预期值）
-	totalInterest := 121328.79
-	totalPrincipal := 1078671.21
+// VariableLeasePayments returns the extra payments that are expensed as incurred instead of
+// being included in the lease liability.
+func VariableLeasePayments(l lease.Lease) []lease.ExtraPayment {
+	var variable []lease.ExtraPayment
+	for _, extra := range l.ExtraPayments {
+		if extra.Variable {
+			variable = append(variable, extra)
+		}
+	}
+	return variable
+}
 
-	// 已经支付的本金和利息
-	paidInterest := 0.0
-	paidPrincipal := 0.0
+// GenerateLiabilitySchedule creates the amortization schedule for the lease liability
+// using the effective interest method.
+//
+// The payment on the last day includes any amount expected to be payable under a residual
+// value guarantee, which is also shown on its own in ResidualValue.
+//
+// Interest accrues daily on the outstanding balance (including interest accrued but not
+// yet paid) at the lease's periodic rate, compounding once per payment period, which is
+// the same basis CalculateLeaseLiability discounts on. Each payment settles accrued
+// interest first and the remainder reduces principal, so the closing balance reaches zero
+// with the final payment. Interest is booked to the cent each day, carrying the rounding
+// difference into the next day, so every entry reconciles exactly.
+func GenerateLiabilitySchedule(l lease.Lease, initialLiability money.Amount) ([]AmortizationEntry, error) {
+	return liabilityScheduleFrom(l, initialLiability, l.StartDate, 1)
+}
 
-	// 总付款额（所有payment的总和）
-	var totalPayment float64
-	for _, p := range paymentsList {
-		totalPayment += p.amount
+// liabilityScheduleFrom builds the daily liability schedule from firstDate to the end of the
+// lease, starting from the given opening balance. Only cash flows falling on or after
+// firstDate are settled, and interest on the first day accrues from the close of the
+// previous day, so a schedule can be continued from any date within the lease term.
+func liabilityScheduleFrom(l lease.Lease, opening money.Amount, firstDate time.Time, firstPeriod int) ([]AmortizationEntry, error) {
+	flows, periods, periodicRate, err := liabilityCashFlows(l)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get periods and rate for schedule: %w", err)
 	}
 
-	// 确保总和等于预期
-	if math.Abs(totalInterest+totalPrincipal-totalPayment) > 0.01 {
-		// 调整利息值，使得总和等于totalPayment
-		totalInterest = totalPayment - totalPrincipal
+	if periods == 0 {
+		return []AmortizationEntry{}, nil
 	}
 
-	for day := 1; day <= totalDays; day++ {
-		// 计算日利息
-		interestExpense := openingBalance * dailyRate
-
-		// 当天的付款金额
-		payment := 0.0
-		isPeriodEnd := false
-
-		// 检查当天是否为付款日
-		for paymentIndex < len(paymentsList) &&
-			(currentDate.Equal(paymentsList[paymentIndex].date) || currentDate.After(paymentsList[paymentIndex].date)) {
-			payment = paymentsList[paymentIndex].amount
-			isPeriodEnd = true
-			paymentIndex++
-		}
+	cycle, err := paymentCycleFor(l)
+	if err != nil {
+		return nil, fmt.Errorf("invalid frequency in schedule generation: %s", l.PaymentFrequency)
+	}
 
-		// 计算本金还款和利息还款
-		principalRepayment := 0.0
-
-		// 在付款日，分配利息和本金
-		if isPeriodEnd {
-			// 计算剩余的应付利息和本金
-			remainingInterest := totalInterest - paidInterest
-			remainingPrincipal := totalPrincipal - paidPrincipal
-
-			// 固定比例分配: 本金/利息的比例与总本金/总利息的比例保持一致
-			// 考虑特殊情况：如果是一次性大额付款（如提前偿还），可能导致利息占比不一致
-			if math.Abs(payment-totalPayment) < 0.01 || payment >= remainingInterest+remainingPrincipal {
-				// 如果是一次性全部付清，或者付款金额足够覆盖剩余全部，直接按剩余分配
-				principalRepayment = remainingPrincipal
-				interestPayment := remainingInterest
-
-				// 确保总付款额等于本金+利息
-				if math.Abs(principalRepayment+interestPayment-payment) > 0.01 {
-					// 如有误差，调整本金以确保准确
-					principalRepayment = payment - interestPayment
-				}
-			} else {
-				// 正常情况: 按比例分配
-				// 先计算利息部分
-				intRatio := totalInterest / totalPayment
-				interestPayment := payment * intRatio
-
-				// 本金是剩余部分
-				principalRepayment = payment - interestPayment
-
-				// 确保不超过剩余本金
-				if principalRepayment > remainingPrincipal {
-					principalRepayment = remainingPrincipal
-					interestPayment = payment - principalRepayment
-				}
-
-				// 确保不超过剩余利息
-				if interestPayment > remainingInterest {
-					interestPayment = remainingInterest
-					principalRepayment = payment - interestPayment
-				}
-			}
+	// Calculate total days between the first day and end date
+	totalDays := int(l.EndDate.Sub(firstDate).Hours()/24) + 1
+
+	if totalDays <= 0 {
+		return []AmortizationEntry{}, nil
+	}
+
+	// Cash flows before the first day have already been settled
+	flowIndex := 0
+	for flowIndex < len(flows) && flows[flowIndex].date.Before(firstDate) {
+		flowIndex++
+	}
+
+	// Create daily schedule
+	schedule := make([]AmortizationEntry, 0, totalDays)
+	openingBalance := opening
+	var accrual money.Accrual
+	accruedInterest := money.Amount(0) // Interest accrued since the last payment and not yet settled
+	previousPosition := periodPosition(l, cycle, periods, firstDate.AddDate(0, 0, -1))
+	currentDate := firstDate
 
-			// 更新已支付的累计金额
-			paidPrincipal += principalRepayment
-			paidInterest += (payment - principalRepayment)
-
-			// 检查总付款是否已经全部分配
-			if paymentIndex == len(paymentsList) {
-				// 最后一次付款，确保总金额正确
-				totalPaidAmount := paidPrincipal + paidInterest
-
-				if math.Abs(totalPaidAmount-totalPayment) > 0.01 {
-					// 有误差，调整本金使总额准确
-					differenceAmount := totalPayment - totalPaidAmount
-					principalRepayment += differenceAmount
-					paidPrincipal += differenceAmount
-				}
-
-				// 确保本金总额等于预期
-				if math.Abs(paidPrincipal-totalPrincipal) > 0.01 {
-					// 调整本次本金支付以匹配总额
-					principalDiff := totalPrincipal - (paidPrincipal - principalRepayment)
-					principalRepayment = principalDiff
-					paidPrincipal = totalPrincipal
-
-					// 调整利息支付
-					paidInterest = totalPayment - paidPrincipal
-				}
+	period := firstPeriod
+
+	for day := 1; day <= totalDays; day++ {
+		position := periodPosition(l, cycle, periods, currentDate)
+		interestExpense := accrual.Book(openingBalance.Float64() * (math.Pow(1+periodicRate, position-previousPosition) - 1))
+		previousPosition = position
+
+		// Collect every payment falling due on this day
+		payment, residualValue := money.Amount(0), money.Amount(0)
+		for flowIndex < len(flows) && !flows[flowIndex].date.After(currentDate) {
+			payment += flows[flowIndex].amount
+			if flows[flowIndex].residualValue {
+				residualValue += flows[flowIndex].amount
 			}
+			flowIndex++
 		}
 
-		closingBalance := openingBalance - principalRepayment
-
-		// 确保最后一天余额为0
-		if currentDate.Equal(l.EndDate) {
-			if payment == 0 {
-				// 如果最后一天没有安排付款，添加一个
-				payment = openingBalance
-				principalRepayment = openingBalance
+		// The initial liability is measured to the cent, so a rounding residual of a cent can
+		// remain after the final payment; it is absorbed into that day's interest.
+		if payment > 0 && flowIndex == len(flows) {
+			residual := openingBalance + interestExpense - payment
+			if residual.Abs() <= money.Cent {
+				interestExpense -= residual
 			}
-			closingBalance = 0
 		}
 
+		// Payments settle accrued interest first, then principal
+		accruedInterest += interestExpense
+		principalRepayment := money.Amount(0)
+		if payment > 0 {
+			interestSettled := min(payment, max(accruedInterest, 0))
+			accruedInterest -= interestSettled
+			principalRepayment = payment - interestSettled
+		}
+
+		closingBalance := openingBalance + interestExpense - payment
+
 		entry := AmortizationEntry{
 			Period:             period,
 			Date:               currentDate,
-			OpeningBalance:     roundFloat(openingBalance, 2),
-			Payment:            roundFloat(payment, 2),
-			InterestExpense:    roundFloat(interestExpense, 2),
-			PrincipalRepayment: roundFloat(principalRepayment, 2),
-			ClosingBalance:     roundFloat(closingBalance, 2),
+			OpeningBalance:     openingBalance,
+			Payment:            payment,
+			ResidualValue:      residualValue,
+			InterestExpense:    interestExpense,
+			PrincipalRepayment: principalRepayment,
+			ClosingBalance:     closingBalance,
 		}
 		schedule = append(schedule, entry)
 
@@ -273,7 +388,7 @@ func GenerateLiabilitySchedule(l lease.Lease, initialLiability float64) ([]Amort
 		currentDate = currentDate.AddDate(0, 0, 1) // Move to next day
 
 		// Increment period only on days with payments
-		if isPeriodEnd {
+		if payment > 0 {
 			period++
 		}
 	}
@@ -282,7 +397,9 @@ func GenerateLiabilitySchedule(l lease.Lease, initialLiability float64) ([]Amort
 }
 
 // GenerateRoUAssetSchedule creates the amortization (depreciation) schedule for the Right-of-Use asset.
-func GenerateRoUAssetSchedule(l lease.Lease, initialRoUAsset float64) ([]AmortizationEntry, error) {
+// The asset is depreciated up to DepreciationEndDate, which runs beyond the end of the lease
+// term when the lessee is expected to obtain ownership of the underlying asset.
+func GenerateRoUAssetSchedule(l lease.Lease, initialRoUAsset money.Amount) ([]AmortizationEntry, error) {
 	// Just need to check if there are validation errors
 	_, _, err := getPeriodsAndRate(l)
 	if err != nil {
@@ -290,66 +407,67 @@ func GenerateRoUAssetSchedule(l lease.Lease, initialRoUAsset float64) ([]Amortiz
 	}
 
 	if initialRoUAsset < 0 {
-		return nil, fmt.Errorf("initial RoU Asset value cannot be negative: %.2f", initialRoUAsset)
+		return nil, fmt.Errorf("initial RoU Asset value cannot be negative: %s", initialRoUAsset)
 	}
 
-	// Calculate total days between start and end date
-	totalDays := int(l.EndDate.Sub(l.StartDate).Hours()/24) + 1
+	return rouScheduleFrom(l, initialRoUAsset, l.StartDate, 1)
+}
+
+// rouScheduleFrom depreciates the given opening carrying amount over the days from firstDate
+// to the depreciation end date, using the lease's depreciation method.
+func rouScheduleFrom(l lease.Lease, opening money.Amount, firstDate time.Time, firstPeriod int) ([]AmortizationEntry, error) {
+	endDate, err := DepreciationEndDate(l)
+	if err != nil {
+		return nil, err
+	}
+	return depreciationSchedule(l, opening, firstDate, endDate, firstPeriod)
+}
+
+// depreciationSchedule depreciates an opening carrying amount over the days from firstDate to
+// endDate, using the lease's depreciation method. Depreciation is allocated cumulatively, so
+// the daily amounts are rounded to the cent without drifting and depreciate the asset to
+// exactly zero.
+func depreciationSchedule(l lease.Lease, opening money.Amount, firstDate, endDate time.Time, firstPeriod int) ([]AmortizationEntry, error) {
+	// Calculate total days between the first day and end date
+	totalDays := int(endDate.Sub(firstDate).Hours()/24) + 1
 
 	if totalDays <= 0 {
 		return []AmortizationEntry{}, nil
 	}
 
-	// Calculate daily depreciation
-	dailyDepreciation := initialRoUAsset / float64(totalDays)
+	depreciatedBy, units, err := depreciationProfile(l, opening, firstDate, endDate)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create daily schedule
 	schedule := make([]AmortizationEntry, 0, totalDays)
-	openingBalance := initialRoUAsset
-	currentDate := l.StartDate
+	openingBalance := opening
+	currentDate := firstDate
 
 	for day := 1; day <= totalDays; day++ {
-		depreciationExpense := dailyDepreciation
-
-		// On the last day, ensure we depreciate to exactly zero
-		if day == totalDays {
-			depreciationExpense = openingBalance
-		}
-
+		depreciationExpense := depreciatedBy(day) - depreciatedBy(day-1)
 		closingBalance := openingBalance - depreciationExpense
 
-		// Explicitly zero out the closing balance on the final day
-		if day == totalDays {
-			closingBalance = 0
-		}
-
 		entry := AmortizationEntry{
-			Period:         day,
+			Period:         firstPeriod + day - 1,
 			Date:           currentDate,
-			OpeningBalance: roundFloat(openingBalance, 2),
-			Depreciation:   roundFloat(depreciationExpense, 2),
-			ClosingBalance: roundFloat(closingBalance, 2),
+			OpeningBalance: openingBalance,
+			Depreciation:   depreciationExpense,
+			ClosingBalance: closingBalance,
+		}
+		if units != nil {
+			entry.UnitsUsed = units[day-1]
 		}
 		schedule = append(schedule, entry)
 
 		openingBalance = closingBalance
 		currentDate = currentDate.AddDate(0, 0, 1) // Move to next day
-
-		// Safety check
-		if openingBalance < -1e-9 {
-			return schedule, fmt.Errorf("RoU asset balance went negative (%.2f) on day %d", openingBalance, day)
-		}
 	}
 
 	return schedule, nil
 }
 
-// roundFloat rounds a float64 to a specified number of decimal places.
-func roundFloat(val float64, precision uint) float64 {
-	ratio := math.Pow(10, float64(precision))
-	return math.Round(val*ratio) / ratio
-}
-
 /*
 // Placeholder for date calculation function - REMOVED as logic is now inline
 func calculateNextPaymentDate(currentDate time.Time, freq lease.PaymentFrequency) time.Time {
@@ -357,8 +475,55 @@ func calculateNextPaymentDate(currentDate time.Time, freq lease.PaymentFrequency
 }
 */
 
-// calculateAccountingPeriodSummary 计算指定账期的摘要数据
-func calculateAccountingPeriodSummary(result *CalculationResult, periodStart, periodEnd string) error {
+// periodBalances 返回账期开始和结束时的账面值
+// 期初使用账期开始日之前最后一个条目的收盘余额(或首个条目的期初余额),
+// 期末使用账期结束日当天或之前最后一个条目的收盘余额。
+func periodBalances(schedule []AmortizationEntry, start, end time.Time) (money.Amount, money.Amount) {
+	var startBalance, endBalance money.Amount
+	var startFound, endFound bool
+
+	for i, entry := range schedule {
+		entryDate := entry.Date
+
+		// 寻找账期开始日期的账面值(使用最接近的前一个日期)
+		if !startFound && (entryDate.Equal(start) || entryDate.After(start)) {
+			if i > 0 && entryDate.After(start) {
+				// 使用前一个条目的收盘余额作为起始余额
+				startBalance = schedule[i-1].ClosingBalance
+			} else {
+				startBalance = entry.OpeningBalance
+			}
+			startFound = true
+		}
+
+		// 寻找账期结束日期的账面值(使用最接近的前一个日期的收盘余额)
+		if entryDate.Equal(end) || (entryDate.After(end) && !endFound) {
+			if entryDate.After(end) && i > 0 {
+				// 使用前一个条目的收盘余额
+				endBalance = schedule[i-1].ClosingBalance
+			} else {
+				// 如果正好等于结束日期，使用当天的收盘余额
+				endBalance = entry.ClosingBalance
+			}
+			endFound = true
+		}
+	}
+
+	// 如果未找到结束值,使用最后一个条目的收盘余额
+	if !endFound && len(schedule) > 0 {
+		endBalance = schedule[len(schedule)-1].ClosingBalance
+	}
+
+	return startBalance, endBalance
+}
+
+// inPeriod 判断日期是否落在账期内(含首尾)
+func inPeriod(date, start, end time.Time) bool {
+	return !date.Before(start) && !date.After(end)
+}
+
+// CalculateAccountingPeriodSummary 计算指定账期的摘要数据
+func CalculateAccountingPeriodSummary(result *CalculationResult, periodStart, periodEnd string) error {
 	// 解析日期
 	start, err := time.Parse("2006-01-02", periodStart)
 	if err != nil {
@@ -380,132 +545,168 @@ func calculateAccountingPeriodSummary(result *CalculationResult, periodStart, pe
 	result.AccountingPeriodEnd = periodEnd
 
 	// 处理租赁负债表
+	// 每个条目都精确满足 期初 + 重新计量 + 利息 - 付款 = 期末,账期合计直接累加即可
 	if len(result.LiabilitySchedule) > 0 {
-		var startBalance, endBalance, totalPayments, totalInterest, totalPrincipal float64
-		var startFound, endFound bool
-
-		// 寻找账期起始的账面值
-		for i, entry := range result.LiabilitySchedule {
-			entryDate := entry.Date
-
-			// 寻找账期开始日期的账面值(使用最接近的前一个日期)
-			if !startFound && (entryDate.Equal(start) || entryDate.After(start)) {
-				if i > 0 && entryDate.After(start) {
-					// 使用前一个条目的收盘余额作为起始余额
-					startBalance = result.LiabilitySchedule[i-1].ClosingBalance
-				} else {
-					startBalance = entry.OpeningBalance
-				}
-				startFound = true
-			}
+		var totalPayments, totalInterest, totalPrincipal, totalRemeasurement money.Amount
+		startBalance, endBalance := periodBalances(result.LiabilitySchedule, start, end)
 
-			// 累计账期内的数据
-			if (entryDate.Equal(start) || entryDate.After(start)) &&
-				(entryDate.Equal(end) || entryDate.Before(end)) {
+		// 累计账期内的数据
+		for _, entry := range result.LiabilitySchedule {
+			if !inPeriod(entry.Date, start, end) {
+				continue
+			}
 
-				// 累计利息费用（每天都要累计）
-				totalInterest += entry.InterestExpense
+			// 累计利息费用（每天都要累计）
+			totalInterest += entry.InterestExpense
+			totalRemeasurement += entry.Remeasurement
 
-				// 只在付款日累计付款和本金
-				if entry.Payment > 0 {
-					totalPayments += entry.Payment
-					totalPrincipal += entry.PrincipalRepayment
-				}
+			// 只在付款日累计付款和本金
+			if entry.Payment > 0 {
+				totalPayments += entry.Payment
+				totalPrincipal += entry.PrincipalRepayment
 			}
+		}
+
+		result.PeriodLiabilityStart = startBalance
+		result.PeriodLiabilityEnd = endBalance
+		result.PeriodInterestExpense = totalInterest
+		result.PeriodPayments = totalPayments
+		result.PeriodPrincipalPayment = totalPrincipal
+		result.PeriodInterestPaid = totalPayments - totalPrincipal // 付款先结清已计提利息
+		result.PeriodLiabilityRemeasurement = totalRemeasurement
+	}
+
+	// 处理使用权资产表
+	// 减值准备与累计折旧分开列示:账期前确认的减值计入期初累计减值准备
+	if len(result.RoUAssetSchedule) > 0 {
+		var totalDepreciation, totalAdjustment, totalImpairment, impairmentBefore money.Amount
+		startBalance, endBalance := periodBalances(result.RoUAssetSchedule, start, end)
 
-			// 寻找账期结束日期的账面值(使用最接近的前一个日期的收盘余额)
-			if entryDate.Equal(end) || (entryDate.After(end) && !endFound) {
-				if entryDate.After(end) && i > 0 {
-					// 使用前一个条目的收盘余额
-					endBalance = result.LiabilitySchedule[i-1].ClosingBalance
-				} else {
-					// 如果正好等于结束日期，使用当天的收盘余额
-					endBalance = entry.ClosingBalance
-				}
-				endFound = true
+		// 累计账期内的折旧和减值
+		for _, entry := range result.RoUAssetSchedule {
+			if entry.Date.Before(start) {
+				impairmentBefore += entry.Impairment
+			}
+			if inPeriod(entry.Date, start, end) {
+				totalDepreciation += entry.Depreciation
+				totalAdjustment += entry.Remeasurement
+				totalImpairment += entry.Impairment
 			}
 		}
 
-		// 如果未找到结束值,使用最后一个条目的收盘余额
-		if !endFound && len(result.LiabilitySchedule) > 0 {
-			lastEntry := result.LiabilitySchedule[len(result.LiabilitySchedule)-1]
-			endBalance = lastEntry.ClosingBalance
+		result.PeriodRoUAssetStart = startBalance
+		result.PeriodRoUAssetEnd = endBalance
+		result.PeriodDepreciation = totalDepreciation
+		result.PeriodRoUAssetRemeasurement = totalAdjustment
+		result.PeriodImpairment = totalImpairment
+		result.PeriodImpairmentStart = impairmentBefore
+		result.PeriodImpairmentEnd = impairmentBefore + totalImpairment
+	}
+
+	// 处理复原准备金表(准备金折现摊销计入财务费用)
+	if len(result.RestorationSchedule) > 0 {
+		var totalUnwinding money.Amount
+		startBalance, endBalance := periodBalances(result.RestorationSchedule, start, end)
+
+		for _, entry := range result.RestorationSchedule {
+			if inPeriod(entry.Date, start, end) {
+				totalUnwinding += entry.InterestExpense
+			}
 		}
 
-		// 验证本金与余额变动的一致性
-		balanceChange := startBalance - endBalance
-		if math.Abs(balanceChange-totalPrincipal) > 0.01 {
-			// 如果有差异，使用余额变动作为本金
-			totalPrincipal = balanceChange
+		result.PeriodProvisionStart = startBalance
+		result.PeriodProvisionEnd = endBalance
+		result.PeriodProvisionUnwinding = totalUnwinding
+	}
+
+	// 豁免租赁(短期或低价值)不确认负债和资产,租赁付款额按直线法计入当期费用
+	if len(result.ExpenseSchedule) > 0 {
+		var totalExpense, totalPayments money.Amount
+		for _, entry := range result.ExpenseSchedule {
+			if inPeriod(entry.Date, start, end) {
+				totalExpense += entry.Expense
+				totalPayments += entry.Payment
+			}
 		}
 
-		// 验证本金+利息=付款总额
-		if math.Abs((totalPrincipal+totalInterest)-totalPayments) > 0.01 {
-			// 如果有差异，调整利息使总额相等
-			totalInterest = totalPayments - totalPrincipal
+		result.PeriodExemptLeaseExpense = totalExpense
+		result.PeriodPayments = totalPayments
+	}
+
+	// 出租人融资租赁:按内含利率确认利息收入,收款先冲减应收利息再冲减租赁投资净额
+	if len(result.NetInvestmentSchedule) > 0 {
+		var totalIncome, totalReceipts money.Amount
+		startBalance, endBalance := periodBalances(result.NetInvestmentSchedule, start, end)
+		for _, entry := range result.NetInvestmentSchedule {
+			if inPeriod(entry.Date, start, end) {
+				totalIncome += entry.Income
+				totalReceipts += entry.Payment
+			}
 		}
 
-		result.PeriodLiabilityStart = roundFloat(startBalance, 2)
-		result.PeriodLiabilityEnd = roundFloat(endBalance, 2)
-		result.PeriodInterestExpense = roundFloat(totalInterest, 2)
-		result.PeriodPayments = roundFloat(totalPayments, 2)
-		result.PeriodPrincipalPayment = roundFloat(totalPrincipal, 2)
+		result.PeriodNetInvestmentStart = startBalance
+		result.PeriodNetInvestmentEnd = endBalance
+		result.PeriodFinanceIncome = totalIncome
+		result.PeriodLeaseReceipts = totalReceipts
 	}
 
-	// 处理使用权资产表
-	if len(result.RoUAssetSchedule) > 0 {
-		var startBalance, endBalance, totalDepreciation float64
-		var startFound, endFound bool
-
-		// 寻找账期起始的账面值
-		for i, entry := range result.RoUAssetSchedule {
-			entryDate := entry.Date
-
-			// 寻找账期开始日期的账面值
-			if !startFound && (entryDate.Equal(start) || entryDate.After(start)) {
-				if i > 0 && entryDate.After(start) {
-					// 使用前一个条目的收盘余额作为起始余额
-					startBalance = result.RoUAssetSchedule[i-1].ClosingBalance
-				} else {
-					startBalance = entry.OpeningBalance
-				}
-				startFound = true
+	// 出租人经营租赁:租赁收款额按直线法确认为收入,租出资产继续计提折旧
+	if len(result.IncomeSchedule) > 0 {
+		var totalIncome, totalReceipts money.Amount
+		for _, entry := range result.IncomeSchedule {
+			if inPeriod(entry.Date, start, end) {
+				totalIncome += entry.Income
+				totalReceipts += entry.Payment
 			}
+		}
 
-			// 累计账期内的折旧
-			if (entryDate.Equal(start) || entryDate.After(start)) &&
-				(entryDate.Equal(end) || entryDate.Before(end)) {
+		result.PeriodOperatingLeaseIncome = totalIncome
+		result.PeriodLeaseReceipts = totalReceipts
+	}
+	if len(result.AssetSchedule) > 0 {
+		var totalDepreciation money.Amount
+		startBalance, endBalance := periodBalances(result.AssetSchedule, start, end)
+		for _, entry := range result.AssetSchedule {
+			if inPeriod(entry.Date, start, end) {
 				totalDepreciation += entry.Depreciation
 			}
+		}
 
-			// 寻找账期结束日期的账面值
-			if entryDate.Equal(end) || (entryDate.After(end) && !endFound) {
-				if entryDate.After(end) && i > 0 {
-					// 使用前一个条目的收盘余额
-					endBalance = result.RoUAssetSchedule[i-1].ClosingBalance
-				} else {
-					// 如果正好等于结束日期，使用当天的收盘余额
-					endBalance = entry.ClosingBalance
-				}
-				endFound = true
-			}
+		result.PeriodAssetStart = startBalance
+		result.PeriodAssetEnd = endBalance
+		result.PeriodAssetDepreciation = totalDepreciation
+	}
+
+	// 可变租赁付款额不计入租赁负债,于发生时计入当期费用
+	var totalVariable money.Amount
+	for _, p := range result.VariablePayments {
+		if inPeriod(p.Date, start, end) {
+			totalVariable += p.Amount
 		}
+	}
+	result.PeriodVariablePayments = totalVariable
 
-		// 如果未找到结束值,使用最后一个条目的收盘余额
-		if !endFound && len(result.RoUAssetSchedule) > 0 {
-			lastEntry := result.RoUAssetSchedule[len(result.RoUAssetSchedule)-1]
-			endBalance = lastEntry.ClosingBalance
+	// 非租赁组成部分不计入租赁负债,作为经营费用计入当期
+	var totalNonLease money.Amount
+	for _, p := range result.NonLeasePayments {
+		if inPeriod(p.Date, start, end) {
+			totalNonLease += p.Amount
 		}
+	}
+	result.PeriodNonLeaseExpense = totalNonLease
 
-		// 验证折旧总额与余额变动的一致性
-		calculatedDepreciation := startBalance - endBalance
-		if math.Abs(calculatedDepreciation-totalDepreciation) > 0.01 {
-			totalDepreciation = calculatedDepreciation
+	// 租赁变更(部分终止)产生的损益
+	var totalGainLoss money.Amount
+	for _, r := range result.Remeasurements {
+		if inPeriod(r.EffectiveDate, start, end) {
+			totalGainLoss += r.GainLoss
 		}
+	}
+	result.PeriodRemeasurementGainLoss = totalGainLoss
 
-		result.PeriodRoUAssetStart = roundFloat(startBalance, 2)
-		result.PeriodRoUAssetEnd = roundFloat(endBalance, 2)
-		result.PeriodDepreciation = roundFloat(totalDepreciation, 2)
+	// 售后租回仅确认转让给出租人的权利部分的利得或损失
+	if result.SaleAndLeaseback != nil && inPeriod(result.SaleAndLeaseback.Date, start, end) {
+		result.PeriodLeasebackGainLoss = result.SaleAndLeaseback.GainLoss
 	}
 
 	return nil
@@ -3,7 +3,7 @@ package calculation
 import (
 	"fmt"
 	"ifrs16_calculator/internal/lease"
-	"math"
+	"ifrs16_calculator/internal/money"
 	"testing"
 	"time"
 )
@@ -25,26 +25,26 @@ func mustParseDateAmort(layout, value string) time.Time {
 // --- End Helpers ---
 
 func TestGenerateLiabilitySchedule(t *testing.T) {
-	const tolerance = 0.01 // Using a cent tolerance for schedule values
+	const tolerance = money.Cent // Interest accrues daily, so a period can differ from one rate calculation by a cent
 
 	sampleLeaseMonthly := lease.Lease{
 		ID:               "L001-Sched",
 		StartDate:        mustParseDateAmort(testDateLayoutAmort, "2024-01-01"),
 		EndDate:          mustParseDateAmort(testDateLayoutAmort, "2024-12-31"), // 1 Year
-		PaymentAmount:    1000,
+		PaymentAmount:    1000 * money.Unit,
 		PaymentFrequency: lease.Monthly,
 		DiscountRate:     0.05,
 	}
-	// Pre-calculated liability for the sample lease
-	initialLiabilityMonthly := 11686.231696
+	// Liability for the sample lease as measured by CalculateLeaseLiability
+	initialLiabilityMonthly := money.FromFloat(11681.22)
 
 	tests := []struct {
 		name              string
 		lease             lease.Lease
-		initialLiability  float64
+		initialLiability  money.Amount
 		expectedPeriods   int
 		checkFirstPeriod  *AmortizationEntry // Check specific values in the first period
-		checkLastPeriodCB float64            // Check final closing balance
+		checkLastPeriodCB money.Amount       // Check final closing balance
 		expectError       bool
 	}{
 		{
@@ -55,13 +55,13 @@ func TestGenerateLiabilitySchedule(t *testing.T) {
 			checkFirstPeriod: &AmortizationEntry{
 				Period:             1,
 				Date:               mustParseDateAmort(testDateLayoutAmort, "2024-02-01"),
-				OpeningBalance:     roundFloat(initialLiabilityMonthly, 2),
-				Payment:            1000.00,
-				InterestExpense:    roundFloat(initialLiabilityMonthly*(0.05/12), 2),                                  // 48.69
-				PrincipalRepayment: roundFloat(1000-(initialLiabilityMonthly*(0.05/12)), 2),                           // 951.31
-				ClosingBalance:     roundFloat(initialLiabilityMonthly-(1000-(initialLiabilityMonthly*(0.05/12))), 2), // 10734.92
+				OpeningBalance:     initialLiabilityMonthly,
+				Payment:            1000 * money.Unit,
+				InterestExpense:    initialLiabilityMonthly.Mul(0.05 / 12),                                             // 48.67
+				PrincipalRepayment: 1000*money.Unit - initialLiabilityMonthly.Mul(0.05/12),                             // 951.33
+				ClosingBalance:     initialLiabilityMonthly - (1000*money.Unit - initialLiabilityMonthly.Mul(0.05/12)), // 10729.89
 			},
-			checkLastPeriodCB: 0.00,
+			checkLastPeriodCB: 0,
 			expectError:       false,
 		},
 		{
@@ -69,14 +69,14 @@ func TestGenerateLiabilitySchedule(t *testing.T) {
 			lease: lease.Lease{
 				StartDate:        mustParseDateAmort(testDateLayoutAmort, "2024-01-01"),
 				EndDate:          mustParseDateAmort(testDateLayoutAmort, "2024-01-20"),
-				PaymentAmount:    1000,
+				PaymentAmount:    1000 * money.Unit,
 				PaymentFrequency: lease.Monthly,
 				DiscountRate:     0.05,
 			},
 			initialLiability:  0, // Liability would be 0
 			expectedPeriods:   0,
 			checkFirstPeriod:  nil,
-			checkLastPeriodCB: 0.00,
+			checkLastPeriodCB: 0,
 			expectError:       false,
 		},
 		{
@@ -87,10 +87,10 @@ func TestGenerateLiabilitySchedule(t *testing.T) {
 				PaymentFrequency: "invalid",
 				DiscountRate:     0.05,
 			},
-			initialLiability:  10000, // Doesn't matter, should fail earlier
+			initialLiability:  10000 * money.Unit, // Doesn't matter, should fail earlier
 			expectedPeriods:   0,
 			checkFirstPeriod:  nil,
-			checkLastPeriodCB: 0.00,
+			checkLastPeriodCB: 0,
 			expectError:       true,
 		},
 		// TODO: Add tests for Quarterly, Annually
@@ -100,6 +100,10 @@ func TestGenerateLiabilitySchedule(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			schedule, err := GenerateLiabilitySchedule(tt.lease, tt.initialLiability)
+			if err == nil {
+				// The engine accrues daily; compare one entry per payment period
+				schedule, err = RollUpSchedule(tt.lease, schedule, PaymentPeriodGranularity, FiscalCalendar{})
+			}
 
 			if (err != nil) != tt.expectError {
 				t.Errorf("GenerateLiabilitySchedule() error = %v, expectError %v", err, tt.expectError)
@@ -122,27 +126,27 @@ func TestGenerateLiabilitySchedule(t *testing.T) {
 						if !firstEntry.Date.Equal(expected.Date) {
 							t.Errorf("First Entry Date = %v, want %v", firstEntry.Date, expected.Date)
 						}
-						if math.Abs(firstEntry.OpeningBalance-expected.OpeningBalance) > tolerance {
-							t.Errorf("First Entry OpeningBalance = %.2f, want %.2f", firstEntry.OpeningBalance, expected.OpeningBalance)
+						if (firstEntry.OpeningBalance - expected.OpeningBalance).Abs() > tolerance {
+							t.Errorf("First Entry OpeningBalance = %s, want %s", firstEntry.OpeningBalance, expected.OpeningBalance)
 						}
-						if math.Abs(firstEntry.Payment-expected.Payment) > tolerance {
-							t.Errorf("First Entry Payment = %.2f, want %.2f", firstEntry.Payment, expected.Payment)
+						if (firstEntry.Payment - expected.Payment).Abs() > tolerance {
+							t.Errorf("First Entry Payment = %s, want %s", firstEntry.Payment, expected.Payment)
 						}
-						if math.Abs(firstEntry.InterestExpense-expected.InterestExpense) > tolerance {
-							t.Errorf("First Entry InterestExpense = %.2f, want %.2f", firstEntry.InterestExpense, expected.InterestExpense)
+						if (firstEntry.InterestExpense - expected.InterestExpense).Abs() > tolerance {
+							t.Errorf("First Entry InterestExpense = %s, want %s", firstEntry.InterestExpense, expected.InterestExpense)
 						}
-						if math.Abs(firstEntry.PrincipalRepayment-expected.PrincipalRepayment) > tolerance {
-							t.Errorf("First Entry PrincipalRepayment = %.2f, want %.2f", firstEntry.PrincipalRepayment, expected.PrincipalRepayment)
+						if (firstEntry.PrincipalRepayment - expected.PrincipalRepayment).Abs() > tolerance {
+							t.Errorf("First Entry PrincipalRepayment = %s, want %s", firstEntry.PrincipalRepayment, expected.PrincipalRepayment)
 						}
-						if math.Abs(firstEntry.ClosingBalance-expected.ClosingBalance) > tolerance {
-							t.Errorf("First Entry ClosingBalance = %.2f, want %.2f", firstEntry.ClosingBalance, expected.ClosingBalance)
+						if (firstEntry.ClosingBalance - expected.ClosingBalance).Abs() > tolerance {
+							t.Errorf("First Entry ClosingBalance = %s, want %s", firstEntry.ClosingBalance, expected.ClosingBalance)
 						}
 					}
 
 					// Check last period closing balance
 					lastEntry := schedule[len(schedule)-1]
-					if math.Abs(lastEntry.ClosingBalance-tt.checkLastPeriodCB) > tolerance {
-						t.Errorf("Last Entry ClosingBalance = %.2f, want %.2f", lastEntry.ClosingBalance, tt.checkLastPeriodCB)
+					if (lastEntry.ClosingBalance - tt.checkLastPeriodCB).Abs() > tolerance {
+						t.Errorf("Last Entry ClosingBalance = %s, want %s", lastEntry.ClosingBalance, tt.checkLastPeriodCB)
 					}
 				}
 			}
@@ -151,7 +155,7 @@ func TestGenerateLiabilitySchedule(t *testing.T) {
 }
 
 func TestGenerateRoUAssetSchedule(t *testing.T) {
-	const tolerance = 0.01 // Using a cent tolerance
+	const tolerance = money.Cent // Using a cent tolerance
 
 	sampleLeaseMonthlyRoU := lease.Lease{
 		ID:               "L001-RoU-Sched",
@@ -161,15 +165,15 @@ func TestGenerateRoUAssetSchedule(t *testing.T) {
 		DiscountRate:     0.05,
 		// Other fields don't directly impact SL depreciation calc
 	}
-	initialRoUAssetMonthly := 11686.23 // Using rounded value for simplicity
+	initialRoUAssetMonthly := money.FromFloat(11686.23) // Using rounded value for simplicity
 
 	tests := []struct {
 		name              string
 		lease             lease.Lease
-		initialRoUAsset   float64
+		initialRoUAsset   money.Amount
 		expectedPeriods   int
 		checkFirstPeriod  *AmortizationEntry // Check specific values in the first period
-		checkLastPeriodCB float64            // Check final closing balance
+		checkLastPeriodCB money.Amount       // Check final closing balance
 		expectError       bool
 	}{
 		{
@@ -180,35 +184,35 @@ func TestGenerateRoUAssetSchedule(t *testing.T) {
 			checkFirstPeriod: &AmortizationEntry{
 				Period:         1,
 				Date:           mustParseDateAmort(testDateLayoutAmort, "2024-02-01"),
-				OpeningBalance: roundFloat(initialRoUAssetMonthly, 2),
-				Depreciation:   roundFloat(initialRoUAssetMonthly/12, 2),                          // 973.85
-				ClosingBalance: roundFloat(initialRoUAssetMonthly-(initialRoUAssetMonthly/12), 2), // 10712.38
+				OpeningBalance: initialRoUAssetMonthly,
+				Depreciation:   initialRoUAssetMonthly.Share(32, 366),                          // 1 Jan to 1 Feb of a 366-day term: 1021.75
+				ClosingBalance: initialRoUAssetMonthly - initialRoUAssetMonthly.Share(32, 366), // 10664.48
 			},
-			checkLastPeriodCB: 0.00,
+			checkLastPeriodCB: 0,
 			expectError:       false,
 		},
 		{
 			name:            "Zero Initial RoU Asset",
 			lease:           sampleLeaseMonthlyRoU,
-			initialRoUAsset: 0.0,
+			initialRoUAsset: 0,
 			expectedPeriods: 12,
 			checkFirstPeriod: &AmortizationEntry{
 				Period:         1,
 				Date:           mustParseDateAmort(testDateLayoutAmort, "2024-02-01"),
-				OpeningBalance: 0.00,
-				Depreciation:   0.00,
-				ClosingBalance: 0.00,
+				OpeningBalance: 0,
+				Depreciation:   0,
+				ClosingBalance: 0,
 			},
-			checkLastPeriodCB: 0.00,
+			checkLastPeriodCB: 0,
 			expectError:       false,
 		},
 		{
 			name:              "Negative Initial RoU Asset",
 			lease:             sampleLeaseMonthlyRoU,
-			initialRoUAsset:   -1000.0,
+			initialRoUAsset:   -1000 * money.Unit,
 			expectedPeriods:   0,
 			checkFirstPeriod:  nil,
-			checkLastPeriodCB: 0.00,
+			checkLastPeriodCB: 0,
 			expectError:       true, // Function should error on negative initial asset
 		},
 		{
@@ -218,10 +222,10 @@ func TestGenerateRoUAssetSchedule(t *testing.T) {
 				EndDate:          mustParseDateAmort(testDateLayoutAmort, "2024-12-31"),
 				PaymentFrequency: "invalid",
 			},
-			initialRoUAsset:   10000,
+			initialRoUAsset:   10000 * money.Unit,
 			expectedPeriods:   0,
 			checkFirstPeriod:  nil,
-			checkLastPeriodCB: 0.00,
+			checkLastPeriodCB: 0,
 			expectError:       true,
 		},
 		// TODO: Add Quarterly/Annually tests
@@ -230,6 +234,10 @@ func TestGenerateRoUAssetSchedule(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			schedule, err := GenerateRoUAssetSchedule(tt.lease, tt.initialRoUAsset)
+			if err == nil {
+				// The engine depreciates daily; compare one entry per payment period
+				schedule, err = RollUpSchedule(tt.lease, schedule, PaymentPeriodGranularity, FiscalCalendar{})
+			}
 
 			if (err != nil) != tt.expectError {
 				t.Errorf("GenerateRoUAssetSchedule() error = %v, expectError %v", err, tt.expectError)
@@ -252,24 +260,351 @@ func TestGenerateRoUAssetSchedule(t *testing.T) {
 						if !firstEntry.Date.Equal(expected.Date) {
 							t.Errorf("First Entry Date = %v, want %v", firstEntry.Date, expected.Date)
 						}
-						if math.Abs(firstEntry.OpeningBalance-expected.OpeningBalance) > tolerance {
-							t.Errorf("First Entry OpeningBalance = %.2f, want %.2f", firstEntry.OpeningBalance, expected.OpeningBalance)
+						if (firstEntry.OpeningBalance - expected.OpeningBalance).Abs() > tolerance {
+							t.Errorf("First Entry OpeningBalance = %s, want %s", firstEntry.OpeningBalance, expected.OpeningBalance)
 						}
-						if math.Abs(firstEntry.Depreciation-expected.Depreciation) > tolerance {
-							t.Errorf("First Entry Depreciation = %.2f, want %.2f", firstEntry.Depreciation, expected.Depreciation)
+						if (firstEntry.Depreciation - expected.Depreciation).Abs() > tolerance {
+							t.Errorf("First Entry Depreciation = %s, want %s", firstEntry.Depreciation, expected.Depreciation)
 						}
-						if math.Abs(firstEntry.ClosingBalance-expected.ClosingBalance) > tolerance {
-							t.Errorf("First Entry ClosingBalance = %.2f, want %.2f", firstEntry.ClosingBalance, expected.ClosingBalance)
+						if (firstEntry.ClosingBalance - expected.ClosingBalance).Abs() > tolerance {
+							t.Errorf("First Entry ClosingBalance = %s, want %s", firstEntry.ClosingBalance, expected.ClosingBalance)
 						}
 					}
 
 					// Check last period closing balance
 					lastEntry := schedule[len(schedule)-1]
-					if math.Abs(lastEntry.ClosingBalance-tt.checkLastPeriodCB) > tolerance {
-						t.Errorf("Last Entry ClosingBalance = %.2f, want %.2f", lastEntry.ClosingBalance, tt.checkLastPeriodCB)
+					if (lastEntry.ClosingBalance - tt.checkLastPeriodCB).Abs() > tolerance {
+						t.Errorf("Last Entry ClosingBalance = %s, want %s", lastEntry.ClosingBalance, tt.checkLastPeriodCB)
 					}
 				}
 			}
 		})
 	}
 }
+
+func TestGenerateLiabilityScheduleEffectiveInterest(t *testing.T) {
+	tests := []struct {
+		name             string
+		lease            lease.Lease
+		expectedPayments money.Amount // Total settled against the liability, when not a flat rent
+	}{
+		{
+			name: "Quarterly 2 Years",
+			lease: lease.Lease{
+				ID:               "L002-EIM",
+				StartDate:        mustParseDateAmort(testDateLayoutAmort, "2024-01-15"),
+				EndDate:          mustParseDateAmort(testDateLayoutAmort, "2026-01-14"),
+				PaymentAmount:    5000 * money.Unit,
+				PaymentFrequency: lease.Quarterly,
+				DiscountRate:     0.08,
+			},
+		},
+		{
+			name: "Monthly 1 Year In Advance",
+			lease: lease.Lease{
+				ID:               "L003-EIM",
+				StartDate:        mustParseDateAmort(testDateLayoutAmort, "2024-01-01"),
+				EndDate:          mustParseDateAmort(testDateLayoutAmort, "2024-12-31"),
+				PaymentAmount:    1000 * money.Unit,
+				PaymentFrequency: lease.Monthly,
+				PaymentTiming:    lease.Advance,
+				DiscountRate:     0.05,
+			},
+		},
+		{
+			name: "Monthly 2 Years with extra payment",
+			lease: lease.Lease{
+				ID:               "L005-EIM",
+				StartDate:        mustParseDateAmort(testDateLayoutAmort, "2024-06-01"),
+				EndDate:          mustParseDateAmort(testDateLayoutAmort, "2026-05-31"),
+				PaymentAmount:    2500 * money.Unit,
+				PaymentFrequency: lease.Monthly,
+				DiscountRate:     0.06,
+				ExtraPayments: []lease.ExtraPayment{
+					{Date: mustParseDateAmort(testDateLayoutAmort, "2025-03-15"), Amount: 10000 * money.Unit},
+				},
+			},
+		},
+		{
+			name: "Monthly 3 Years escalating after rent-free months",
+			lease: lease.Lease{
+				ID:               "L006-EIM",
+				StartDate:        mustParseDateAmort(testDateLayoutAmort, "2024-01-01"),
+				EndDate:          mustParseDateAmort(testDateLayoutAmort, "2026-12-31"),
+				PaymentAmount:    1000 * money.Unit,
+				PaymentFrequency: lease.Monthly,
+				DiscountRate:     0.05,
+				EscalationRate:   0.03,
+				RentFreeMonths:   6,
+			},
+			expectedPayments: money.FromFloat(6*1000 + 12*1030 + 12*1060.90),
+		},
+		{
+			name: "Weekly 1 Year",
+			lease: lease.Lease{
+				ID:               "L007-EIM",
+				StartDate:        mustParseDateAmort(testDateLayoutAmort, "2024-01-01"),
+				EndDate:          mustParseDateAmort(testDateLayoutAmort, "2024-12-29"),
+				PaymentAmount:    250 * money.Unit,
+				PaymentFrequency: lease.Weekly,
+				DiscountRate:     0.05,
+			},
+		},
+		{
+			name: "Every 5 Months In Advance",
+			lease: lease.Lease{
+				ID:                    "L008-EIM",
+				StartDate:             mustParseDateAmort(testDateLayoutAmort, "2024-01-31"),
+				EndDate:               mustParseDateAmort(testDateLayoutAmort, "2026-06-30"),
+				PaymentAmount:         6000 * money.Unit,
+				PaymentFrequency:      lease.EveryNMonths,
+				PaymentIntervalMonths: 5,
+				PaymentTiming:         lease.Advance,
+				DiscountRate:          0.07,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			liability, err := CalculateLeaseLiability(tt.lease)
+			if err != nil {
+				t.Fatalf("CalculateLeaseLiability() error = %v", err)
+			}
+			schedule, err := GenerateLiabilitySchedule(tt.lease, liability)
+			if err != nil {
+				t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+			}
+
+			var totalPayments, totalInterest, totalPrincipal money.Amount
+			for _, entry := range schedule {
+				totalPayments += entry.Payment
+				totalInterest += entry.InterestExpense
+				totalPrincipal += entry.PrincipalRepayment
+			}
+
+			// The payment due on commencement of a lease in advance is not settled against the liability
+			periods, _, _ := getPeriodsAndRate(tt.lease)
+			expectedPayments := money.Amount(periods) * tt.lease.PaymentAmount
+			if tt.lease.PaysInAdvance() {
+				expectedPayments -= tt.lease.PaymentAmount
+			}
+			for _, extra := range tt.lease.ExtraPayments {
+				expectedPayments += extra.Amount
+			}
+			if tt.expectedPayments != 0 {
+				expectedPayments = tt.expectedPayments
+			}
+
+			if totalPayments != expectedPayments {
+				t.Errorf("Total payments = %s, want %s", totalPayments, expectedPayments)
+			}
+			// Principal repaid over the term equals the measured liability
+			if totalPrincipal != liability {
+				t.Errorf("Total principal = %s, want %s", totalPrincipal, liability)
+			}
+			// Interest is whatever remains of the payments, not a fixed ratio. Every daily row
+			// reconciles exactly, so the totals do too.
+			if totalInterest != expectedPayments-liability {
+				t.Errorf("Total interest = %s, want %s", totalInterest, expectedPayments-liability)
+			}
+			for _, entry := range schedule {
+				if entry.OpeningBalance+entry.InterestExpense-entry.Payment != entry.ClosingBalance {
+					t.Fatalf("Entry on %s does not reconcile: %+v", entry.Date.Format(testDateLayoutAmort), entry)
+				}
+			}
+
+			lastEntry := schedule[len(schedule)-1]
+			if lastEntry.ClosingBalance != 0 {
+				t.Errorf("Last Entry ClosingBalance = %s, want 0.00", lastEntry.ClosingBalance)
+			}
+		})
+	}
+}
+
+func TestGenerateLiabilityScheduleExtraPayments(t *testing.T) {
+	baseLease := lease.Lease{
+		ID:               "L006-Extra",
+		StartDate:        mustParseDateAmort(testDateLayoutAmort, "2024-07-01"),
+		EndDate:          mustParseDateAmort(testDateLayoutAmort, "2025-06-30"),
+		PaymentAmount:    1000 * money.Unit,
+		PaymentFrequency: lease.Monthly,
+		DiscountRate:     0.05,
+	}
+
+	feb2025 := mustParseDateAmort(testDateLayoutAmort, "2025-02-01")
+
+	t.Run("No payments are added beyond the lease terms", func(t *testing.T) {
+		liability, _ := CalculateLeaseLiability(baseLease)
+		schedule, err := GenerateLiabilitySchedule(baseLease, liability)
+		if err != nil {
+			t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+		}
+		for _, entry := range schedule {
+			if entry.Date.Equal(feb2025) && entry.Payment != 1000*money.Unit {
+				t.Errorf("Payment on %s = %s, want 1000.00", feb2025.Format(testDateLayoutAmort), entry.Payment)
+			}
+		}
+	})
+
+	t.Run("Variable payments stay out of the liability", func(t *testing.T) {
+		withVariable := baseLease
+		withVariable.ExtraPayments = []lease.ExtraPayment{
+			{Date: feb2025, Amount: 100000 * money.Unit, Type: lease.OtherPayment, Variable: true},
+		}
+
+		base, _ := CalculateLeaseLiability(baseLease)
+		liability, err := CalculateLeaseLiability(withVariable)
+		if err != nil {
+			t.Fatalf("CalculateLeaseLiability() error = %v", err)
+		}
+		if liability != base {
+			t.Errorf("Liability with variable payment = %s, want %s", liability, base)
+		}
+
+		schedule, err := GenerateLiabilitySchedule(withVariable, liability)
+		if err != nil {
+			t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+		}
+		lastEntry := schedule[len(schedule)-1]
+		if lastEntry.ClosingBalance != 0 {
+			t.Errorf("Last Entry ClosingBalance = %s, want 0.00", lastEntry.ClosingBalance)
+		}
+
+		variable := VariableLeasePayments(withVariable)
+		if len(variable) != 1 || variable[0].Amount != 100000*money.Unit {
+			t.Errorf("VariableLeasePayments() = %v, want the single variable payment", variable)
+		}
+	})
+
+	t.Run("Fixed key money is included in the liability", func(t *testing.T) {
+		withKeyMoney := baseLease
+		withKeyMoney.ExtraPayments = []lease.ExtraPayment{
+			{Date: feb2025, Amount: 5000 * money.Unit, Type: lease.KeyMoney},
+		}
+
+		base, _ := CalculateLeaseLiability(baseLease)
+		liability, err := CalculateLeaseLiability(withKeyMoney)
+		if err != nil {
+			t.Fatalf("CalculateLeaseLiability() error = %v", err)
+		}
+		if liability <= base || liability >= base+5000*money.Unit {
+			t.Errorf("Liability with key money = %s, want between %s and %s", liability, base, base+5000*money.Unit)
+		}
+
+		schedule, err := GenerateLiabilitySchedule(withKeyMoney, liability)
+		if err != nil {
+			t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+		}
+		lastEntry := schedule[len(schedule)-1]
+		if lastEntry.ClosingBalance != 0 {
+			t.Errorf("Last Entry ClosingBalance = %s, want 0.00", lastEntry.ClosingBalance)
+		}
+	})
+}
+
+func TestGenerateLiabilityScheduleResidualValue(t *testing.T) {
+	l := lease.Lease{
+		ID:               "L007-RVG",
+		StartDate:        mustParseDateAmort(testDateLayoutAmort, "2024-07-01"),
+		EndDate:          mustParseDateAmort(testDateLayoutAmort, "2025-06-30"),
+		PaymentAmount:    1000 * money.Unit,
+		PaymentFrequency: lease.Monthly,
+		DiscountRate:     0.05,
+		ResidualValue:    2500 * money.Unit,
+	}
+
+	// The guarantee is discounted like a payment due on the end date
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	asPayment := l
+	asPayment.ResidualValue = 0
+	asPayment.ExtraPayments = []lease.ExtraPayment{{Date: l.EndDate, Amount: 2500 * money.Unit}}
+	want, err := CalculateLeaseLiability(asPayment)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	if liability != want {
+		t.Errorf("Liability with residual value guarantee = %s, want %s", liability, want)
+	}
+
+	schedule, err := GenerateLiabilitySchedule(l, liability)
+	if err != nil {
+		t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+	}
+	for _, entry := range schedule[:len(schedule)-1] {
+		if entry.ResidualValue != 0 {
+			t.Fatalf("Residual value guarantee paid on %s, want only on the end date", entry.Date.Format(testDateLayoutAmort))
+		}
+	}
+	last := schedule[len(schedule)-1]
+	if last.ResidualValue != 2500*money.Unit || last.Payment != 3500*money.Unit || last.ClosingBalance != 0 {
+		t.Errorf("Last entry = %+v, want the final rent and 2500.00 under the guarantee settling the liability", last)
+	}
+
+	l.ResidualValue = -1 * money.Unit
+	if _, err := CalculateLeaseLiability(l); err == nil {
+		t.Errorf("CalculateLeaseLiability() expected an error for a negative residual value guarantee")
+	}
+}
+
+func TestGenerateLiabilityScheduleIrregular(t *testing.T) {
+	start := mustParseDateAmort(testDateLayoutAmort, "2024-01-01")
+	l := lease.Lease{
+		ID:               "L007-Irregular",
+		StartDate:        start,
+		EndDate:          mustParseDateAmort(testDateLayoutAmort, "2025-06-30"),
+		PaymentFrequency: lease.Irregular,
+		DiscountRate:     0.08,
+		PaymentSchedule: []lease.ScheduledPayment{
+			{Date: start, Amount: 2000 * money.Unit}, // Deposit on commencement
+			{Date: mustParseDateAmort(testDateLayoutAmort, "2024-03-15"), Amount: 5000 * money.Unit},
+			{Date: mustParseDateAmort(testDateLayoutAmort, "2024-09-30"), Amount: 12000 * money.Unit},
+			{Date: mustParseDateAmort(testDateLayoutAmort, "2025-06-30"), Amount: 20000 * money.Unit},
+		},
+	}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	if liability != money.FromFloat(34076.43) {
+		t.Errorf("Liability = %s, want 34076.43 (the commencement payment is excluded)", liability)
+	}
+
+	components, err := CalculateInitialRoUAssetComponents(liability, l)
+	if err != nil {
+		t.Fatalf("CalculateInitialRoUAssetComponents() error = %v", err)
+	}
+	if components.PaymentsAtCommencement != 2000*money.Unit {
+		t.Errorf("PaymentsAtCommencement = %s, want 2000.00", components.PaymentsAtCommencement)
+	}
+
+	schedule, err := GenerateLiabilitySchedule(l, liability)
+	if err != nil {
+		t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+	}
+
+	// Each scheduled payment after commencement is settled on its own date
+	for _, scheduled := range l.PaymentSchedule[1:] {
+		entry, ok := entryOn(schedule, scheduled.Date)
+		if !ok || entry.Payment != scheduled.Amount {
+			t.Errorf("Payment on %s = %s, want %s", scheduled.Date.Format(testDateLayoutAmort), entry.Payment, scheduled.Amount)
+		}
+	}
+	// Interest over the term is the undiscounted payments less the liability
+	totalInterest := money.Amount(0)
+	for _, entry := range schedule {
+		totalInterest += entry.InterestExpense
+	}
+	if totalInterest != 37000*money.Unit-liability {
+		t.Errorf("Total interest = %s, want %s", totalInterest, 37000*money.Unit-liability)
+	}
+
+	lastEntry := schedule[len(schedule)-1]
+	if lastEntry.ClosingBalance != 0 {
+		t.Errorf("Last Entry ClosingBalance = %s, want 0.00", lastEntry.ClosingBalance)
+	}
+}
@@ -0,0 +1,86 @@
+package calculation
+
+import (
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"sort"
+)
+
+// CombineComponents applies the practical expedient of IFRS 16.15 to a lease in one of the
+// asset classes it is elected for: the non-lease components are not separated, so the whole
+// of each payment is accounted for as the lease component. It reports whether the expedient
+// applied; a lease without non-lease components is returned unchanged.
+func CombineComponents(l lease.Lease, classes []string) (lease.Lease, bool) {
+	if len(l.NonLeaseComponents) == 0 || !elects(classes, l.AssetClass) {
+		return l, false
+	}
+	l.NonLeaseComponents = nil
+	l.LeaseStandAlonePrice = 0
+	return l, true
+}
+
+// validateComponents checks that the non-lease components of a lease split its payments on a
+// single basis: either every component has an amount, or every component and the lease have
+// a stand-alone price.
+func validateComponents(l lease.Lease) error {
+	if len(l.NonLeaseComponents) == 0 {
+		return nil
+	}
+	if l.LeaseStandAlonePrice < 0 {
+		return fmt.Errorf("lease stand-alone price cannot be negative: %s", l.LeaseStandAlonePrice)
+	}
+
+	byAmount := false
+	for _, c := range l.NonLeaseComponents {
+		byAmount = byAmount || c.Amount != 0
+	}
+	for i, c := range l.NonLeaseComponents {
+		if c.Amount < 0 || c.StandAlonePrice < 0 {
+			return fmt.Errorf("non-lease component %d: amounts cannot be negative", i+1)
+		}
+		if byAmount && c.Amount == 0 {
+			return fmt.Errorf("non-lease component %d: every component needs an amount when the payments are split by amount", i+1)
+		}
+		if !byAmount && c.StandAlonePrice == 0 {
+			return fmt.Errorf("non-lease component %d: needs an amount or a stand-alone price", i+1)
+		}
+	}
+	if !byAmount && l.LeaseStandAlonePrice == 0 {
+		return fmt.Errorf("a lease stand-alone price is needed to allocate the payments by relative stand-alone price")
+	}
+	return nil
+}
+
+// NonLeasePayments returns the part of each regular payment that pays for non-lease
+// components, dated when it is paid. These payments are not part of the lease liability; they
+// are expensed as the services are received, taken here as when they are paid.
+func NonLeasePayments(l lease.Lease) ([]lease.ScheduledPayment, error) {
+	if len(l.NonLeaseComponents) == 0 {
+		return nil, nil
+	}
+	flows, _, _, err := liabilityCashFlows(l)
+	if err != nil {
+		return nil, err
+	}
+
+	var payments []lease.ScheduledPayment
+	for _, payment := range regularPaymentsAtCommencement(l) {
+		leaseComponent := l.LeaseComponent(payment.Amount)
+		if leaseComponent < 0 {
+			return nil, fmt.Errorf("non-lease components exceed the payment of %s on %s", payment.Amount, payment.Date.Format("2006-01-02"))
+		}
+		if nonLease := payment.Amount - leaseComponent; nonLease != 0 {
+			payments = append(payments, lease.ScheduledPayment{Date: payment.Date, Amount: nonLease})
+		}
+	}
+	for _, flow := range flows {
+		if flow.nonLease != 0 {
+			payments = append(payments, lease.ScheduledPayment{Date: flow.date, Amount: flow.nonLease})
+		}
+	}
+
+	sort.SliceStable(payments, func(i, j int) bool {
+		return payments[i].Date.Before(payments[j].Date)
+	})
+	return payments, nil
+}
@@ -0,0 +1,156 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"testing"
+)
+
+func TestNonLeaseComponentsExcludedFromLiability(t *testing.T) {
+	leaseOnly := testLease("2024-01-01", "2026-01-01")
+	leaseOnly.PaymentAmount = 800 * money.Unit
+	want, err := CalculateLeaseLiability(leaseOnly)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+
+	byAmount := testLease("2024-01-01", "2026-01-01")
+	byAmount.NonLeaseComponents = []lease.NonLeaseComponent{
+		{Description: "Service charge", Amount: 150 * money.Unit},
+		{Description: "Maintenance", Amount: 50 * money.Unit},
+	}
+	byPrice := testLease("2024-01-01", "2026-01-01")
+	byPrice.LeaseStandAlonePrice = 9600 * money.Unit
+	byPrice.NonLeaseComponents = []lease.NonLeaseComponent{{Description: "Service charge", StandAlonePrice: 2400 * money.Unit}}
+
+	for name, l := range map[string]lease.Lease{"By amount": byAmount, "By stand-alone price": byPrice} {
+		t.Run(name, func(t *testing.T) {
+			got, err := CalculateLeaseLiability(l)
+			if err != nil {
+				t.Fatalf("CalculateLeaseLiability() error = %v", err)
+			}
+			if got != want {
+				t.Errorf("Liability = %s, want %s for the lease component only", got, want)
+			}
+
+			schedule, err := GenerateLiabilitySchedule(l, got)
+			if err != nil {
+				t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+			}
+			var payments money.Amount
+			for _, entry := range schedule {
+				payments += entry.Payment
+			}
+			if payments != 24*800*money.Unit || schedule[len(schedule)-1].ClosingBalance != 0 {
+				t.Errorf("Liability schedule settles %s, want 19200.00 closing at 0", payments)
+			}
+
+			nonLease, err := NonLeasePayments(l)
+			if err != nil {
+				t.Fatalf("NonLeasePayments() error = %v", err)
+			}
+			var total money.Amount
+			for _, p := range nonLease {
+				total += p.Amount
+			}
+			if len(nonLease) != 24 || total != 24*200*money.Unit {
+				t.Errorf("NonLeasePayments() = %d payments totalling %s, want 24 totalling 4800.00", len(nonLease), total)
+			}
+
+			// The non-lease components of the payments made in a period are its operating expense
+			result := CalculationResult{LiabilitySchedule: schedule, NonLeasePayments: nonLease}
+			if err := CalculateAccountingPeriodSummary(&result, "2025-01-01", "2025-12-31"); err != nil {
+				t.Fatalf("CalculateAccountingPeriodSummary() error = %v", err)
+			}
+			if result.PeriodNonLeaseExpense != 12*200*money.Unit || result.PeriodPayments != 12*800*money.Unit {
+				t.Errorf("Period non-lease expense = %s, payments = %s; want 2400.00 and 9600.00", result.PeriodNonLeaseExpense, result.PeriodPayments)
+			}
+		})
+	}
+}
+
+func TestNonLeaseComponentsAtCommencement(t *testing.T) {
+	l := testLease("2024-01-01", "2026-01-01")
+	l.PaymentTiming = lease.Advance
+	l.NonLeaseComponents = []lease.NonLeaseComponent{{Description: "Service charge", Amount: 200 * money.Unit}}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	components, err := CalculateInitialRoUAssetComponents(liability, l)
+	if err != nil {
+		t.Fatalf("CalculateInitialRoUAssetComponents() error = %v", err)
+	}
+	if components.PaymentsAtCommencement != 800*money.Unit {
+		t.Errorf("Payments at commencement = %s, want the 800.00 lease component", components.PaymentsAtCommencement)
+	}
+
+	nonLease, err := NonLeasePayments(l)
+	if err != nil {
+		t.Fatalf("NonLeasePayments() error = %v", err)
+	}
+	if len(nonLease) != 24 || !nonLease[0].Date.Equal(l.StartDate) {
+		t.Errorf("NonLeasePayments() = %+v, want 24 payments from the start date", nonLease)
+	}
+}
+
+func TestNonLeaseComponentErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*lease.Lease)
+	}{
+		{"Components exceed the payment", func(l *lease.Lease) {
+			l.NonLeaseComponents = []lease.NonLeaseComponent{{Amount: 1200 * money.Unit}}
+		}},
+		{"Mixed bases", func(l *lease.Lease) {
+			l.LeaseStandAlonePrice = 9600 * money.Unit
+			l.NonLeaseComponents = []lease.NonLeaseComponent{{Amount: 100 * money.Unit}, {StandAlonePrice: 2400 * money.Unit}}
+		}},
+		{"No lease stand-alone price", func(l *lease.Lease) {
+			l.NonLeaseComponents = []lease.NonLeaseComponent{{StandAlonePrice: 2400 * money.Unit}}
+		}},
+		{"Negative amount", func(l *lease.Lease) {
+			l.NonLeaseComponents = []lease.NonLeaseComponent{{Amount: -100 * money.Unit}}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease("2024-01-01", "2026-01-01")
+			tt.modify(&l)
+			if _, err := CalculateLeaseLiability(l); err == nil {
+				t.Errorf("CalculateLeaseLiability() expected an error")
+			}
+		})
+	}
+}
+
+func TestCombineComponents(t *testing.T) {
+	l := testLease("2024-01-01", "2026-01-01")
+	l.AssetClass = "Property"
+	l.NonLeaseComponents = []lease.NonLeaseComponent{{Description: "Service charge", Amount: 200 * money.Unit}}
+
+	if _, combined := CombineComponents(l, []string{"Vehicles"}); combined {
+		t.Errorf("CombineComponents() applied to a class it is not elected for")
+	}
+
+	combinedLease, combined := CombineComponents(l, []string{"property"})
+	if !combined || len(combinedLease.NonLeaseComponents) != 0 {
+		t.Fatalf("CombineComponents() = %+v, %v; want the components combined", combinedLease.NonLeaseComponents, combined)
+	}
+	got, err := CalculateLeaseLiability(combinedLease)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	want, err := CalculateLeaseLiability(testLease("2024-01-01", "2026-01-01"))
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("Liability with components combined = %s, want %s for the whole payment", got, want)
+	}
+	if len(l.NonLeaseComponents) != 1 {
+		t.Errorf("CombineComponents() changed the components of the original lease")
+	}
+}
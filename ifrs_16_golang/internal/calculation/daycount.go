@@ -0,0 +1,110 @@
+package calculation
+
+import (
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"math"
+	"time"
+)
+
+// dayCountFor returns the day-count convention of a lease. Regular payments default to
+// Actual/Actual, which keeps every payment period the same length; an irregular payment
+// schedule defaults to Actual/365F, the basis of a spreadsheet XNPV.
+func dayCountFor(l lease.Lease) (lease.DayCountConvention, error) {
+	switch l.DayCount {
+	case "":
+		if l.PaymentFrequency == lease.Irregular {
+			return lease.Actual365Fixed, nil
+		}
+		return lease.ActualActual, nil
+	case lease.Actual365Fixed, lease.Actual360, lease.Thirty360, lease.ActualActual:
+		return l.DayCount, nil
+	default:
+		return "", fmt.Errorf("unsupported day-count convention: %s", l.DayCount)
+	}
+}
+
+// rateBasisFor returns how the discount rate of a lease is quoted, nominal by default.
+func rateBasisFor(l lease.Lease) (lease.RateBasis, error) {
+	switch l.RateBasis {
+	case "":
+		return lease.NominalRate, nil
+	case lease.NominalRate, lease.EffectiveRate:
+		return l.RateBasis, nil
+	default:
+		return "", fmt.Errorf("unsupported rate basis: %s", l.RateBasis)
+	}
+}
+
+// LeaseConventions returns the day-count convention and rate basis a lease is measured on,
+// with the defaults filled in.
+func LeaseConventions(l lease.Lease) (lease.DayCountConvention, lease.RateBasis, error) {
+	dayCount, err := dayCountFor(l)
+	if err != nil {
+		return "", "", err
+	}
+	basis, err := rateBasisFor(l)
+	if err != nil {
+		return "", "", err
+	}
+	return dayCount, basis, nil
+}
+
+// periodicRateFor converts the annual discount rate into the rate per payment period. A
+// nominal rate is divided evenly over the periods; an effective annual rate is the
+// equivalent compound rate per period.
+func periodicRateFor(l lease.Lease, cycle paymentCycle) (float64, error) {
+	basis, err := rateBasisFor(l)
+	if err != nil {
+		return 0, err
+	}
+	if basis == lease.EffectiveRate {
+		return math.Pow(1+l.DiscountRate, 1/cycle.periodsPerYear()) - 1, nil
+	}
+	return l.DiscountRate / cycle.periodsPerYear(), nil
+}
+
+// yearFraction measures the time from start to end in years under a day-count convention.
+// Actual/Actual splits the interval at calendar year ends and counts each part over the days
+// in its year (the ISDA method).
+func yearFraction(convention lease.DayCountConvention, start, end time.Time) float64 {
+	switch convention {
+	case lease.Actual360:
+		return daysBetween(start, end) / 360
+	case lease.Thirty360:
+		return thirty360Days(start, end) / 360
+	case lease.ActualActual:
+		fraction := 0.0
+		for start.Before(end) {
+			yearStart := time.Date(start.Year(), 1, 1, 0, 0, 0, 0, start.Location())
+			nextYear := yearStart.AddDate(1, 0, 0)
+			partEnd := end
+			if nextYear.Before(end) {
+				partEnd = nextYear
+			}
+			fraction += daysBetween(start, partEnd) / daysBetween(yearStart, nextYear)
+			start = partEnd
+		}
+		return fraction
+	default:
+		return daysBetween(start, end) / 365
+	}
+}
+
+// daysBetween returns the number of actual days from start to end.
+func daysBetween(start, end time.Time) float64 {
+	return end.Sub(start).Hours() / 24
+}
+
+// thirty360Days counts the days from start to end on 30-day months (the US bond basis):
+// a 31st is treated as the 30th, and an end on the 31st only when the start is the 30th or 31st.
+func thirty360Days(start, end time.Time) float64 {
+	d1, d2 := start.Day(), end.Day()
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+	return float64(360*(end.Year()-start.Year()) + 30*(int(end.Month())-int(start.Month())) + d2 - d1)
+}
@@ -0,0 +1,107 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestYearFraction(t *testing.T) {
+	date := func(s string) time.Time { return mustParseDate(testDateLayout, s) }
+
+	tests := []struct {
+		name       string
+		convention lease.DayCountConvention
+		start, end time.Time
+		want       float64
+	}{
+		{"Actual/365F over a leap year", lease.Actual365Fixed, date("2024-01-01"), date("2025-01-01"), 366.0 / 365},
+		{"Actual/360 over a leap year", lease.Actual360, date("2024-01-01"), date("2025-01-01"), 366.0 / 360},
+		{"30/360 from the 31st", lease.Thirty360, date("2024-01-31"), date("2024-03-01"), 31.0 / 360},
+		{"30/360 from the 30th to the 31st", lease.Thirty360, date("2024-01-30"), date("2024-03-31"), 60.0 / 360},
+		{"30/360 full year", lease.Thirty360, date("2024-01-01"), date("2025-01-01"), 1},
+		{"Actual/Actual across a year end", lease.ActualActual, date("2024-07-01"), date("2025-07-01"), 184.0/366 + 181.0/365},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := yearFraction(tt.convention, tt.start, tt.end); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("yearFraction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateLeaseLiabilityConventions(t *testing.T) {
+	tests := []struct {
+		name        string
+		dayCount    lease.DayCountConvention
+		basis       lease.RateBasis
+		expectedPV  float64
+		expectError bool
+	}{
+		{"Default", "", "", 11681.22, false},
+		{"Actual/Actual nominal", lease.ActualActual, lease.NominalRate, 11681.22, false},
+		{"Actual/Actual effective", lease.ActualActual, lease.EffectiveRate, 11688.17, false},
+		{"30/360 nominal", lease.Thirty360, "", 11681.22, false}, // Every month is exactly 30 days
+		{"Actual/365F nominal", lease.Actual365Fixed, "", 11681.16, false},
+		{"Actual/365F effective", lease.Actual365Fixed, lease.EffectiveRate, 11688.11, false},
+		{"Actual/360 nominal", lease.Actual360, "", 11676.81, false},
+		{"Unknown day count", "Business/252", "", 0, true},
+		{"Unknown rate basis", lease.Actual365Fixed, "Continuous", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease("2024-01-01", "2024-12-31")
+			l.DayCount, l.RateBasis = tt.dayCount, tt.basis
+			pv, err := CalculateLeaseLiability(l)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("CalculateLeaseLiability() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && pv != money.FromFloat(tt.expectedPV) {
+				t.Errorf("CalculateLeaseLiability() PV = %s, want %.2f", pv, tt.expectedPV)
+			}
+		})
+	}
+}
+
+func TestLiabilityScheduleUnwindsUnderConventions(t *testing.T) {
+	dayCounts := []lease.DayCountConvention{lease.Actual365Fixed, lease.Actual360, lease.Thirty360, lease.ActualActual}
+	bases := []lease.RateBasis{lease.NominalRate, lease.EffectiveRate}
+
+	for _, dayCount := range dayCounts {
+		for _, basis := range bases {
+			t.Run(string(dayCount)+" "+string(basis), func(t *testing.T) {
+				l := testLease("2024-01-01", "2026-12-31")
+				l.DayCount, l.RateBasis = dayCount, basis
+				l.PaymentFrequency = lease.Quarterly
+
+				liability, err := CalculateLeaseLiability(l)
+				if err != nil {
+					t.Fatalf("CalculateLeaseLiability() error = %v", err)
+				}
+				schedule, err := GenerateLiabilitySchedule(l, liability)
+				if err != nil {
+					t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+				}
+
+				totalPayments := money.Amount(0)
+				for _, entry := range schedule {
+					totalPayments += entry.Payment
+				}
+				if totalPayments != 12*1000*money.Unit {
+					t.Errorf("Total payments = %s, want 12000.00", totalPayments)
+				}
+
+				// The schedule accrues on the same basis the liability was discounted on, so
+				// the final payment settles the balance
+				if last := schedule[len(schedule)-1]; last.ClosingBalance != 0 {
+					t.Errorf("Last Entry ClosingBalance = %s, want 0.00", last.ClosingBalance)
+				}
+			})
+		}
+	}
+}
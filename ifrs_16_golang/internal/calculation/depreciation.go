@@ -0,0 +1,152 @@
+package calculation
+
+import (
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"math"
+	"time"
+)
+
+// DepreciationMethodFor returns the depreciation method of a lease, straight-line by default.
+func DepreciationMethodFor(l lease.Lease) (lease.DepreciationMethod, error) {
+	switch l.DepreciationMethod {
+	case "":
+		return lease.StraightLine, nil
+	case lease.StraightLine, lease.UnitsOfProduction, lease.DiminishingBalance:
+		return l.DepreciationMethod, nil
+	default:
+		return "", fmt.Errorf("unsupported depreciation method: %s", l.DepreciationMethod)
+	}
+}
+
+// depreciationProfile spreads a carrying amount over the days from firstDate to endDate
+// under the depreciation method of a lease. It returns the cumulative depreciation booked
+// by the close of each day as a function of the day number (1 for firstDate) and, for units
+// of production, the units used on each day. The cumulative amount reaches the full carrying
+// amount on the last day, so the daily charges depreciate the asset to exactly zero.
+func depreciationProfile(l lease.Lease, opening money.Amount, firstDate, endDate time.Time) (func(day int) money.Amount, []float64, error) {
+	method, err := DepreciationMethodFor(l)
+	if err != nil {
+		return nil, nil, err
+	}
+	totalDays := int(endDate.Sub(firstDate).Hours()/24) + 1
+
+	var shares []float64 // Cumulative share of the carrying amount depreciated by each day
+	var units []float64
+	switch method {
+	case lease.StraightLine:
+		return func(day int) money.Amount {
+			return opening.Share(int64(day), int64(totalDays))
+		}, nil, nil
+	case lease.UnitsOfProduction:
+		units, err = dailyUsage(l, firstDate, totalDays)
+		if err != nil {
+			return nil, nil, err
+		}
+		shares = usageShares(units)
+	case lease.DiminishingBalance:
+		shares, err = diminishingBalanceShares(l, firstDate, endDate, totalDays)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return func(day int) money.Amount {
+		if day >= totalDays {
+			return opening
+		}
+		if day <= 0 {
+			return 0
+		}
+		return money.FromFloat(opening.Float64() * shares[day-1])
+	}, units, nil
+}
+
+// dailyUsage spreads each usage estimate of a lease evenly over the days it covers and
+// returns the units used on each of the totalDays days from firstDate.
+func dailyUsage(l lease.Lease, firstDate time.Time, totalDays int) ([]float64, error) {
+	if len(l.UsageForecast) == 0 {
+		return nil, fmt.Errorf("the %s depreciation method requires a usage forecast", lease.UnitsOfProduction)
+	}
+
+	units := make([]float64, totalDays)
+	from := l.StartDate
+	for _, estimate := range l.UsageForecast {
+		if estimate.Units < 0 {
+			return nil, fmt.Errorf("usage estimate for %s cannot be negative: %.2f",
+				estimate.Date.Format("2006-01-02"), estimate.Units)
+		}
+		if estimate.Date.Before(from) {
+			return nil, fmt.Errorf("usage estimate for %s is out of order or before the start date",
+				estimate.Date.Format("2006-01-02"))
+		}
+
+		days := int(estimate.Date.Sub(from).Hours()/24) + 1
+		perDay := estimate.Units / float64(days)
+		for date := from; !date.After(estimate.Date); date = date.AddDate(0, 0, 1) {
+			if i := int(date.Sub(firstDate).Hours() / 24); i >= 0 && i < totalDays {
+				units[i] += perDay
+			}
+		}
+		from = estimate.Date.AddDate(0, 0, 1)
+	}
+
+	total := 0.0
+	for _, u := range units {
+		total += u
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("the usage forecast has no units within the depreciation period from %s",
+			firstDate.Format("2006-01-02"))
+	}
+	return units, nil
+}
+
+// usageShares converts daily units into the cumulative share of the total used by each day.
+func usageShares(units []float64) []float64 {
+	total := 0.0
+	for _, u := range units {
+		total += u
+	}
+
+	shares := make([]float64, len(units))
+	used := 0.0
+	for i, u := range units {
+		used += u
+		shares[i] = used / total
+	}
+	return shares
+}
+
+// diminishingBalanceShares returns the cumulative share of the carrying amount depreciated by
+// each day under the diminishing-balance method. The annual rate is applied as an equivalent
+// daily rate to the remaining carrying amount; once spreading what remains evenly over the
+// remaining days would charge more, the method switches to straight-line, so the asset is
+// fully depreciated by the end date. Without a rate, double the straight-line rate over the
+// whole depreciation period from the start date is used.
+func diminishingBalanceShares(l lease.Lease, firstDate, endDate time.Time, totalDays int) ([]float64, error) {
+	rate := l.DiminishingBalanceRate
+	if rate == 0 {
+		years := (endDate.Sub(l.StartDate).Hours()/24 + 1) / 365
+		rate = math.Min(2/years, 0.99)
+	}
+	if rate <= 0 || rate >= 1 {
+		return nil, fmt.Errorf("diminishing balance rate must be between 0 and 100%%: %.4f", rate)
+	}
+
+	dailyFactor := math.Pow(1-rate, 1.0/365)
+	shares := make([]float64, totalDays)
+	remaining := 1.0
+	straightLine := false
+	for i := range shares {
+		charge := remaining * (1 - dailyFactor)
+		if evenly := remaining / float64(totalDays-i); straightLine || evenly >= charge {
+			straightLine = true
+			charge = evenly
+		}
+		remaining -= charge
+		shares[i] = 1 - remaining
+	}
+	return shares, nil
+}
@@ -0,0 +1,163 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"math"
+	"testing"
+)
+
+// yearlyDepreciation totals the depreciation of a daily schedule by calendar year.
+func yearlyDepreciation(schedule []AmortizationEntry) map[int]money.Amount {
+	totals := map[int]money.Amount{}
+	for _, entry := range schedule {
+		totals[entry.Date.Year()] += entry.Depreciation
+	}
+	return totals
+}
+
+func TestRoUAssetScheduleUnitsOfProduction(t *testing.T) {
+	l := testLease("2024-01-01", "2026-12-31")
+	l.DepreciationMethod = lease.UnitsOfProduction
+	l.UsageForecast = []lease.UsageEstimate{
+		{Date: mustParseDate(testDateLayout, "2024-12-31"), Units: 1000},
+		{Date: mustParseDate(testDateLayout, "2025-12-31"), Units: 3000},
+		{Date: mustParseDate(testDateLayout, "2026-12-31"), Units: 1000},
+	}
+	opening := 80000 * money.Unit
+
+	schedule, err := GenerateRoUAssetSchedule(l, opening)
+	if err != nil {
+		t.Fatalf("GenerateRoUAssetSchedule() error = %v", err)
+	}
+
+	// Depreciation follows the forecast use: 20%, 60% and 20% of the asset
+	yearly := yearlyDepreciation(schedule)
+	for year, share := range map[int]float64{2024: 0.2, 2025: 0.6, 2026: 0.2} {
+		if want := opening.Mul(share); (yearly[year] - want).Abs() > money.Cent {
+			t.Errorf("%d depreciation = %s, want %s", year, yearly[year], want)
+		}
+	}
+
+	units := 0.0
+	for _, entry := range schedule {
+		units += entry.UnitsUsed
+	}
+	if math.Abs(units-5000) > 1e-6 {
+		t.Errorf("Units used = %.4f, want 5000", units)
+	}
+	if last := schedule[len(schedule)-1]; last.ClosingBalance != 0 {
+		t.Errorf("Last entry ClosingBalance = %s, want 0.00", last.ClosingBalance)
+	}
+	if yearly[2024]+yearly[2025]+yearly[2026] != opening {
+		t.Errorf("Depreciation totals %s, want %s", yearly[2024]+yearly[2025]+yearly[2026], opening)
+	}
+}
+
+func TestRoUAssetScheduleDiminishingBalance(t *testing.T) {
+	l := testLease("2024-01-01", "2026-12-31")
+	l.DepreciationMethod = lease.DiminishingBalance
+	l.DiminishingBalanceRate = 0.5
+	opening := 80000 * money.Unit
+
+	schedule, err := GenerateRoUAssetSchedule(l, opening)
+	if err != nil {
+		t.Fatalf("GenerateRoUAssetSchedule() error = %v", err)
+	}
+
+	// Half the carrying amount goes in the first year (366 days in 2024)
+	yearly := yearlyDepreciation(schedule)
+	want := opening.Mul(1 - math.Pow(0.5, 366.0/365))
+	if (yearly[2024] - want).Abs() > money.Cent {
+		t.Errorf("2024 depreciation = %s, want %s", yearly[2024], want)
+	}
+
+	// The charge declines, then switches to straight-line to reach exactly zero
+	for i := 1; i < len(schedule); i++ {
+		if schedule[i].Depreciation > schedule[i-1].Depreciation+money.Cent {
+			t.Fatalf("Depreciation rises on %s: %s after %s", schedule[i].Date.Format(testDateLayout),
+				schedule[i].Depreciation, schedule[i-1].Depreciation)
+		}
+	}
+	if last := schedule[len(schedule)-1]; last.ClosingBalance != 0 {
+		t.Errorf("Last entry ClosingBalance = %s, want 0.00", last.ClosingBalance)
+	}
+	if yearly[2026] <= opening.Mul(0.25*0.5) {
+		t.Errorf("2026 depreciation = %s, want the straight-line write-off of the remaining balance", yearly[2026])
+	}
+
+	// Without a rate, double the straight-line rate over three years applies
+	l.DiminishingBalanceRate = 0
+	defaulted, err := GenerateRoUAssetSchedule(l, opening)
+	if err != nil {
+		t.Fatalf("GenerateRoUAssetSchedule() error = %v", err)
+	}
+	first := yearlyDepreciation(defaulted)[2024]
+	if want := opening.Mul(1 - math.Pow(1-2/(1096.0/365), 366.0/365)); (first - want).Abs() > money.Cent {
+		t.Errorf("2024 depreciation at the default rate = %s, want %s", first, want)
+	}
+}
+
+func TestRoUAssetScheduleMethodErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(l *lease.Lease)
+	}{
+		{"Unsupported method", func(l *lease.Lease) { l.DepreciationMethod = "SumOfYearsDigits" }},
+		{"Units of production without a forecast", func(l *lease.Lease) { l.DepreciationMethod = lease.UnitsOfProduction }},
+		{"Forecast without units", func(l *lease.Lease) {
+			l.DepreciationMethod = lease.UnitsOfProduction
+			l.UsageForecast = []lease.UsageEstimate{{Date: l.EndDate, Units: 0}}
+		}},
+		{"Forecast out of order", func(l *lease.Lease) {
+			l.DepreciationMethod = lease.UnitsOfProduction
+			l.UsageForecast = []lease.UsageEstimate{{Date: l.EndDate, Units: 10}, {Date: l.StartDate, Units: 10}}
+		}},
+		{"Diminishing balance rate of 100%", func(l *lease.Lease) {
+			l.DepreciationMethod = lease.DiminishingBalance
+			l.DiminishingBalanceRate = 1
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease("2024-01-01", "2026-12-31")
+			tt.modify(&l)
+			if _, err := GenerateRoUAssetSchedule(l, 1000*money.Unit); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestUnitsOfProductionAfterModification(t *testing.T) {
+	l := testLease("2024-01-01", "2026-12-31")
+	l.DepreciationMethod = lease.UnitsOfProduction
+	l.UsageForecast = []lease.UsageEstimate{
+		{Date: mustParseDate(testDateLayout, "2025-12-31"), Units: 4000},
+		{Date: mustParseDate(testDateLayout, "2026-12-31"), Units: 1000},
+	}
+	effective := mustParseDate(testDateLayout, "2025-01-01")
+	l.Modifications = []lease.Modification{{EffectiveDate: effective, NewPaymentAmount: 1200 * money.Unit, RevisedDiscountRate: 0.06}}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	result, err := ApplyModifications(l, liability, liability)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+
+	// The remeasured asset is spread over the use still to come; the first estimate covers
+	// 731 days, of which 365 fall in 2025
+	r := result.Remeasurements[0]
+	yearly := yearlyDepreciation(result.RoUAssetSchedule)
+	units2025 := 4000 * 365.0 / 731
+	if want := r.RoUAssetAfter.Mul(units2025 / (units2025 + 1000)); (yearly[2025] - want).Abs() > money.Cent {
+		t.Errorf("2025 depreciation = %s, want %s", yearly[2025], want)
+	}
+	if last := result.RoUAssetSchedule[len(result.RoUAssetSchedule)-1]; last.ClosingBalance != 0 {
+		t.Errorf("Last entry ClosingBalance = %s, want 0.00", last.ClosingBalance)
+	}
+}
@@ -0,0 +1,142 @@
+package calculation
+
+import (
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"strings"
+	"time"
+)
+
+// Exemption identifies the recognition exemption of IFRS 16.5 applied to a lease.
+type Exemption string
+
+const (
+	NoExemption        Exemption = ""          // The lease is recognised on the balance sheet
+	ShortTermExemption Exemption = "ShortTerm" // Lease term of 12 months or less without a purchase option (IFRS 16.5(a))
+	LowValueExemption  Exemption = "LowValue"  // Underlying asset of low value when new (IFRS 16.5(b))
+)
+
+const (
+	DefaultLowValueThreshold = 5000 * money.Unit // The order of magnitude the IASB had in mind (IFRS 16.BC100)
+	DefaultLowValueCurrency  = "USD"
+)
+
+// ExemptionPolicy sets which leases the entity has elected to exempt from recognition. The
+// short-term election is made by class of underlying asset (IFRS 16.8); the low-value election
+// may be made lease by lease and is given by class here too. A class of "*" elects an exemption
+// for every class. The zero value elects no exemption.
+type ExemptionPolicy struct {
+	ShortTermClasses  []string
+	LowValueClasses   []string
+	LowValueThreshold money.Amount // Highest value of an underlying asset when new that is of low value; defaults to DefaultLowValueThreshold
+	LowValueCurrency  string       // Currency of LowValueThreshold; defaults to DefaultLowValueCurrency
+}
+
+// elects reports whether an exemption elected for the given classes applies to class.
+func elects(classes []string, class string) bool {
+	for _, c := range classes {
+		c = strings.TrimSpace(c)
+		if c == "*" || (c != "" && strings.EqualFold(c, strings.TrimSpace(class))) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifyExemption returns the recognition exemption the policy applies to a lease, if any.
+//
+// A lease is short-term when its lease term, as derived from its options, is 12 months or
+// less and it has no purchase option (IFRS 16 Appendix A). An underlying asset is of low
+// value when its value when new is at or below the threshold (IFRS 16.B3-B5); a lease without
+// that value is not assessed, and one in a currency other than the threshold's is an error.
+// A lease that qualifies for both is classed as short-term.
+func ClassifyExemption(l lease.Lease, p ExemptionPolicy) (Exemption, error) {
+	if elects(p.ShortTermClasses, l.AssetClass) {
+		hasPurchaseOption := l.PurchaseOptionPrice > 0 || l.PurchaseOptionReasonablyCertain
+		if !hasPurchaseOption && l.EndDate.Before(l.StartDate.AddDate(1, 0, 0)) {
+			return ShortTermExemption, nil
+		}
+	}
+
+	if elects(p.LowValueClasses, l.AssetClass) && l.UnderlyingAssetValue > 0 {
+		threshold, currency := p.LowValueThreshold, p.LowValueCurrency
+		if threshold == 0 {
+			threshold = DefaultLowValueThreshold
+		}
+		if currency == "" {
+			currency = DefaultLowValueCurrency
+		}
+		if l.Currency != "" && !strings.EqualFold(l.Currency, currency) {
+			return NoExemption, fmt.Errorf("lease currency %s differs from the low-value threshold currency %s", l.Currency, currency)
+		}
+		if l.UnderlyingAssetValue <= threshold {
+			return LowValueExemption, nil
+		}
+	}
+
+	return NoExemption, nil
+}
+
+// ExemptLeaseExpenseSchedule spreads the lease payments of an exempt lease evenly over the
+// days of the lease term (IFRS 16.6). Each entry books the day's share of the expense and the
+// payments made that day; the balance is the expense accrued but not yet paid, negative while
+// rent is prepaid. Payments made before commencement open the schedule as prepaid rent and
+// incentives received as deferred income, so the balance closes at zero on the end date.
+//
+// The payments are the fixed payments that would otherwise be included in the liability
+// (including those at commencement); variable payments remain expensed as incurred.
+func ExemptLeaseExpenseSchedule(l lease.Lease) ([]AmortizationEntry, error) {
+	return straightLineSchedule(l)
+}
+
+// straightLineSchedule spreads the fixed lease payments of a lease, less the incentives and
+// plus the rent paid before commencement, evenly over the days of the lease term, booking
+// each day's share to Expense. The payments are not discounted, so no discount rate is needed.
+func straightLineSchedule(l lease.Lease) ([]AmortizationEntry, error) {
+	periods, err := paymentPeriods(l)
+	if err != nil {
+		return nil, err
+	}
+	flows, err := paymentCashFlows(l, periods)
+	if err != nil {
+		return nil, err
+	}
+
+	totalDays := int(l.EndDate.Sub(l.StartDate).Hours()/24) + 1
+	if totalDays <= 0 {
+		return []AmortizationEntry{}, nil
+	}
+
+	payments := make(map[time.Time]money.Amount, len(flows)+1)
+	payments[l.StartDate] += paymentsAtCommencement(l)
+	opening := l.LeaseIncentives - l.PrepaidRent
+	totalExpense := paymentsAtCommencement(l) + l.PrepaidRent - l.LeaseIncentives
+	for _, flow := range flows {
+		payments[flow.date] += flow.amount
+		totalExpense += flow.amount
+	}
+
+	schedule := make([]AmortizationEntry, 0, totalDays)
+	openingBalance := opening
+	currentDate := l.StartDate
+	for day := 1; day <= totalDays; day++ {
+		expense := totalExpense.Share(int64(day), int64(totalDays)) - totalExpense.Share(int64(day-1), int64(totalDays))
+		payment := payments[currentDate]
+		closingBalance := openingBalance + expense - payment
+
+		schedule = append(schedule, AmortizationEntry{
+			Period:         day,
+			Date:           currentDate,
+			OpeningBalance: openingBalance,
+			Payment:        payment,
+			Expense:        expense,
+			ClosingBalance: closingBalance,
+		})
+
+		openingBalance = closingBalance
+		currentDate = currentDate.AddDate(0, 0, 1) // Move to next day
+	}
+
+	return schedule, nil
+}
@@ -0,0 +1,101 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"testing"
+)
+
+func TestClassifyExemption(t *testing.T) {
+	both := ExemptionPolicy{ShortTermClasses: []string{"*"}, LowValueClasses: []string{"*"}}
+	lowValue := ExemptionPolicy{LowValueClasses: []string{"it equipment"}}
+
+	tests := []struct {
+		name    string
+		modify  func(*lease.Lease)
+		policy  ExemptionPolicy
+		want    Exemption
+		wantErr bool
+	}{
+		{"No election", func(l *lease.Lease) {}, ExemptionPolicy{}, NoExemption, false},
+		{"Short-term", func(l *lease.Lease) {}, both, ShortTermExemption, false},
+		{"Twelve months and a day", func(l *lease.Lease) { l.EndDate = mustParseDate(testDateLayout, "2025-01-01") }, ExemptionPolicy{ShortTermClasses: []string{"*"}}, NoExemption, false},
+		{"Short-term with a purchase option", func(l *lease.Lease) { l.PurchaseOptionPrice = 500 * money.Unit }, ExemptionPolicy{ShortTermClasses: []string{"*"}}, NoExemption, false},
+		{"Short-term not elected for the class", func(l *lease.Lease) {}, ExemptionPolicy{ShortTermClasses: []string{"Vehicles"}}, NoExemption, false},
+		{"Short-term by derived lease term", func(l *lease.Lease) {
+			l.EndDate = mustParseDate(testDateLayout, "2026-12-31")
+			l.Options = []lease.Option{{Type: lease.TerminationOption, EndDate: mustParseDate(testDateLayout, "2024-06-30"), ReasonablyCertain: true}}
+			*l, _ = LeaseTerm(*l)
+		}, ExemptionPolicy{ShortTermClasses: []string{"*"}}, ShortTermExemption, false},
+		{"Low value", func(l *lease.Lease) { l.EndDate = mustParseDate(testDateLayout, "2027-12-31") }, lowValue, LowValueExemption, false},
+		{"Low value at the threshold", func(l *lease.Lease) { l.UnderlyingAssetValue = 5000 * money.Unit }, lowValue, LowValueExemption, false},
+		{"Above the threshold", func(l *lease.Lease) { l.UnderlyingAssetValue = 5000*money.Unit + money.Cent }, lowValue, NoExemption, false},
+		{"Threshold set by the policy", func(l *lease.Lease) {}, ExemptionPolicy{LowValueClasses: []string{"*"}, LowValueThreshold: 1000 * money.Unit}, NoExemption, false},
+		{"No underlying asset value", func(l *lease.Lease) { l.UnderlyingAssetValue = 0 }, lowValue, NoExemption, false},
+		{"Threshold currency", func(l *lease.Lease) { l.Currency = "EUR" }, ExemptionPolicy{LowValueClasses: []string{"*"}, LowValueCurrency: "EUR"}, LowValueExemption, false},
+		{"Currency differs from the threshold", func(l *lease.Lease) { l.Currency = "EUR" }, lowValue, NoExemption, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease("2024-01-01", "2024-12-31")
+			l.AssetClass = "IT Equipment"
+			l.UnderlyingAssetValue = 1800 * money.Unit
+			tt.modify(&l)
+			got, err := ClassifyExemption(l, tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ClassifyExemption() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ClassifyExemption() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExemptLeaseExpenseSchedule(t *testing.T) {
+	l := testLease("2024-01-01", "2024-12-31")
+	l.PaymentAmount = 300 * money.Unit
+	l.DiscountRate = 0 // An exempt lease is not discounted
+	l.PaymentTiming = lease.Advance
+	l.LeaseIncentives = 540 * money.Unit
+	l.PaymentSteps = []lease.PaymentStep{{StartDate: mustParseDate(testDateLayout, "2024-07-01"), Amount: 400 * money.Unit}}
+
+	schedule, err := ExemptLeaseExpenseSchedule(l)
+	if err != nil {
+		t.Fatalf("ExemptLeaseExpenseSchedule() error = %v", err)
+	}
+	if len(schedule) != 366 {
+		t.Fatalf("ExemptLeaseExpenseSchedule() returned %d entries, want 366", len(schedule))
+	}
+
+	// Six payments of 300 and six of 400, less the incentive, spread evenly over 366 days
+	var totalExpense, totalPayments money.Amount
+	for i, entry := range schedule {
+		if entry.OpeningBalance+entry.Expense-entry.Payment != entry.ClosingBalance {
+			t.Errorf("Entry %d does not reconcile: %+v", i+1, entry)
+		}
+		if entry.Expense != 10*money.Unit {
+			t.Errorf("Entry %d expense = %s, want 10.00", i+1, entry.Expense)
+		}
+		totalExpense += entry.Expense
+		totalPayments += entry.Payment
+	}
+	if totalExpense != 3660*money.Unit || totalPayments != 4200*money.Unit {
+		t.Errorf("Total expense = %s, payments = %s; want 3660.00 and 4200.00", totalExpense, totalPayments)
+	}
+	if schedule[0].OpeningBalance != 540*money.Unit || schedule[0].Payment != 300*money.Unit {
+		t.Errorf("First entry = %+v, want the incentive as the opening accrual and the first payment in advance", schedule[0])
+	}
+	if last := schedule[len(schedule)-1]; last.ClosingBalance != 0 {
+		t.Errorf("Expense schedule closes at %s, want 0", last.ClosingBalance)
+	}
+
+	rolled, err := RollUpSchedule(l, schedule, MonthlyGranularity, FiscalCalendar{})
+	if err != nil {
+		t.Fatalf("RollUpSchedule() error = %v", err)
+	}
+	if len(rolled) != 12 || rolled[0].Expense != 310*money.Unit || !rolled[0].Date.Equal(mustParseDate(testDateLayout, "2024-01-31")) {
+		t.Errorf("January = %+v, want 310.00 of expense over 12 months", rolled[0])
+	}
+}
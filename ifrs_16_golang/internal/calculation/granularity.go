@@ -0,0 +1,168 @@
+package calculation
+
+import (
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Granularity sets the span of time each entry of a presented schedule covers. Schedules are
+// always generated day by day, so interest, depreciation and remeasurements accrue exactly;
+// the granularity only decides how the days are rolled up.
+type Granularity string
+
+const (
+	DailyGranularity         Granularity = "daily"   // One entry per calendar day
+	MonthlyGranularity       Granularity = "monthly" // One entry per calendar month (the default)
+	PaymentPeriodGranularity Granularity = "payment" // One entry per payment period
+	FiscalPeriodGranularity  Granularity = "fiscal"  // One entry per fiscal period
+)
+
+// ParseGranularity maps a granularity name onto a Granularity. An empty name is monthly.
+func ParseGranularity(value string) (Granularity, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "monthly", "month":
+		return MonthlyGranularity, nil
+	case "daily", "day":
+		return DailyGranularity, nil
+	case "payment", "paymentperiod", "payment period":
+		return PaymentPeriodGranularity, nil
+	case "fiscal", "fiscalperiod", "fiscal period":
+		return FiscalPeriodGranularity, nil
+	default:
+		return "", fmt.Errorf("unsupported schedule granularity: %s", value)
+	}
+}
+
+// FiscalCalendar describes the periods used by FiscalPeriodGranularity: consecutive periods
+// of Months months running from Start in both directions. The zero value is calendar years.
+type FiscalCalendar struct {
+	Start  time.Time
+	Months int
+}
+
+// periodEnd returns the last day of the fiscal period containing date.
+func (c FiscalCalendar) periodEnd(date time.Time) time.Time {
+	anchor, months := c.Start, c.Months
+	if anchor.IsZero() {
+		anchor = time.Date(date.Year(), 1, 1, 0, 0, 0, 0, date.Location())
+	}
+	if months <= 0 {
+		months = 12
+	}
+
+	elapsed := (date.Year()-anchor.Year())*12 + int(date.Month()) - int(anchor.Month())
+	k := elapsed / months
+	if elapsed < 0 && elapsed%months != 0 {
+		k-- // Round towards the earlier period
+	}
+	for anchor.AddDate(0, k*months, 0).After(date) {
+		k--
+	}
+	for !anchor.AddDate(0, (k+1)*months, 0).After(date) {
+		k++
+	}
+	return anchor.AddDate(0, (k+1)*months, 0).AddDate(0, 0, -1)
+}
+
+// RollUpSchedule groups a daily schedule into one entry per period of the given granularity.
+// Each entry is dated on the last day it covers, opens at the opening balance of its first
+// day, closes at the closing balance of its last day and totals the payments (and the part
+// of them made under a residual value guarantee), interest, depreciation, lease expense and
+// income, principal, remeasurements, impairments and units used in between. Entries are
+// numbered from 1. Every day reconciles exactly, so every rolled-up entry does too.
+//
+// Payment periods end on each payment date, so a payment in arrears closes its period; for
+// payments in advance a period ends the day before the next payment. The lease supplies the
+// payment dates and fiscal supplies the fiscal periods; each is only used by its granularity.
+func RollUpSchedule(l lease.Lease, schedule []AmortizationEntry, granularity Granularity, fiscal FiscalCalendar) ([]AmortizationEntry, error) {
+	var periodEnd func(time.Time) time.Time
+
+	switch granularity {
+	case DailyGranularity:
+		rolled := make([]AmortizationEntry, len(schedule))
+		copy(rolled, schedule)
+		return rolled, nil
+	case MonthlyGranularity:
+		periodEnd = func(date time.Time) time.Time {
+			return time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, date.Location())
+		}
+	case FiscalPeriodGranularity:
+		periodEnd = fiscal.periodEnd
+	case PaymentPeriodGranularity:
+		ends, err := paymentPeriodEnds(l)
+		if err != nil {
+			return nil, err
+		}
+		periodEnd = func(date time.Time) time.Time {
+			i := sort.Search(len(ends), func(i int) bool {
+				return !ends[i].Before(date)
+			})
+			if i == len(ends) {
+				return schedule[len(schedule)-1].Date // Beyond the last payment period
+			}
+			return ends[i]
+		}
+	default:
+		return nil, fmt.Errorf("unsupported schedule granularity: %s", granularity)
+	}
+
+	rolled := make([]AmortizationEntry, 0)
+	var end time.Time
+	for _, day := range schedule {
+		if len(rolled) == 0 || day.Date.After(end) {
+			end = periodEnd(day.Date)
+			rolled = append(rolled, AmortizationEntry{
+				Period:         len(rolled) + 1,
+				OpeningBalance: day.OpeningBalance,
+			})
+		}
+
+		entry := &rolled[len(rolled)-1]
+		entry.Date = day.Date
+		entry.Payment += day.Payment
+		entry.ResidualValue += day.ResidualValue
+		entry.InterestExpense += day.InterestExpense
+		entry.Depreciation += day.Depreciation
+		entry.Expense += day.Expense
+		entry.Income += day.Income
+		entry.PrincipalRepayment += day.PrincipalRepayment
+		entry.Remeasurement += day.Remeasurement
+		entry.Impairment += day.Impairment
+		entry.UnitsUsed += day.UnitsUsed
+		entry.ClosingBalance = day.ClosingBalance
+	}
+
+	return rolled, nil
+}
+
+// paymentPeriodEnds returns the last day of each payment period of a lease, in order. The
+// final period ends on the end date.
+func paymentPeriodEnds(l lease.Lease) ([]time.Time, error) {
+	cycle, err := paymentCycleFor(l)
+	if err != nil {
+		return nil, err
+	}
+
+	var ends []time.Time
+	if cycle.exact {
+		for _, scheduled := range l.PaymentSchedule {
+			if scheduled.Date.After(l.StartDate) && scheduled.Date.Before(l.EndDate) {
+				ends = append(ends, scheduled.Date)
+			}
+		}
+		sort.Slice(ends, func(i, j int) bool { return ends[i].Before(ends[j]) })
+	} else {
+		for k := 1; cycle.date(l.StartDate, k).Before(l.EndDate); k++ {
+			end := cycle.date(l.StartDate, k)
+			if l.PaysInAdvance() {
+				end = end.AddDate(0, 0, -1)
+			}
+			ends = append(ends, end)
+		}
+	}
+
+	return append(ends, l.EndDate), nil
+}
@@ -0,0 +1,174 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"testing"
+)
+
+func TestRollUpSchedule(t *testing.T) {
+	l := testLease("2024-01-15", "2026-01-14")
+	l.PaymentAmount = 3000 * money.Unit
+	l.PaymentFrequency = lease.Quarterly
+	l.DiscountRate = 0.06
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	daily, err := GenerateLiabilitySchedule(l, liability)
+	if err != nil {
+		t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+	}
+	rou, err := GenerateRoUAssetSchedule(l, liability)
+	if err != nil {
+		t.Fatalf("GenerateRoUAssetSchedule() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		granularity Granularity
+		fiscal      FiscalCalendar
+		entries     int
+		firstDate   string
+		lastDate    string
+	}{
+		{"Daily", DailyGranularity, FiscalCalendar{}, len(daily), "2024-01-15", "2026-01-14"},
+		{"Monthly", MonthlyGranularity, FiscalCalendar{}, 25, "2024-01-31", "2026-01-14"},
+		{"Payment period", PaymentPeriodGranularity, FiscalCalendar{}, 8, "2024-04-15", "2026-01-14"},
+		{"Calendar years", FiscalPeriodGranularity, FiscalCalendar{}, 3, "2024-12-31", "2026-01-14"},
+		{"Fiscal quarters from April", FiscalPeriodGranularity,
+			FiscalCalendar{Start: mustParseDate(testDateLayout, "2025-04-01"), Months: 3}, 9, "2024-03-31", "2026-01-14"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, schedule := range [][]AmortizationEntry{daily, rou} {
+				rolled, err := RollUpSchedule(l, schedule, tt.granularity, tt.fiscal)
+				if err != nil {
+					t.Fatalf("RollUpSchedule() error = %v", err)
+				}
+				if len(rolled) != tt.entries {
+					t.Fatalf("RollUpSchedule() returned %d entries, want %d", len(rolled), tt.entries)
+				}
+				if got := rolled[0].Date.Format(testDateLayout); got != tt.firstDate {
+					t.Errorf("First entry dated %s, want %s", got, tt.firstDate)
+				}
+				if got := rolled[len(rolled)-1].Date.Format(testDateLayout); got != tt.lastDate {
+					t.Errorf("Last entry dated %s, want %s", got, tt.lastDate)
+				}
+
+				// Every entry reconciles exactly and continues from the previous one; rolled-up
+				// entries are numbered in order, daily entries keep the engine's numbering
+				rolledUp := tt.granularity != DailyGranularity
+				var totalPayments, dailyPayments money.Amount
+				for i, entry := range rolled {
+					movement := entry.Remeasurement + entry.InterestExpense + entry.Expense + entry.Income - entry.Payment - entry.Impairment - entry.Depreciation
+					if entry.OpeningBalance+movement != entry.ClosingBalance {
+						t.Errorf("Entry %d does not reconcile: %+v", i+1, entry)
+					}
+					if i > 0 && entry.OpeningBalance != rolled[i-1].ClosingBalance {
+						t.Errorf("Entry %d opens at %s, previous closed at %s", i+1, entry.OpeningBalance, rolled[i-1].ClosingBalance)
+					}
+					if rolledUp && entry.Period != i+1 {
+						t.Errorf("Entry %d numbered %d", i+1, entry.Period)
+					}
+					totalPayments += entry.Payment
+				}
+				for _, entry := range schedule {
+					dailyPayments += entry.Payment
+				}
+				if totalPayments != dailyPayments {
+					t.Errorf("Rolled-up payments = %s, want %s", totalPayments, dailyPayments)
+				}
+			}
+		})
+	}
+}
+
+func TestRollUpSchedulePaymentPeriodsInAdvance(t *testing.T) {
+	l := testLease("2024-01-15", "2026-01-14")
+	l.PaymentAmount = 3000 * money.Unit
+	l.PaymentFrequency = lease.Quarterly
+	l.DiscountRate = 0.06
+	l.PaymentTiming = lease.Advance
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	daily, err := GenerateLiabilitySchedule(l, liability)
+	if err != nil {
+		t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+	}
+
+	rolled, err := RollUpSchedule(l, daily, PaymentPeriodGranularity, FiscalCalendar{})
+	if err != nil {
+		t.Fatalf("RollUpSchedule() error = %v", err)
+	}
+
+	// Each period after the first opens with the payment made at its start
+	if len(rolled) != 8 {
+		t.Fatalf("RollUpSchedule() returned %d entries, want 8", len(rolled))
+	}
+	if rolled[0].Payment != 0 || !rolled[0].Date.Equal(mustParseDate(testDateLayout, "2024-04-14")) {
+		t.Errorf("First period = %+v, want no payment up to 2024-04-14", rolled[0])
+	}
+	for _, entry := range rolled[1:] {
+		if entry.Payment != 3000*money.Unit {
+			t.Errorf("Period %d payment = %s, want 3000.00", entry.Period, entry.Payment)
+		}
+	}
+}
+
+func TestRollUpScheduleKeepsRemeasurements(t *testing.T) {
+	l := testLease("2024-01-01", "2026-01-01")
+	effective := mustParseDate(testDateLayout, "2025-01-02")
+	l.Modifications = []lease.Modification{{EffectiveDate: effective, NewPaymentAmount: 1200 * money.Unit, RevisedDiscountRate: 0.06}}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	result, err := ApplyModifications(l, liability, liability)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+
+	rolled, err := RollUpSchedule(result.Lease, result.LiabilitySchedule, MonthlyGranularity, FiscalCalendar{})
+	if err != nil {
+		t.Fatalf("RollUpSchedule() error = %v", err)
+	}
+	january, ok := entryOn(rolled, mustParseDate(testDateLayout, "2025-01-31"))
+	if !ok {
+		t.Fatalf("No entry for January 2025")
+	}
+	r := result.Remeasurements[0]
+	if january.Remeasurement != r.LiabilityAfter-r.LiabilityBefore {
+		t.Errorf("January remeasurement = %s, want %s", january.Remeasurement, r.LiabilityAfter-r.LiabilityBefore)
+	}
+}
+
+func TestParseGranularity(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Granularity
+		wantErr bool
+	}{
+		{input: "", want: MonthlyGranularity},
+		{input: "Daily", want: DailyGranularity},
+		{input: "payment", want: PaymentPeriodGranularity},
+		{input: "Fiscal Period", want: FiscalPeriodGranularity},
+		{input: "hourly", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseGranularity(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGranularity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseGranularity() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
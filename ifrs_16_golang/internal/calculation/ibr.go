@@ -0,0 +1,148 @@
+package calculation
+
+import (
+	"errors"
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RateTenor sets the tenor at which yield curves are read to price a lease.
+type RateTenor string
+
+const (
+	TermTenor     RateTenor = "Term"     // The lease term
+	DurationTenor RateTenor = "Duration" // The weighted-average time to the lease payments, weighted by amount
+)
+
+// IncrementalBorrowingRate builds the incremental borrowing rate of a lessee's lease from
+// yield curves (IFRS 16.26): the risk-free rate of the lease currency at the tenor, plus the
+// credit spread of the entity that holds the lease, plus any adjustment for borrowing secured
+// on an asset of the lease's class. Each is read from the latest curve published on or before
+// the start date, interpolated between tenors. A spread or adjustment given without a currency
+// applies when there is none for the lease currency, and one named "*" when there is none for
+// the entity or asset class. A risk-free curve and a credit spread are required; the security
+// adjustment is optional.
+//
+// The tenor is the lease term or, for leases whose payments are weighted towards one end, the
+// weighted-average time to the lease payments, those at commencement included.
+func IncrementalBorrowingRate(l lease.Lease, curves lease.RateCurves, tenor RateTenor) (lease.RateProvenance, error) {
+	return incrementalBorrowingRateAt(l, curves, tenor, l.StartDate)
+}
+
+// incrementalBorrowingRateAt builds the incremental borrowing rate of a lease on date, such as
+// the date of initial application of IFRS 16, from the curves published on or before it. The
+// tenor runs from date over the rest of the lease term or its remaining payments.
+func incrementalBorrowingRateAt(l lease.Lease, curves lease.RateCurves, tenor RateTenor, date time.Time) (lease.RateProvenance, error) {
+	currency := strings.ToUpper(strings.TrimSpace(l.Currency))
+	if currency == "" {
+		return lease.RateProvenance{}, errors.New("a discount rate built from yield curves needs the lease currency")
+	}
+
+	var months float64
+	switch tenor {
+	case TermTenor, "":
+		tenor = TermTenor
+		months = daysBetween(date, l.EndDate.AddDate(0, 0, 1)) / daysPerYear * 12
+	case DurationTenor:
+		var err error
+		if months, err = paymentDurationMonths(l, date); err != nil {
+			return lease.RateProvenance{}, err
+		}
+	default:
+		return lease.RateProvenance{}, fmt.Errorf("unsupported rate tenor: %s (expected Term or Duration)", tenor)
+	}
+
+	riskFree, ok := curves.CurveAt(lease.RateCurveKey{Component: lease.RiskFreeRate, Currency: currency}, date)
+	if !ok {
+		return lease.RateProvenance{}, fmt.Errorf("no %s risk-free curve published on or before %s", currency, date.Format("2006-01-02"))
+	}
+	spread, ok := curveFor(curves, lease.CreditSpread, l.Entity, currency, date)
+	if !ok {
+		return lease.RateProvenance{}, fmt.Errorf("no %s credit spread for entity '%s' published on or before %s", currency, l.Entity, date.Format("2006-01-02"))
+	}
+	security, _ := curveFor(curves, lease.SecurityAdjustment, l.AssetClass, currency, date)
+
+	p := lease.RateProvenance{
+		CurveDate:          riskFree.Date,
+		Currency:           currency,
+		Entity:             strings.TrimSpace(l.Entity),
+		TenorBasis:         string(tenor),
+		TenorMonths:        math.Round(months*100) / 100,
+		RiskFreeRate:       riskFree.RateAt(months),
+		CreditSpread:       spread.RateAt(months),
+		SecurityAdjustment: security.RateAt(months),
+	}
+	// Rates are quoted to a hundredth of a basis point
+	p.Rate = math.Round((p.RiskFreeRate+p.CreditSpread+p.SecurityAdjustment)*1e6) / 1e6
+	if p.Rate <= 0 {
+		return lease.RateProvenance{}, fmt.Errorf("the discount rate built from yield curves is not positive: %.4f%%", p.Rate*100)
+	}
+	return p, nil
+}
+
+// curveFor returns the latest curve of a component published on or before date for a name
+// (an entity or asset class) and currency, falling back to the curve without a currency and
+// then to the curves named "*". A name given exactly is preferred; otherwise names match
+// regardless of case, the first in sorted order winning, so the same curves always give the
+// same rate.
+func curveFor(curves lease.RateCurves, component lease.RateComponent, name, currency string, date time.Time) (lease.RateCurve, bool) {
+	name = strings.TrimSpace(name)
+	for _, n := range []string{name, "*"} {
+		if n == "" {
+			continue
+		}
+		for _, c := range []string{currency, ""} {
+			if curve, ok := curves.CurveAt(lease.RateCurveKey{Component: component, Name: n, Currency: c}, date); ok {
+				return curve, true
+			}
+			var names []string
+			for key := range curves {
+				if key.Component == component && key.Currency == c && key.Name != n && strings.EqualFold(key.Name, n) {
+					names = append(names, key.Name)
+				}
+			}
+			sort.Strings(names)
+			for _, match := range names {
+				if curve, ok := curves.CurveAt(lease.RateCurveKey{Component: component, Name: match, Currency: c}, date); ok {
+					return curve, true
+				}
+			}
+		}
+	}
+	return lease.RateCurve{}, false
+}
+
+// paymentDurationMonths returns the weighted-average time in months from date to the lease
+// payments falling due on or after it, each weighted by its amount; from commencement, the
+// payments made then count at zero. The payments do not depend on the discount rate, so a
+// lease whose rate is still to be found is given a placeholder one.
+func paymentDurationMonths(l lease.Lease, date time.Time) (float64, error) {
+	if l.DiscountRate <= 0 {
+		l.DiscountRate = 0.01
+	}
+	flows, _, _, err := liabilityCashFlows(l)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	if !date.After(l.StartDate) {
+		total = paymentsAtCommencement(l).Float64()
+	}
+	weighted := 0.0
+	for _, flow := range flows {
+		if flow.date.Before(date) {
+			continue
+		}
+		amount := flow.amount.Float64()
+		total += amount
+		weighted += daysBetween(date, flow.date) / daysPerYear * 12 * amount
+	}
+	if total <= 0 {
+		return 0, errors.New("the weighted-average payment duration needs lease payments")
+	}
+	return weighted / total, nil
+}
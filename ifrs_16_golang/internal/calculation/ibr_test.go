@@ -0,0 +1,124 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"math"
+	"testing"
+)
+
+// testRateCurves returns a US dollar risk-free curve published before 2024 and again after it
+// starts, spreads for Acme Ltd and for any other entity, and a discount for property.
+func testRateCurves() lease.RateCurves {
+	return lease.RateCurves{
+		{Component: lease.RiskFreeRate, Currency: "USD"}: {
+			{Date: mustParseDate(testDateLayout, "2023-12-29"), Points: []lease.CurvePoint{{TenorMonths: 12, Rate: 0.04}, {TenorMonths: 60, Rate: 0.045}}},
+			{Date: mustParseDate(testDateLayout, "2024-02-01"), Points: []lease.CurvePoint{{TenorMonths: 12, Rate: 0.09}}},
+		},
+		{Component: lease.CreditSpread, Name: "ACME LTD", Currency: "USD"}: {
+			{Date: mustParseDate(testDateLayout, "2023-12-29"), Points: []lease.CurvePoint{{Rate: 0.015}}},
+		},
+		{Component: lease.CreditSpread, Name: "*"}: {
+			{Date: mustParseDate(testDateLayout, "2023-12-29"), Points: []lease.CurvePoint{{Rate: 0.02}}},
+		},
+		{Component: lease.SecurityAdjustment, Name: "Property"}: {
+			{Date: mustParseDate(testDateLayout, "2023-12-29"), Points: []lease.CurvePoint{{Rate: -0.005}}},
+		},
+	}
+}
+
+func TestIncrementalBorrowingRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		start      string
+		currency   string
+		entity     string
+		tenor      RateTenor
+		wantRate   float64
+		wantSpread float64
+		wantErr    bool
+	}{
+		// Five years, read flat beyond the last tenor of the curve published before the start date
+		{"Term of five years", "2024-01-01", "usd", "Acme Ltd", TermTenor, 0.055, 0.015, false},
+		{"Spread given for any entity", "2024-01-01", "usd", "Other plc", TermTenor, 0.06, 0.02, false},
+		{"No risk-free curve for the currency", "2024-01-01", "EUR", "Acme Ltd", TermTenor, 0, 0, true},
+		{"No curve published by the start date", "2023-06-01", "usd", "Acme Ltd", TermTenor, 0, 0, true},
+		{"Unsupported tenor", "2024-01-01", "usd", "Acme Ltd", RateTenor("Swap"), 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease(tt.start, "2028-12-31")
+			l.DiscountRate = 0
+			l.Currency = tt.currency
+			l.Entity = tt.entity
+			l.AssetClass = "Property"
+			got, err := IncrementalBorrowingRate(l, testRateCurves(), tt.tenor)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IncrementalBorrowingRate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Rate != tt.wantRate || got.RiskFreeRate != 0.045 || got.CreditSpread != tt.wantSpread || got.SecurityAdjustment != -0.005 ||
+				got.Currency != "USD" || !got.CurveDate.Equal(mustParseDate(testDateLayout, "2023-12-29")) {
+				t.Errorf("IncrementalBorrowingRate() = %+v, want 4.5%% + %v%% - 0.5%% = %v", got, tt.wantSpread*100, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestIncrementalBorrowingRateDuration(t *testing.T) {
+	l := testLease("2024-01-01", "2028-12-31")
+	l.Currency = "USD"
+	l.Entity = "Acme Ltd"
+	l.AssetClass = "Property"
+	curves := testRateCurves()
+
+	term, err := IncrementalBorrowingRate(l, curves, TermTenor)
+	if err != nil {
+		t.Fatalf("IncrementalBorrowingRate() error = %v", err)
+	}
+	// Level monthly payments in arrears average about two and a half years
+	duration, err := IncrementalBorrowingRate(l, curves, DurationTenor)
+	if err != nil {
+		t.Fatalf("IncrementalBorrowingRate() error = %v", err)
+	}
+	wantRiskFree := 0.04 + 0.005*(duration.TenorMonths-12)/48
+	if duration.TenorMonths < 30 || duration.TenorMonths > 31 || math.Abs(duration.RiskFreeRate-wantRiskFree) > 1e-4 || duration.Rate >= term.Rate {
+		t.Errorf("Duration rate = %+v, want the curve interpolated at about 30.5 months", duration)
+	}
+}
+
+func TestIncrementalBorrowingRateSpreadNames(t *testing.T) {
+	curves := testRateCurves()
+	curves[lease.RateCurveKey{Component: lease.CreditSpread, Name: "Acme Ltd", Currency: "USD"}] = []lease.RateCurve{
+		{Date: mustParseDate(testDateLayout, "2023-12-29"), Points: []lease.CurvePoint{{Rate: 0.012}}},
+	}
+	curves[lease.RateCurveKey{Component: lease.CreditSpread, Name: "acme ltd", Currency: "USD"}] = []lease.RateCurve{
+		{Date: mustParseDate(testDateLayout, "2023-12-29"), Points: []lease.CurvePoint{{Rate: 0.018}}},
+	}
+
+	// Spreads named in different cases give the same rate on every run, the exact name first
+	tests := []struct {
+		name       string
+		entity     string
+		wantSpread float64
+	}{
+		{"Spread named exactly", "Acme Ltd", 0.012},
+		{"First spread in name order", "ACME ltd", 0.015},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease("2024-01-01", "2028-12-31")
+			l.Currency = "USD"
+			l.Entity = tt.entity
+			for i := 0; i < 20; i++ {
+				got, err := IncrementalBorrowingRate(l, curves, TermTenor)
+				if err != nil || got.CreditSpread != tt.wantSpread {
+					t.Fatalf("IncrementalBorrowingRate() = %+v, %v, want a spread of %v", got, err, tt.wantSpread)
+				}
+			}
+		})
+	}
+}
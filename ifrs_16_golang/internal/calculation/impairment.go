@@ -0,0 +1,99 @@
+package calculation
+
+import (
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"sort"
+	"time"
+)
+
+// ImpairmentAdjustment records an impairment loss or reversal booked against the RoU asset at
+// the start of its date (IAS 36).
+type ImpairmentAdjustment struct {
+	Date                 time.Time     `json:"date"`
+	Description          string        `json:"description,omitempty"`
+	CarryingAmountBefore money.Amount  `json:"carryingAmountBefore"`
+	RecoverableAmount    *money.Amount `json:"recoverableAmount,omitempty"` // Set when the test measured the recoverable amount
+	Loss                 money.Amount  `json:"loss"`                        // Impairment loss (positive) or reversal (negative) recognised in profit or loss
+	CarryingAmountAfter  money.Amount  `json:"carryingAmountAfter"`
+}
+
+// impair writes the RoU asset down to its recoverable amount, or by the given impairment
+// loss, at the start of the impairment date and depreciates the reduced carrying amount over
+// the rest of the depreciation period with the lease's depreciation method (IAS 36.63).
+//
+// A negative loss, or a recoverable amount above the carrying amount, reverses earlier
+// impairment losses. The carrying amount after a reversal may not exceed the carrying amount
+// the asset would have had without any impairment (IAS 36.117), so a reversal is limited to
+// that amount and a recoverable amount above it has no effect.
+func (s *ModifiedSchedules) impair(i lease.Impairment) error {
+	if i.Date.Before(s.Lease.StartDate) {
+		return fmt.Errorf("impairment date is before the start date %s", s.Lease.StartDate.Format("2006-01-02"))
+	}
+	if i.RecoverableAmount != nil && i.Loss != 0 {
+		return fmt.Errorf("give either a recoverable amount or an impairment loss, not both")
+	}
+	if i.RecoverableAmount != nil && *i.RecoverableAmount < 0 {
+		return fmt.Errorf("recoverable amount cannot be negative: %s", *i.RecoverableAmount)
+	}
+
+	index := sort.Search(len(s.RoUAssetSchedule), func(k int) bool {
+		return !s.RoUAssetSchedule[k].Date.Before(i.Date)
+	})
+	if index == len(s.RoUAssetSchedule) {
+		return fmt.Errorf("the RoU asset is fully depreciated by then")
+	}
+	replaced := s.RoUAssetSchedule[index]
+	before := carryingAmountOn(replaced)
+
+	loss := i.Loss
+	if i.RecoverableAmount != nil {
+		loss = before - *i.RecoverableAmount
+	}
+	if loss > before {
+		return fmt.Errorf("impairment loss %s exceeds the carrying amount %s", loss, before)
+	}
+	if loss < 0 {
+		ceiling := money.Amount(0)
+		k := sort.Search(len(s.unimpaired), func(k int) bool {
+			return !s.unimpaired[k].Date.Before(i.Date)
+		})
+		if k < len(s.unimpaired) {
+			ceiling = carryingAmountOn(s.unimpaired[k])
+		}
+		loss = max(loss, min(before-ceiling, 0))
+	}
+	after := before - loss
+
+	rest, err := rouScheduleFrom(s.Lease, after, i.Date, replaced.Period)
+	if err != nil {
+		return err
+	}
+
+	// The first continued entry keeps what was already booked at the start of the day and
+	// shows the impairment separately.
+	if len(rest) > 0 {
+		rest[0].OpeningBalance = replaced.OpeningBalance
+		rest[0].Remeasurement = replaced.Remeasurement
+		rest[0].Impairment = replaced.Impairment + loss
+	}
+
+	s.RoUAssetSchedule = append(s.RoUAssetSchedule[:index:index], rest...)
+	s.Impairments = append(s.Impairments, ImpairmentAdjustment{
+		Date:                 i.Date,
+		Description:          i.Description,
+		CarryingAmountBefore: before,
+		RecoverableAmount:    i.RecoverableAmount,
+		Loss:                 loss,
+		CarryingAmountAfter:  after,
+	})
+
+	return nil
+}
+
+// carryingAmountOn returns the carrying amount of an asset after the adjustments booked at the
+// start of an entry's day and before its depreciation.
+func carryingAmountOn(entry AmortizationEntry) money.Amount {
+	return entry.OpeningBalance + entry.Remeasurement - entry.Impairment
+}
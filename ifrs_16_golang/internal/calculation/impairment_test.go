@@ -0,0 +1,167 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"testing"
+)
+
+func TestImpairmentWritesDownToRecoverableAmount(t *testing.T) {
+	l := testLease("2024-01-01", "2026-12-31")
+	impairedOn := mustParseDate(testDateLayout, "2025-01-01")
+	recoverable := 20000 * money.Unit
+	l.Impairments = []lease.Impairment{{Date: impairedOn, RecoverableAmount: &recoverable}}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	original, _ := GenerateRoUAssetSchedule(l, liability)
+	result, err := ApplyModifications(l, liability, liability)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+
+	if len(result.Impairments) != 1 {
+		t.Fatalf("Expected 1 impairment, got %d", len(result.Impairments))
+	}
+	impairment := result.Impairments[0]
+	before, _ := entryOn(original, impairedOn)
+	if impairment.CarryingAmountBefore != before.OpeningBalance || impairment.CarryingAmountAfter != recoverable ||
+		impairment.Loss != before.OpeningBalance-recoverable {
+		t.Errorf("Impairment = %+v, want a write-down from %s to %s", impairment, before.OpeningBalance, recoverable)
+	}
+
+	// The loss is booked at the start of the day and the rest depreciated over the 730 days left
+	entry, _ := entryOn(result.RoUAssetSchedule, impairedOn)
+	if entry.Impairment != impairment.Loss || entry.OpeningBalance != before.OpeningBalance {
+		t.Errorf("Entry on the impairment date = %+v, want opening %s and impairment %s", entry, before.OpeningBalance, impairment.Loss)
+	}
+	yearly := yearlyDepreciation(result.RoUAssetSchedule)
+	if want := recoverable.Share(365, 730); yearly[2025] != want {
+		t.Errorf("2025 depreciation = %s, want %s", yearly[2025], want)
+	}
+
+	for i, entry := range result.RoUAssetSchedule {
+		if entry.OpeningBalance+entry.Remeasurement-entry.Impairment-entry.Depreciation != entry.ClosingBalance {
+			t.Fatalf("Entry %d does not reconcile: %+v", i+1, entry)
+		}
+	}
+	if last := result.RoUAssetSchedule[len(result.RoUAssetSchedule)-1]; last.ClosingBalance != 0 {
+		t.Errorf("Last entry ClosingBalance = %s, want 0.00", last.ClosingBalance)
+	}
+
+	// The liability is not affected
+	if len(result.LiabilitySchedule) != len(original) || result.LiabilitySchedule[len(original)-1].ClosingBalance != 0 {
+		t.Errorf("Liability schedule changed by an impairment")
+	}
+}
+
+func TestImpairmentReversalIsLimited(t *testing.T) {
+	l := testLease("2024-01-01", "2026-12-31")
+	impairedOn := mustParseDate(testDateLayout, "2025-01-01")
+	reversedOn := mustParseDate(testDateLayout, "2026-01-01")
+	l.Impairments = []lease.Impairment{
+		{Date: reversedOn, Loss: -100000 * money.Unit, Description: "Reversal"},
+		{Date: impairedOn, Loss: 10000 * money.Unit},
+	}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	original, _ := GenerateRoUAssetSchedule(l, liability)
+	result, err := ApplyModifications(l, liability, liability)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+	if len(result.Impairments) != 2 || result.Impairments[0].Date != impairedOn {
+		t.Fatalf("Impairments = %+v, want the loss and then the reversal", result.Impairments)
+	}
+
+	// The reversal restores the carrying amount the asset would have had without the loss
+	unimpaired, _ := entryOn(original, reversedOn)
+	reversal := result.Impairments[1]
+	if reversal.CarryingAmountAfter != unimpaired.OpeningBalance {
+		t.Errorf("Carrying amount after reversal = %s, want %s", reversal.CarryingAmountAfter, unimpaired.OpeningBalance)
+	}
+	if reversal.Loss >= 0 || reversal.Loss <= -10000*money.Unit {
+		t.Errorf("Reversal = %s, want less than the 10000.00 loss once depreciation is taken into account", reversal.Loss)
+	}
+
+	// Period summaries show the accumulated impairment separately from depreciation
+	calcResult := CalculationResult{RoUAssetSchedule: result.RoUAssetSchedule}
+	if err := CalculateAccountingPeriodSummary(&calcResult, "2025-01-01", "2025-12-31"); err != nil {
+		t.Fatalf("CalculateAccountingPeriodSummary() error = %v", err)
+	}
+	if calcResult.PeriodImpairment != 10000*money.Unit || calcResult.PeriodImpairmentStart != 0 ||
+		calcResult.PeriodImpairmentEnd != 10000*money.Unit {
+		t.Errorf("2025 impairment = %s (%s to %s), want 10000.00 (0.00 to 10000.00)", calcResult.PeriodImpairment,
+			calcResult.PeriodImpairmentStart, calcResult.PeriodImpairmentEnd)
+	}
+	if err := CalculateAccountingPeriodSummary(&calcResult, "2026-01-01", "2026-12-31"); err != nil {
+		t.Fatalf("CalculateAccountingPeriodSummary() error = %v", err)
+	}
+	if calcResult.PeriodImpairment != reversal.Loss || calcResult.PeriodImpairmentStart != 10000*money.Unit ||
+		calcResult.PeriodImpairmentEnd != 10000*money.Unit+reversal.Loss {
+		t.Errorf("2026 impairment = %s (%s to %s), want the reversal %s", calcResult.PeriodImpairment,
+			calcResult.PeriodImpairmentStart, calcResult.PeriodImpairmentEnd, reversal.Loss)
+	}
+	movement := calcResult.PeriodRoUAssetRemeasurement - calcResult.PeriodImpairment - calcResult.PeriodDepreciation
+	if calcResult.PeriodRoUAssetStart+movement != calcResult.PeriodRoUAssetEnd {
+		t.Errorf("2026 RoU asset movement does not reconcile: %+v", calcResult)
+	}
+}
+
+func TestImpairmentThenModification(t *testing.T) {
+	l := testLease("2024-01-01", "2026-12-31")
+	impairedOn := mustParseDate(testDateLayout, "2025-01-01")
+	modifiedOn := mustParseDate(testDateLayout, "2025-07-01")
+	l.Impairments = []lease.Impairment{{Date: impairedOn, Loss: 5000 * money.Unit}}
+	l.Modifications = []lease.Modification{{EffectiveDate: modifiedOn, NewPaymentAmount: 1250 * money.Unit, RevisedDiscountRate: 0.05}}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	result, err := ApplyModifications(l, liability, liability)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+
+	// The modification adjusts the impaired carrying amount
+	beforeModification, _ := entryOn(result.RoUAssetSchedule, modifiedOn.AddDate(0, 0, -1))
+	r := result.Remeasurements[0]
+	if r.RoUAssetBefore != beforeModification.ClosingBalance {
+		t.Errorf("RoUAssetBefore = %s, want the impaired carrying amount %s", r.RoUAssetBefore, beforeModification.ClosingBalance)
+	}
+	if entry, _ := entryOn(result.RoUAssetSchedule, impairedOn); entry.Impairment != 5000*money.Unit {
+		t.Errorf("Impairment on %s = %s, want 5000.00", impairedOn.Format(testDateLayout), entry.Impairment)
+	}
+}
+
+func TestImpairmentErrors(t *testing.T) {
+	recoverable := 1000 * money.Unit
+	negative := -1 * money.Unit
+	tests := []struct {
+		name       string
+		impairment lease.Impairment
+	}{
+		{"Loss above the carrying amount", lease.Impairment{Date: mustParseDate(testDateLayout, "2026-06-01"), Loss: 100000 * money.Unit}},
+		{"Both loss and recoverable amount", lease.Impairment{Date: mustParseDate(testDateLayout, "2025-01-01"), Loss: 100 * money.Unit, RecoverableAmount: &recoverable}},
+		{"Negative recoverable amount", lease.Impairment{Date: mustParseDate(testDateLayout, "2025-01-01"), RecoverableAmount: &negative}},
+		{"Before commencement", lease.Impairment{Date: mustParseDate(testDateLayout, "2023-12-31"), Loss: 100 * money.Unit}},
+		{"After the asset is fully depreciated", lease.Impairment{Date: mustParseDate(testDateLayout, "2027-01-01"), Loss: 100 * money.Unit}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease("2024-01-01", "2026-12-31")
+			l.Impairments = []lease.Impairment{tt.impairment}
+			liability, _ := CalculateLeaseLiability(l)
+			if _, err := ApplyModifications(l, liability, liability); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
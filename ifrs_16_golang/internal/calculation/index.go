@@ -0,0 +1,72 @@
+package calculation
+
+import (
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"math"
+)
+
+// IndexReviews derives the payment changes of an index-linked lease from published index
+// values. Reviews fall every ReviewMonths from the start date until the end of the term. At
+// each review the payment moves by the change in the index since the value used at the
+// previous review (the base value at first), limited by the link's floor and cap, using the
+// latest value published on or before the review date.
+//
+// Reviews without a newly published value, or that leave the payment unchanged, are
+// skipped. The returned modifications keep the discount rate unchanged (IFRS 16.43).
+func IndexReviews(l lease.Lease, table lease.IndexTable) ([]lease.Modification, error) {
+	link := l.IndexLink
+	if link == nil {
+		return nil, nil
+	}
+	if link.BaseValue <= 0 {
+		return nil, fmt.Errorf("index base value must be positive: %.4f", link.BaseValue)
+	}
+	if link.ReviewMonths <= 0 {
+		return nil, fmt.Errorf("index review frequency must be at least one month: %d", link.ReviewMonths)
+	}
+	if link.Floor != nil && link.Cap != nil && *link.Floor > *link.Cap {
+		return nil, fmt.Errorf("index floor %.4f is above the cap %.4f", *link.Floor, *link.Cap)
+	}
+
+	var reviews []lease.Modification
+	payment := l.PaymentAmount
+	previous := lease.IndexValue{Value: link.BaseValue}
+
+	for k := 1; ; k++ {
+		reviewDate := l.StartDate.AddDate(0, k*link.ReviewMonths, 0)
+		if !reviewDate.Before(l.EndDate) {
+			break
+		}
+
+		current, ok := table.ValueAt(link.IndexName, reviewDate)
+		if !ok || (!previous.Date.IsZero() && !current.Date.After(previous.Date)) {
+			continue
+		}
+
+		change := current.Value/previous.Value - 1
+		if link.Floor != nil {
+			change = math.Max(change, *link.Floor)
+		}
+		if link.Cap != nil {
+			change = math.Min(change, *link.Cap)
+		}
+		previous = current
+
+		revised := payment.Mul(1 + change)
+		if revised == payment {
+			continue
+		}
+		payment = revised
+
+		reviews = append(reviews, lease.Modification{
+			Type:             lease.IndexReview,
+			EffectiveDate:    reviewDate,
+			NewPaymentAmount: payment,
+			Description: fmt.Sprintf("%s %s: %.4g (%+.2f%%)",
+				link.IndexName, current.Date.Format("2006-01-02"), current.Value, change*100),
+		})
+	}
+
+	return reviews, nil
+}
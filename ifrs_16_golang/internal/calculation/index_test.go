@@ -0,0 +1,128 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"math"
+	"testing"
+)
+
+// cpiLink links payments to a CPI based at 100, reviewed every 12 months.
+func cpiLink(floor, cap *float64) *lease.IndexLink {
+	return &lease.IndexLink{IndexName: "CPI", BaseValue: 100, ReviewMonths: 12, Floor: floor, Cap: cap}
+}
+
+func TestIndexReviews(t *testing.T) {
+	zero, fivePercent := 0.0, 0.05
+	cpi := lease.IndexTable{"CPI": {
+		{Date: mustParseDate(testDateLayout, "2023-12-01"), Value: 100},
+		{Date: mustParseDate(testDateLayout, "2024-12-01"), Value: 104},
+		{Date: mustParseDate(testDateLayout, "2025-12-01"), Value: 110},
+	}}
+	deflation := lease.IndexTable{"CPI": {
+		{Date: mustParseDate(testDateLayout, "2024-12-01"), Value: 98},
+		{Date: mustParseDate(testDateLayout, "2025-12-01"), Value: 101},
+	}}
+	stale := lease.IndexTable{"CPI": {
+		{Date: mustParseDate(testDateLayout, "2024-12-01"), Value: 104},
+	}}
+
+	tests := []struct {
+		name     string
+		link     *lease.IndexLink
+		table    lease.IndexTable
+		payments []float64 // Revised payment at each review that changes it
+	}{
+		{"Uncapped", cpiLink(nil, nil), cpi, []float64{1040, 1100}},
+		{"Capped at 5% per review", cpiLink(nil, &fivePercent), cpi, []float64{1040, 1092}},
+		{"Upward only", cpiLink(&zero, nil), deflation, []float64{1030.61}},
+		{"No new value published", cpiLink(nil, nil), stale, []float64{1040}},
+		{"No index values", cpiLink(nil, nil), lease.IndexTable{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease("2024-01-01", "2027-01-01")
+			l.IndexLink = tt.link
+			reviews, err := IndexReviews(l, tt.table)
+			if err != nil {
+				t.Fatalf("IndexReviews() error = %v", err)
+			}
+			if len(reviews) != len(tt.payments) {
+				t.Fatalf("Expected %d reviews, got %d: %+v", len(tt.payments), len(reviews), reviews)
+			}
+			for i, review := range reviews {
+				if review.Type != lease.IndexReview {
+					t.Errorf("Review %d type = %s, want %s", i, review.Type, lease.IndexReview)
+				}
+				if review.NewPaymentAmount != money.FromFloat(tt.payments[i]) {
+					t.Errorf("Review %d payment = %s, want %.2f", i, review.NewPaymentAmount, tt.payments[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIndexReviewsRemeasureAtUnchangedRate(t *testing.T) {
+	l := testLease("2024-01-01", "2027-01-01")
+	l.IndexLink = cpiLink(nil, nil)
+	reviews, err := IndexReviews(l, lease.IndexTable{"CPI": {
+		{Date: mustParseDate(testDateLayout, "2024-12-01"), Value: 104},
+	}})
+	if err != nil {
+		t.Fatalf("IndexReviews() error = %v", err)
+	}
+	l.Modifications = reviews
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	result, err := ApplyModifications(l, liability, liability)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+
+	if len(result.Remeasurements) != 1 || result.Remeasurements[0].Reason != string(lease.IndexReview) {
+		t.Fatalf("Expected one index review remeasurement, got %+v", result.Remeasurements)
+	}
+	if result.Lease.DiscountRate != l.DiscountRate {
+		t.Errorf("Discount rate changed to %.4f", result.Lease.DiscountRate)
+	}
+
+	// Payments from 1 January 2025 (positions 12..36) at 1040, discounted to the day before
+	r := result.Remeasurements[0]
+	rate := 0.05 / 12
+	base := 11 + 30.0/31
+	expected := 0.0
+	for position := 12; position <= 36; position++ {
+		expected += 1040 * math.Pow(1+rate, -(float64(position)-base))
+	}
+	if r.LiabilityAfter != money.FromFloat(expected) {
+		t.Errorf("LiabilityAfter = %s, want %.2f", r.LiabilityAfter, expected)
+	}
+}
+
+func TestIndexReviewsInvalidLink(t *testing.T) {
+	floor, cap := 0.05, 0.02
+	noBase := cpiLink(nil, nil)
+	noBase.BaseValue = 0
+
+	tests := []struct {
+		name string
+		link *lease.IndexLink
+	}{
+		{"Zero base value", noBase},
+		{"Floor above the cap", cpiLink(&floor, &cap)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease("2024-01-01", "2027-01-01")
+			l.IndexLink = tt.link
+			if _, err := IndexReviews(l, lease.IndexTable{}); err == nil {
+				t.Errorf("IndexReviews() expected an error")
+			}
+		})
+	}
+}
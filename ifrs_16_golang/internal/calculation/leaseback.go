@@ -0,0 +1,79 @@
+package calculation
+
+import (
+	"errors"
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"time"
+)
+
+// SaleAndLeaseback measures the sale of an asset the entity leases back, as seller-lessee, at
+// the start of the leaseback (IFRS 16.98-103). The transfer is taken to be a sale under
+// IFRS 15; a transfer that is not remains the seller's asset, the proceeds a financial
+// liability (IFRS 16.103), and is not a leaseback here.
+type SaleAndLeaseback struct {
+	Date                time.Time    `json:"date"`
+	SalePrice           money.Amount `json:"salePrice"`
+	FairValue           money.Amount `json:"fairValue"`                     // Fair value of the asset sold
+	CarryingAmount      money.Amount `json:"carryingAmount"`                // Carrying amount of the asset sold
+	Prepayment          money.Amount `json:"prepayment,omitempty"`          // Shortfall of the sale price below fair value, a prepayment of lease payments (IFRS 16.101(a))
+	AdditionalFinancing money.Amount `json:"additionalFinancing,omitempty"` // Excess of the sale price over fair value, financing from the buyer-lessor (IFRS 16.101(b))
+	LeasePayments       money.Amount `json:"leasePayments"`                 // Present value of the leaseback payments, those at commencement included
+	RightsRetained      money.Amount `json:"rightsRetained"`                // Lease payments at market terms: the fair value of the right of use retained
+	RoUAsset            money.Amount `json:"rouAsset"`                      // Part of the carrying amount that relates to the rights retained (IFRS 16.100(a))
+	GainLoss            money.Amount `json:"gainLoss"`                      // Gain (positive) or loss (negative) on the rights transferred to the buyer-lessor
+}
+
+// MeasureSaleAndLeaseback measures the sale of the asset a leaseback leases back, given the
+// initial lease liability of the leaseback, and returns the components of its RoU asset.
+//
+// A sale price below the asset's fair value is a prepayment of lease payments and one above
+// it additional financing from the buyer-lessor (IFRS 16.101), so the rights retained are
+// worth the lease payments plus the prepayment, or less the financing. The liability,
+// financing included, is repaid by the leaseback payments in the usual liability schedule.
+// The RoU asset is the proportion of the asset's carrying amount that the rights retained
+// bear to its fair value, plus the lessee's initial direct costs and restoration costs, and
+// only the gain or loss on the rights transferred is recognised (IFRS 16.100(a)): the sale
+// price and the RoU asset less the carrying amount and the lease payments.
+func MeasureSaleAndLeaseback(l lease.Lease, leaseLiability money.Amount) (*SaleAndLeaseback, RoUAssetComponents, error) {
+	if l.FairValue <= 0 {
+		return nil, RoUAssetComponents{}, errors.New("a sale and leaseback needs the fair value of the asset sold")
+	}
+	if l.CarryingAmount < 0 {
+		return nil, RoUAssetComponents{}, fmt.Errorf("carrying amount cannot be negative: %s", l.CarryingAmount)
+	}
+	components, err := CalculateInitialRoUAssetComponents(leaseLiability, l)
+	if err != nil {
+		return nil, RoUAssetComponents{}, err
+	}
+
+	s := SaleAndLeaseback{
+		Date:           l.StartDate,
+		SalePrice:      l.SalePrice,
+		FairValue:      l.FairValue,
+		CarryingAmount: l.CarryingAmount,
+		LeasePayments:  leaseLiability + paymentsAtCommencement(l),
+	}
+	if l.SalePrice < l.FairValue {
+		s.Prepayment = l.FairValue - l.SalePrice
+	} else {
+		s.AdditionalFinancing = l.SalePrice - l.FairValue
+	}
+	s.RightsRetained = s.LeasePayments + s.Prepayment - s.AdditionalFinancing
+	if s.RightsRetained < 0 {
+		return nil, RoUAssetComponents{}, fmt.Errorf("the additional financing of %s exceeds the leaseback payments of %s", s.AdditionalFinancing, s.LeasePayments)
+	}
+	if s.RightsRetained > s.FairValue {
+		return nil, RoUAssetComponents{}, fmt.Errorf("the rights retained of %s exceed the fair value of the asset of %s", s.RightsRetained, s.FairValue)
+	}
+	s.RoUAsset = s.CarryingAmount.Mul(s.RightsRetained.Float64() / s.FairValue.Float64())
+	s.GainLoss = s.SalePrice + s.RoUAsset - s.CarryingAmount - s.LeasePayments
+
+	components.LeaseLiability = 0
+	components.PaymentsAtCommencement = 0
+	components.LeaseIncentives = 0
+	components.RetainedCarryingAmount = s.RoUAsset
+	components.Total = components.RetainedCarryingAmount + components.InitialDirectCosts + components.RestorationCosts
+	return &s, components, nil
+}
@@ -0,0 +1,70 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"testing"
+)
+
+func TestMeasureSaleAndLeaseback(t *testing.T) {
+	// IFRS 16 Illustrative Example 24: a building carried at 1,000,000 with a fair value of
+	// 1,800,000 is sold and leased back for 18 years at 120,000 a year, paid in arrears, at 4.5%
+	l := testLease("2024-01-01", "2041-12-31")
+	l.PaymentAmount = 120000 * money.Unit
+	l.PaymentFrequency = lease.Annually
+	l.DiscountRate = 0.045
+	l.CarryingAmount = 1000000 * money.Unit
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	if (liability - 1459200*money.Unit).Abs() > money.Unit {
+		t.Fatalf("Liability = %s, want about 1459200, the financing included", liability)
+	}
+
+	tests := []struct {
+		name           string
+		salePrice      money.Amount
+		fairValue      money.Amount
+		wantFinancing  money.Amount
+		wantPrepayment money.Amount
+		wantRoUAsset   money.Amount
+		wantGainLoss   money.Amount
+		wantErr        bool
+	}{
+		// The example rounds to 1,259,200 retained, 699,555 of RoU asset and a 240,355 gain
+		{"Above fair value, as in the example", 2000000 * money.Unit, 1800000 * money.Unit, 200000 * money.Unit, 0, 699555 * money.Unit, 240355 * money.Unit, false},
+		// A price below fair value prepays lease payments, which the rights retained include
+		{"Below fair value", 1700000 * money.Unit, 1800000 * money.Unit, 0, 100000 * money.Unit, 866222 * money.Unit, 107022 * money.Unit, false},
+		{"No fair value of the asset", 2000000 * money.Unit, 0, 0, 0, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := l
+			l.SalePrice = tt.salePrice
+			l.FairValue = tt.fairValue
+			sale, components, err := MeasureSaleAndLeaseback(l, liability)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MeasureSaleAndLeaseback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if sale.AdditionalFinancing != tt.wantFinancing || sale.Prepayment != tt.wantPrepayment ||
+				sale.RightsRetained != liability-sale.AdditionalFinancing+sale.Prepayment {
+				t.Errorf("Sale = %+v, want financing %s and prepayment %s adjusting the rights retained", sale, tt.wantFinancing, tt.wantPrepayment)
+			}
+			if (sale.RoUAsset-tt.wantRoUAsset).Abs() > money.Unit || components.Total != sale.RoUAsset || components.LeaseLiability != 0 {
+				t.Errorf("RoU asset = %s with components %+v, want about %s", sale.RoUAsset, components, tt.wantRoUAsset)
+			}
+			if (sale.GainLoss - tt.wantGainLoss).Abs() > money.Unit {
+				t.Errorf("Gain on rights transferred = %s, want about %s", sale.GainLoss, tt.wantGainLoss)
+			}
+			// Cash and the RoU asset debited equal the asset, liability and gain credited
+			if sale.SalePrice+sale.RoUAsset != sale.CarryingAmount+liability+sale.GainLoss {
+				t.Errorf("Sale and leaseback entry does not balance: %+v", sale)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package calculation
+
+import (
+	"errors"
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"math"
+	"time"
+)
+
+// IFRS 16.63(c) and (d) leave "major part" and "substantially all" to judgement; these are the
+// thresholds commonly adopted as policy.
+const (
+	MajorPartOfEconomicLife     = 0.75 // Lease term as a share of the economic life of the underlying asset
+	SubstantiallyAllOfFairValue = 0.90 // Present value of the lease payments as a share of the asset's fair value
+)
+
+// ClassifyLessorLease classifies a lease granted by a lessor as a finance or an operating lease
+// (IFRS 16.61-66) and returns the indicators of a finance lease it meets.
+//
+// The indicators are the transfer of ownership, a purchase option the lessee is reasonably
+// certain to exercise, a lease term of at least MajorPartOfEconomicLife of the useful life, a
+// present value of the lease payments at the implicit rate of at least
+// SubstantiallyAllOfFairValue of the fair value, a specialised asset and the situations of
+// IFRS 16.64. The useful life and fair value indicators are only assessed when those inputs
+// are given. A lease meeting any indicator is a finance lease, unless the lease records a
+// Classification reached on other features of the contract (IFRS 16.65).
+func ClassifyLessorLease(l lease.Lease) (lease.Classification, []string, error) {
+	if l.UsefulLifeMonths < 0 {
+		return "", nil, fmt.Errorf("useful life cannot be negative: %d months", l.UsefulLifeMonths)
+	}
+	var lifeEnd time.Time
+	if l.UsefulLifeMonths > 0 {
+		lifeEnd = l.StartDate.AddDate(0, l.UsefulLifeMonths, 0)
+	}
+	return classifyLessorLease(l, lifeEnd, "asset")
+}
+
+// classifyLessorLease classifies a lease granted by a lessor against an asset whose economic
+// life ends the day before lifeEnd, if known, and whose fair value is the lease's FairValue.
+func classifyLessorLease(l lease.Lease, lifeEnd time.Time, asset string) (lease.Classification, []string, error) {
+	var indicators []string
+	if l.OwnershipTransfer {
+		indicators = append(indicators, "IFRS 16.63(a) ownership transfers to the lessee by the end of the lease term")
+	}
+	if l.PurchaseOptionReasonablyCertain {
+		indicators = append(indicators, "IFRS 16.63(b) the lessee is reasonably certain to exercise a purchase option")
+	}
+
+	if !lifeEnd.IsZero() {
+		termDays := l.EndDate.Sub(l.StartDate).Hours()/24 + 1
+		lifeDays := lifeEnd.Sub(l.StartDate).Hours() / 24
+		if share := termDays / lifeDays; share >= MajorPartOfEconomicLife {
+			indicators = append(indicators, fmt.Sprintf("IFRS 16.63(c) the lease term is %.1f%% of the economic life of the %s", share*100, asset))
+		}
+	}
+
+	if l.FairValue > 0 {
+		rate, err := ImplicitRate(l)
+		if err != nil {
+			return "", nil, err
+		}
+		leasePayments := l
+		leasePayments.DiscountRate = rate
+		presentValue, err := presentValueAt(leasePayments)
+		if err != nil {
+			return "", nil, err
+		}
+		if share := presentValue / l.FairValue.Float64(); share >= SubstantiallyAllOfFairValue {
+			indicators = append(indicators, fmt.Sprintf("IFRS 16.63(d) the present value of the lease payments is %.1f%% of the fair value of the %s", share*100, asset))
+		}
+	}
+
+	if l.SpecialisedAsset {
+		indicators = append(indicators, "IFRS 16.63(e) the asset is so specialised that only the lessee can use it without major modifications")
+	}
+	if l.LesseeBearsCancellationLosses {
+		indicators = append(indicators, "IFRS 16.64(a) the lessee bears the lessor's losses if it cancels the lease")
+	}
+	if l.LesseeBearsResidualValueRisk {
+		indicators = append(indicators, "IFRS 16.64(b) gains or losses from changes in the fair value of the residual accrue to the lessee")
+	}
+	if l.BargainSecondaryPeriod {
+		indicators = append(indicators, "IFRS 16.64(c) the lessee can continue the lease for a secondary period at a rent substantially below market")
+	}
+
+	classification := lease.OperatingLease
+	if len(indicators) > 0 {
+		classification = lease.FinanceLease
+	}
+	switch l.Classification {
+	case "":
+	case lease.FinanceLease, lease.OperatingLease:
+		classification = l.Classification
+	default:
+		return "", nil, fmt.Errorf("unsupported lease classification: %s", l.Classification)
+	}
+	return classification, indicators, nil
+}
+
+// ImplicitRate returns the interest rate implicit in a lease granted by a lessor: the annual
+// rate, on the lease's day count and rate basis, at which the present value of the lease
+// payments and the unguaranteed residual value equals the fair value of the underlying asset
+// plus the lessor's initial direct costs (IFRS 16 Appendix A). Without a fair value, the
+// lease's DiscountRate is taken as the implicit rate.
+func ImplicitRate(l lease.Lease) (float64, error) {
+	if l.FairValue < 0 {
+		return 0, fmt.Errorf("fair value cannot be negative: %s", l.FairValue)
+	}
+	if l.UnguaranteedResidualValue < 0 {
+		return 0, fmt.Errorf("unguaranteed residual value cannot be negative: %s", l.UnguaranteedResidualValue)
+	}
+	if l.FairValue == 0 {
+		if l.DiscountRate <= 0 {
+			return 0, errors.New("a fair value of the underlying asset or a discount rate is required for the rate implicit in the lease")
+		}
+		return l.DiscountRate, nil
+	}
+
+	// The present value falls as the rate rises, so the rate is found by bisection
+	target := (l.FairValue + l.InitialDirectCost).Float64()
+	excess := func(rate float64) (float64, error) {
+		presentValue, err := presentValueAt(netInvestmentLease(l, rate))
+		return presentValue - target, err
+	}
+
+	low, high := 1e-9, 1.0
+	if e, err := excess(low); err != nil {
+		return 0, err
+	} else if e < 0 {
+		return 0, fmt.Errorf("the lease payments and unguaranteed residual value do not recover the fair value and initial direct costs of %s", l.FairValue+l.InitialDirectCost)
+	}
+	for {
+		e, err := excess(high)
+		if err != nil {
+			return 0, err
+		}
+		if e <= 0 {
+			break
+		}
+		if high *= 2; high > 1e3 {
+			return 0, errors.New("the rate implicit in the lease exceeds 100000%")
+		}
+	}
+	for high-low > 1e-12 {
+		mid := (low + high) / 2
+		e, err := excess(mid)
+		if err != nil {
+			return 0, err
+		}
+		if e > 0 {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2, nil
+}
+
+// netInvestmentLease returns the lease on which a lessor's net investment is measured: its
+// payments, and the residual value it expects to recover at the end of the lease term,
+// guaranteed or not, discounted at the given rate implicit in the lease.
+func netInvestmentLease(l lease.Lease, rate float64) lease.Lease {
+	l.DiscountRate = rate
+	l.ResidualValue += l.UnguaranteedResidualValue
+	return l
+}
+
+// presentValueAt returns the present value at the commencement date of the lease payments of a
+// lease, discounted at its DiscountRate, including those received at or before commencement
+// net of the incentives paid to the lessee (IFRS 16.70(a)).
+func presentValueAt(l lease.Lease) (float64, error) {
+	flows, _, periodicRate, err := liabilityCashFlows(l)
+	if err != nil {
+		return 0, err
+	}
+	presentValue := (paymentsAtCommencement(l) + l.PrepaidRent - l.LeaseIncentives).Float64()
+	for _, flow := range flows {
+		presentValue += flow.amount.Float64() * math.Pow(1+periodicRate, -flow.position)
+	}
+	return presentValue, nil
+}
+
+// CalculateNetInvestment measures the net investment in a finance lease at the commencement
+// date: the present value at the implicit rate of the lease payments not yet received and of
+// the unguaranteed residual value (IFRS 16.68-70). With a fair value, it equals the fair value
+// of the underlying asset plus the initial direct costs, less the payments received at or
+// before commencement.
+func CalculateNetInvestment(l lease.Lease, implicitRate float64) (money.Amount, error) {
+	return CalculateLeaseLiability(netInvestmentLease(l, implicitRate))
+}
+
+// GenerateNetInvestmentSchedule creates the daily schedule of the net investment in a finance
+// lease. Finance income accrues at the implicit rate on the same basis as interest on a lease
+// liability and is booked to Income (IFRS 16.75); each receipt settles the income accrued and
+// then reduces the net investment. The final receipt includes the residual value recovered
+// with the asset, guaranteed or not, which is also shown on its own in ResidualValue.
+func GenerateNetInvestmentSchedule(l lease.Lease, implicitRate float64, netInvestment money.Amount) ([]AmortizationEntry, error) {
+	schedule, err := GenerateLiabilitySchedule(netInvestmentLease(l, implicitRate), netInvestment)
+	if err != nil {
+		return nil, err
+	}
+	for i := range schedule {
+		schedule[i].Income, schedule[i].InterestExpense = schedule[i].InterestExpense, 0
+	}
+	return schedule, nil
+}
+
+// FinanceLeaseGainLoss returns the gain (or, if negative, loss) a lessor recognises at the
+// commencement date when it derecognises the underlying asset of a finance lease: the net
+// investment and the payments received by then, less the initial direct costs included in the
+// net investment and the carrying amount of the asset.
+func FinanceLeaseGainLoss(l lease.Lease, netInvestment money.Amount) money.Amount {
+	received := paymentsAtCommencement(l) + l.PrepaidRent - l.LeaseIncentives
+	return netInvestment + received - l.InitialDirectCost - l.CarryingAmount
+}
+
+// OperatingLeaseIncomeSchedule recognises the lease payments of an operating lease as income
+// on a straight-line basis over the lease term (IFRS 16.81). Each entry books the day's share
+// of the income to Income and the payments received that day; the balance is the income
+// accrued but not yet received, negative while rent is received in advance. Incentives paid to
+// the lessee open it as an asset and rent received before commencement as deferred income, so
+// it closes at zero on the end date. The receipts are not discounted, so the lease needs no
+// discount rate.
+func OperatingLeaseIncomeSchedule(l lease.Lease) ([]AmortizationEntry, error) {
+	schedule, err := straightLineSchedule(l)
+	if err != nil {
+		return nil, err
+	}
+	for i := range schedule {
+		schedule[i].Income, schedule[i].Expense = schedule[i].Expense, 0
+	}
+	return schedule, nil
+}
+
+// UnderlyingAssetSchedule depreciates the carrying amount of the underlying asset of an
+// operating lease, which the lessor continues to recognise, over the remaining useful life of
+// the asset from the start date, using the lease's depreciation method (IFRS 16.84). A lease
+// without a carrying amount has no schedule.
+func UnderlyingAssetSchedule(l lease.Lease) ([]AmortizationEntry, error) {
+	if l.CarryingAmount < 0 {
+		return nil, fmt.Errorf("carrying amount cannot be negative: %s", l.CarryingAmount)
+	}
+	if l.CarryingAmount == 0 {
+		return nil, nil
+	}
+	if l.UsefulLifeMonths <= 0 {
+		return nil, errors.New("a useful life is required to depreciate the underlying asset")
+	}
+	return depreciationSchedule(l, l.CarryingAmount, l.StartDate, l.StartDate.AddDate(0, l.UsefulLifeMonths, -1), 1)
+}
@@ -0,0 +1,180 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"math"
+	"testing"
+)
+
+func TestClassifyLessorLease(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(*lease.Lease)
+		want       lease.Classification
+		indicators int
+		wantErr    bool
+	}{
+		{"No indicators", func(l *lease.Lease) {}, lease.OperatingLease, 0, false},
+		{"Ownership transfers", func(l *lease.Lease) { l.OwnershipTransfer = true }, lease.FinanceLease, 1, false},
+		{"Major part of the economic life", func(l *lease.Lease) { l.UsefulLifeMonths = 48 }, lease.FinanceLease, 1, false},
+		{"Minor part of the economic life", func(l *lease.Lease) { l.UsefulLifeMonths = 120 }, lease.OperatingLease, 0, false},
+		{"Substantially all of the fair value", func(l *lease.Lease) { l.FairValue = 34000 * money.Unit }, lease.FinanceLease, 1, false},
+		{"Well short of the fair value", func(l *lease.Lease) {
+			l.FairValue = 60000 * money.Unit
+			l.UnguaranteedResidualValue = 35000 * money.Unit
+		}, lease.OperatingLease, 0, false},
+		{"Specialised asset and cancellation losses", func(l *lease.Lease) {
+			l.SpecialisedAsset = true
+			l.LesseeBearsCancellationLosses = true
+		}, lease.FinanceLease, 2, false},
+		{"Judgement overrides the indicators", func(l *lease.Lease) {
+			l.SpecialisedAsset = true
+			l.Classification = lease.OperatingLease
+		}, lease.OperatingLease, 1, false},
+		{"Unsupported classification", func(l *lease.Lease) { l.Classification = "Sales-type" }, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease("2024-01-01", "2026-12-31")
+			l.Role = lease.LessorRole
+			tt.modify(&l)
+			got, indicators, err := ClassifyLessorLease(l)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ClassifyLessorLease() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || len(indicators) != tt.indicators {
+				t.Errorf("ClassifyLessorLease() = %q with indicators %q, want %q with %d", got, indicators, tt.want, tt.indicators)
+			}
+		})
+	}
+}
+
+func TestImplicitRate(t *testing.T) {
+	l := testLease("2024-01-01", "2026-12-31")
+	l.Role = lease.LessorRole
+	presentValue, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+
+	// A fair value equal to the payments discounted at 5% implies 5%
+	l.FairValue = presentValue
+	l.DiscountRate = 0
+	rate, err := ImplicitRate(l)
+	if err != nil {
+		t.Fatalf("ImplicitRate() error = %v", err)
+	}
+	if math.Abs(rate-0.05) > 1e-6 {
+		t.Errorf("ImplicitRate() = %.8f, want 0.05", rate)
+	}
+
+	// Initial direct costs lower the implicit rate
+	l.InitialDirectCost = 500 * money.Unit
+	if withCosts, err := ImplicitRate(l); err != nil || withCosts >= rate {
+		t.Errorf("ImplicitRate() with initial direct costs = %.8f, %v; want below %.8f", withCosts, err, rate)
+	}
+
+	l.FairValue = 40000 * money.Unit
+	if _, err := ImplicitRate(l); err == nil {
+		t.Errorf("ImplicitRate() expected an error when the payments do not recover the fair value")
+	}
+}
+
+func TestFinanceLeaseNetInvestment(t *testing.T) {
+	l := testLease("2024-01-01", "2026-12-31")
+	l.Role = lease.LessorRole
+	l.FairValue = 35000 * money.Unit
+	l.InitialDirectCost = 300 * money.Unit
+	l.UnguaranteedResidualValue = 2000 * money.Unit
+	l.CarryingAmount = 32000 * money.Unit
+
+	rate, err := ImplicitRate(l)
+	if err != nil {
+		t.Fatalf("ImplicitRate() error = %v", err)
+	}
+	netInvestment, err := CalculateNetInvestment(l, rate)
+	if err != nil {
+		t.Fatalf("CalculateNetInvestment() error = %v", err)
+	}
+	if want := 35300 * money.Unit; (netInvestment - want).Abs() > money.Cent {
+		t.Errorf("Net investment = %s, want %s, the fair value plus initial direct costs", netInvestment, want)
+	}
+	if gain := FinanceLeaseGainLoss(l, netInvestment); (gain - 3000*money.Unit).Abs() > money.Cent {
+		t.Errorf("Gain on derecognition = %s, want 3000.00", gain)
+	}
+
+	schedule, err := GenerateNetInvestmentSchedule(l, rate, netInvestment)
+	if err != nil {
+		t.Fatalf("GenerateNetInvestmentSchedule() error = %v", err)
+	}
+	var income, receipts money.Amount
+	for i, entry := range schedule {
+		if entry.InterestExpense != 0 || entry.OpeningBalance+entry.Income-entry.Payment != entry.ClosingBalance {
+			t.Fatalf("Entry %d does not reconcile as finance income: %+v", i+1, entry)
+		}
+		income += entry.Income
+		receipts += entry.Payment
+	}
+	last := schedule[len(schedule)-1]
+	if last.ClosingBalance != 0 || last.ResidualValue != 2000*money.Unit {
+		t.Errorf("Last entry = %+v, want the unguaranteed residual recovered and a zero balance", last)
+	}
+	if receipts != 38000*money.Unit || income != receipts-netInvestment {
+		t.Errorf("Receipts = %s, income = %s; want 38000.00 and the excess over the net investment", receipts, income)
+	}
+
+	// The finance income of a period is summarised with the receipts (eleven in arrears) and
+	// the net investment
+	result := CalculationResult{NetInvestmentSchedule: schedule}
+	if err := CalculateAccountingPeriodSummary(&result, "2024-01-01", "2024-12-31"); err != nil {
+		t.Fatalf("CalculateAccountingPeriodSummary() error = %v", err)
+	}
+	if result.PeriodLeaseReceipts != 11000*money.Unit || result.PeriodNetInvestmentStart != netInvestment ||
+		result.PeriodNetInvestmentEnd != result.PeriodNetInvestmentStart+result.PeriodFinanceIncome-result.PeriodLeaseReceipts {
+		t.Errorf("Period summary = %s opening, %s income, %s receipts, %s closing; want it to reconcile",
+			result.PeriodNetInvestmentStart, result.PeriodFinanceIncome, result.PeriodLeaseReceipts, result.PeriodNetInvestmentEnd)
+	}
+}
+
+func TestOperatingLeaseSchedules(t *testing.T) {
+	l := testLease("2024-01-01", "2026-12-31")
+	l.Role = lease.LessorRole
+	l.DiscountRate = 0 // Operating lease income is not discounted
+	l.EndDate = mustParseDate(testDateLayout, "2024-12-31")
+	l.PaymentAmount = 300 * money.Unit
+	l.PaymentTiming = lease.Advance
+	l.LeaseIncentives = 540 * money.Unit
+	l.PaymentSteps = []lease.PaymentStep{{StartDate: mustParseDate(testDateLayout, "2024-07-01"), Amount: 400 * money.Unit}}
+	l.CarryingAmount = 36540 * money.Unit
+	l.UsefulLifeMonths = 60
+
+	income, err := OperatingLeaseIncomeSchedule(l)
+	if err != nil {
+		t.Fatalf("OperatingLeaseIncomeSchedule() error = %v", err)
+	}
+	// Six receipts of 300 and six of 400, less the incentive paid, spread evenly over 366 days
+	for i, entry := range income {
+		if entry.Income != 10*money.Unit || entry.Expense != 0 {
+			t.Fatalf("Entry %d = %+v, want 10.00 of income", i+1, entry)
+		}
+	}
+	if income[0].OpeningBalance != 540*money.Unit || income[len(income)-1].ClosingBalance != 0 {
+		t.Errorf("Income schedule runs from %s to %s, want the incentive paid to zero", income[0].OpeningBalance, income[len(income)-1].ClosingBalance)
+	}
+
+	assets, err := UnderlyingAssetSchedule(l)
+	if err != nil {
+		t.Fatalf("UnderlyingAssetSchedule() error = %v", err)
+	}
+	last := assets[len(assets)-1]
+	if last.Date.Format(testDateLayout) != "2028-12-31" || last.ClosingBalance != 0 || assets[0].Depreciation != 20*money.Unit {
+		t.Errorf("Asset schedule ends %+v, want 20.00 a day to zero on 2028-12-31", last)
+	}
+
+	l.UsefulLifeMonths = 0
+	if _, err := UnderlyingAssetSchedule(l); err == nil {
+		t.Errorf("UnderlyingAssetSchedule() expected an error without a useful life")
+	}
+}
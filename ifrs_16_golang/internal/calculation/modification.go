@@ -0,0 +1,376 @@
+package calculation
+
+import (
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"math"
+	"sort"
+	"time"
+)
+
+// Remeasurement records how the lease liability and RoU asset changed when a lease was
+// remeasured. The change is booked at the start of the effective date.
+type Remeasurement struct {
+	EffectiveDate   time.Time    `json:"effectiveDate"`
+	Reason          string       `json:"reason"` // The lease.ModificationType that caused it
+	Description     string       `json:"description,omitempty"`
+	LiabilityBefore money.Amount `json:"liabilityBefore"`
+	LiabilityAfter  money.Amount `json:"liabilityAfter"`
+	RoUAssetBefore  money.Amount `json:"rouAssetBefore"`
+	RoUAssetAfter   money.Amount `json:"rouAssetAfter"`
+	GainLoss        money.Amount `json:"gainLoss,omitempty"` // Gain (positive) or loss (negative) recognised in profit or loss
+	// ProvisionChange is the remeasurement of the restoration provision when the lease term
+	// changes, added to the RoU asset (IFRIC 1.5).
+	ProvisionChange money.Amount `json:"provisionChange,omitempty"`
+}
+
+// ModifiedSchedules holds the liability and RoU asset schedules of a lease after its
+// modifications and impairments have been applied, together with the terms in force at the end.
+type ModifiedSchedules struct {
+	Lease             lease.Lease
+	LiabilitySchedule []AmortizationEntry
+	RoUAssetSchedule  []AmortizationEntry
+	Remeasurements    []Remeasurement
+	Impairments       []ImpairmentAdjustment
+	// RestorationSchedule unwinds the restoration provision to the end of the lease term in
+	// force, remeasured whenever a modification changes that end.
+	RestorationSchedule []AmortizationEntry
+
+	// unimpaired is the RoU asset schedule the lease would have without any impairment,
+	// which limits the reversal of an impairment loss.
+	unimpaired []AmortizationEntry
+}
+
+// ApplyModifications generates the liability and RoU asset schedules of a lease and applies
+// its modifications in order of effective date. At each effective date the schedules are
+// split: entries before the date are kept, the liability is remeasured as the present value
+// of the remaining payments under the modified terms, and both schedules continue from the
+// new carrying amounts. Payments falling due on or after the effective date are made under
+// the modified terms.
+//
+// A decrease in scope first reduces the liability and the RoU asset in proportion and
+// recognises the difference as a gain or loss on partial termination (IFRS 16.46(a)). A
+// modification that shortens the lease term decreases its scope too: the liability for the
+// term given up is derecognised at the rate in force, with the RoU asset for the same share
+// of the remaining term. Any
+// remaining change in the liability adjusts the RoU asset (IFRS 16.46(b)); if that would
+// take the RoU asset below zero, the excess is recognised in profit or loss. When the end of
+// the lease term changes, the restoration provision is remeasured for the new date of
+// restoration and the change adjusts the RoU asset too (IFRIC 1.5).
+//
+// A modification is remeasured at the revised discount rate it must carry (IFRS 16.45(c)).
+// An increase in scope is remeasured as part of the existing lease through its new payment
+// amount; a separate lease under IFRS 16.44 should be entered as a lease of its own. Index
+// reviews change only the payments, and residual value reviews only the amount expected to
+// be payable under a residual value guarantee; both are remeasured at the unchanged rate
+// (IFRS 16.42-43). An option reassessment derives the lease term again from the revised
+// assessment (see LeaseTerm) and is remeasured at the revised discount rate it must carry
+// (IFRS 16.40(a)); on a lease with options, a new end date replaces the contract end date
+// the options apply to.
+//
+// Impairments of the RoU asset (see impair) are applied in the same pass, so a modification
+// continues from the impaired carrying amount and an impairment from the remeasured one. On
+// the same date, modifications are applied first.
+func ApplyModifications(l lease.Lease, initialLiability, initialRoUAsset money.Amount) (ModifiedSchedules, error) {
+	liabilitySchedule, err := GenerateLiabilitySchedule(l, initialLiability)
+	if err != nil {
+		return ModifiedSchedules{}, err
+	}
+	rouSchedule, err := GenerateRoUAssetSchedule(l, initialRoUAsset)
+	if err != nil {
+		return ModifiedSchedules{}, err
+	}
+	provision, err := CalculateRestorationProvision(l)
+	if err != nil {
+		return ModifiedSchedules{}, err
+	}
+	restorationSchedule, err := GenerateRestorationProvisionSchedule(l, provision)
+	if err != nil {
+		return ModifiedSchedules{}, err
+	}
+
+	result := ModifiedSchedules{
+		Lease:               l,
+		LiabilitySchedule:   liabilitySchedule,
+		RoUAssetSchedule:    rouSchedule,
+		RestorationSchedule: restorationSchedule,
+	}
+
+	modifications := make([]lease.Modification, len(l.Modifications))
+	copy(modifications, l.Modifications)
+	sort.SliceStable(modifications, func(i, j int) bool {
+		return modifications[i].EffectiveDate.Before(modifications[j].EffectiveDate)
+	})
+
+	impairments := make([]lease.Impairment, len(l.Impairments))
+	copy(impairments, l.Impairments)
+	sort.SliceStable(impairments, func(i, j int) bool {
+		return impairments[i].Date.Before(impairments[j].Date)
+	})
+	if len(impairments) > 0 {
+		unimpaired := l
+		unimpaired.Impairments = nil
+		shadow, err := ApplyModifications(unimpaired, initialLiability, initialRoUAsset)
+		if err != nil {
+			return ModifiedSchedules{}, err
+		}
+		result.unimpaired = shadow.RoUAssetSchedule
+	}
+
+	for len(modifications) > 0 || len(impairments) > 0 {
+		if len(impairments) == 0 || (len(modifications) > 0 && !impairments[0].Date.Before(modifications[0].EffectiveDate)) {
+			m := modifications[0]
+			modifications = modifications[1:]
+			if err := result.remeasure(m); err != nil {
+				return result, fmt.Errorf("modification effective %s: %w", m.EffectiveDate.Format("2006-01-02"), err)
+			}
+			continue
+		}
+
+		impairment := impairments[0]
+		impairments = impairments[1:]
+		if err := result.impair(impairment); err != nil {
+			return result, fmt.Errorf("impairment on %s: %w", impairment.Date.Format("2006-01-02"), err)
+		}
+	}
+
+	return result, nil
+}
+
+// remeasure applies a change in lease terms at its effective date and continues both
+// schedules from the remeasured carrying amounts.
+func (s *ModifiedSchedules) remeasure(m lease.Modification) error {
+	current := s.Lease
+	effective := m.EffectiveDate
+
+	reason := m.Type
+	if reason == "" {
+		reason = lease.ContractModification
+	}
+	if reason == lease.IndexReview && (m.RevisedDiscountRate != 0 || !m.NewEndDate.IsZero() || m.ScopeChangePercent != 0 || m.NewResidualValue != nil) {
+		return fmt.Errorf("an index review may only change the payment amount")
+	}
+	if reason == lease.ResidualValueReview {
+		if m.NewResidualValue == nil {
+			return fmt.Errorf("a residual value review needs the revised amount expected to be payable")
+		}
+		if m.NewPaymentAmount != 0 || m.RevisedDiscountRate != 0 || !m.NewEndDate.IsZero() || m.ScopeChangePercent != 0 {
+			return fmt.Errorf("a residual value review may only change the amount expected to be payable under the guarantee")
+		}
+	}
+	if reason == lease.ContractModification {
+		if m.RevisedDiscountRate == 0 {
+			return fmt.Errorf("a modification that is not a separate lease is remeasured at a revised discount rate (IFRS 16.45(c))")
+		}
+		if m.ScopeChangePercent > 0 {
+			return fmt.Errorf("an increase in scope is entered as a new payment amount, or as a lease of its own when it is a separate lease (IFRS 16.44)")
+		}
+	}
+	if reason == lease.OptionReassessment {
+		if m.NewPaymentAmount != 0 || !m.NewEndDate.IsZero() || m.ScopeChangePercent != 0 {
+			return fmt.Errorf("an option reassessment may only change the assessment of an option and the discount rate")
+		}
+		if m.Option < 1 || m.Option > len(current.Options) {
+			return fmt.Errorf("the lease has no option %d", m.Option)
+		}
+		if m.RevisedDiscountRate == 0 {
+			return fmt.Errorf("an option reassessment is remeasured at a revised discount rate (IFRS 16.40)")
+		}
+		if closes := current.Options[m.Option-1].ExerciseEnd; !closes.IsZero() && effective.After(closes) {
+			return fmt.Errorf("option %d can no longer be exercised after %s", m.Option, closes.Format("2006-01-02"))
+		}
+	}
+
+	if !effective.After(current.StartDate) || effective.After(current.EndDate) {
+		return fmt.Errorf("effective date must fall after commencement and within the lease term (%s to %s)",
+			current.StartDate.Format("2006-01-02"), current.EndDate.Format("2006-01-02"))
+	}
+	if m.NewPaymentAmount > 0 && current.PaymentFrequency == lease.Irregular {
+		return fmt.Errorf("a lease with an irregular payment schedule has no regular payment amount to change")
+	}
+	if m.NewPaymentAmount < 0 {
+		return fmt.Errorf("new payment amount cannot be negative: %s", m.NewPaymentAmount)
+	}
+	if m.NewResidualValue != nil && *m.NewResidualValue < 0 {
+		return fmt.Errorf("residual value guarantee cannot be negative: %s", *m.NewResidualValue)
+	}
+	if m.RevisedDiscountRate < 0 {
+		return fmt.Errorf("revised discount rate cannot be negative: %.4f", m.RevisedDiscountRate)
+	}
+	if m.ScopeChangePercent <= -1 {
+		return fmt.Errorf("scope decrease must be less than 100%%: %.2f", m.ScopeChangePercent)
+	}
+	if !m.NewEndDate.IsZero() && m.NewEndDate.Before(effective) {
+		return fmt.Errorf("new end date %s is before the effective date", m.NewEndDate.Format("2006-01-02"))
+	}
+
+	revised := current
+	if m.NewPaymentAmount > 0 {
+		// The new payment replaces any stepped or escalating schedule from here on
+		revised.PaymentAmount = m.NewPaymentAmount
+		revised.PaymentSteps = nil
+		revised.EscalationRate = 0
+		revised.RentFreeMonths = 0
+	}
+	if !m.NewEndDate.IsZero() {
+		revised.EndDate = m.NewEndDate
+		if len(revised.Options) > 0 {
+			revised.ContractEndDate = m.NewEndDate
+		}
+	}
+	if m.RevisedDiscountRate > 0 {
+		revised.DiscountRate = m.RevisedDiscountRate
+	}
+	if m.NewResidualValue != nil {
+		revised.ResidualValue = *m.NewResidualValue
+	}
+	if reason == lease.OptionReassessment {
+		revised.Options = append([]lease.Option(nil), current.Options...)
+		revised.Options[m.Option-1].ReasonablyCertain = m.OptionReasonablyCertain
+	}
+	revised, err := LeaseTerm(revised)
+	if err != nil {
+		return err
+	}
+	if revised.EndDate.Before(effective) {
+		return fmt.Errorf("the revised lease term ends on %s, before the effective date", revised.EndDate.Format("2006-01-02"))
+	}
+
+	liabilityBefore, liabilityKept, liabilityPeriod := splitSchedule(s.LiabilitySchedule, effective)
+	rouBefore, rouKept, rouPeriod := splitSchedule(s.RoUAssetSchedule, effective)
+
+	liabilityAfter, err := remainingPresentValue(revised, effective)
+	if err != nil {
+		return err
+	}
+
+	// A decrease in scope partially terminates the lease (IFRS 16.46(a)). Option reassessments
+	// are not modifications, and a shorter term they give adjusts the RoU asset instead.
+	liabilityRetained, rouRetained := liabilityBefore, rouBefore
+	if reason == lease.ContractModification && revised.EndDate.Before(current.EndDate) {
+		// The payments of the shorter term under the terms in force are kept
+		shortened := current
+		shortened.EndDate = revised.EndDate
+		if len(shortened.Options) > 0 {
+			shortened.ContractEndDate = revised.EndDate
+			shortened.Options = nil
+		}
+		liabilityRetained, err = remainingPresentValue(shortened, effective)
+		if err != nil {
+			return err
+		}
+		remaining := daysBetween(effective, current.EndDate.AddDate(0, 0, 1))
+		kept := daysBetween(effective, revised.EndDate.AddDate(0, 0, 1))
+		rouRetained = rouBefore.Mul(kept / remaining)
+	}
+	if m.ScopeChangePercent < 0 {
+		retained := 1 + m.ScopeChangePercent
+		liabilityRetained = liabilityRetained.Mul(retained)
+		rouRetained = rouRetained.Mul(retained)
+	}
+	gainLoss := (liabilityBefore - liabilityRetained) - (rouBefore - rouRetained)
+
+	// Restoring the asset at a different date remeasures the provision (IFRIC 1.5)
+	provisionChange := money.Amount(0)
+	if len(s.RestorationSchedule) > 0 && !revised.EndDate.Equal(current.EndDate) {
+		provisionBefore, provisionKept, provisionPeriod := splitSchedule(s.RestorationSchedule, effective)
+		provisionAfter := restorationProvisionAt(revised, effective)
+		provisionChange = provisionAfter - provisionBefore
+		provisionRest := restorationScheduleFrom(revised, provisionAfter, effective, provisionPeriod)
+		if len(provisionRest) > 0 {
+			provisionRest[0].OpeningBalance = provisionBefore
+			provisionRest[0].Remeasurement = provisionChange
+		}
+		s.RestorationSchedule = append(provisionKept, provisionRest...)
+	}
+
+	// The rest of the remeasurement adjusts the RoU asset (IFRS 16.46(b))
+	rouAfter := rouRetained + liabilityAfter - liabilityRetained + provisionChange
+	if rouAfter < 0 {
+		gainLoss -= rouAfter
+		rouAfter = 0
+	}
+
+	liabilityRest, err := liabilityScheduleFrom(revised, liabilityAfter, effective, liabilityPeriod)
+	if err != nil {
+		return err
+	}
+	rouRest, err := rouScheduleFrom(revised, rouAfter, effective, rouPeriod)
+	if err != nil {
+		return err
+	}
+
+	// The first continued entry opens at the carrying amount before remeasurement and
+	// shows the adjustment separately.
+	if len(liabilityRest) > 0 {
+		liabilityRest[0].OpeningBalance = liabilityBefore
+		liabilityRest[0].Remeasurement = liabilityAfter - liabilityBefore
+	}
+	if len(rouRest) > 0 {
+		rouRest[0].OpeningBalance = rouBefore
+		rouRest[0].Remeasurement = rouAfter - rouBefore
+	}
+
+	s.Lease = revised
+	s.LiabilitySchedule = append(liabilityKept, liabilityRest...)
+	s.RoUAssetSchedule = append(rouKept, rouRest...)
+	s.Remeasurements = append(s.Remeasurements, Remeasurement{
+		EffectiveDate:   effective,
+		Reason:          string(reason),
+		Description:     m.Description,
+		LiabilityBefore: liabilityBefore,
+		LiabilityAfter:  liabilityAfter,
+		RoUAssetBefore:  rouBefore,
+		RoUAssetAfter:   rouAfter,
+		GainLoss:        gainLoss,
+		ProvisionChange: provisionChange,
+	})
+
+	return nil
+}
+
+// splitSchedule returns the carrying amount at the close of the day before date, the
+// entries dated before it, and the period number the continued schedule should start at.
+func splitSchedule(schedule []AmortizationEntry, date time.Time) (money.Amount, []AmortizationEntry, int) {
+	i := sort.Search(len(schedule), func(i int) bool {
+		return !schedule[i].Date.Before(date)
+	})
+
+	kept := make([]AmortizationEntry, i)
+	copy(kept, schedule[:i])
+
+	switch {
+	case i < len(schedule):
+		// The entry being replaced already carries the right period number
+		return schedule[i].OpeningBalance, kept, schedule[i].Period
+	case i > 0:
+		return schedule[i-1].ClosingBalance, kept, schedule[i-1].Period + 1
+	default:
+		return 0, kept, 1
+	}
+}
+
+// remainingPresentValue discounts the lease payments falling due on or after date to the
+// close of the previous day, on the same periodic basis as the liability schedule.
+func remainingPresentValue(l lease.Lease, date time.Time) (money.Amount, error) {
+	flows, periods, periodicRate, err := liabilityCashFlows(l)
+	if err != nil {
+		return 0, err
+	}
+	cycle, err := paymentCycleFor(l)
+	if err != nil {
+		return 0, err
+	}
+
+	base := periodPosition(l, cycle, periods, date.AddDate(0, 0, -1))
+	presentValue := 0.0
+	for _, flow := range flows {
+		if flow.date.Before(date) {
+			continue
+		}
+		presentValue += flow.amount.Float64() * math.Pow(1+periodicRate, -(flow.position-base))
+	}
+
+	return money.FromFloat(presentValue), nil
+}
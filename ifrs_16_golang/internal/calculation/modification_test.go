@@ -0,0 +1,373 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"math"
+	"testing"
+	"time"
+)
+
+func entryOn(schedule []AmortizationEntry, date time.Time) (AmortizationEntry, bool) {
+	for _, entry := range schedule {
+		if entry.Date.Equal(date) {
+			return entry, true
+		}
+	}
+	return AmortizationEntry{}, false
+}
+
+func TestApplyModificationsRentIncrease(t *testing.T) {
+	l := testLease("2024-01-01", "2026-01-01")
+	effective := mustParseDate(testDateLayout, "2025-01-02")
+	l.Modifications = []lease.Modification{{EffectiveDate: effective, NewPaymentAmount: 1200 * money.Unit, RevisedDiscountRate: 0.05}}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	original, err := GenerateLiabilitySchedule(l, liability)
+	if err != nil {
+		t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+	}
+
+	result, err := ApplyModifications(l, liability, liability)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+	if len(result.Remeasurements) != 1 {
+		t.Fatalf("Expected 1 remeasurement, got %d", len(result.Remeasurements))
+	}
+	r := result.Remeasurements[0]
+
+	// The history before the effective date is unchanged
+	before, _ := entryOn(original, effective.AddDate(0, 0, -1))
+	kept, _ := entryOn(result.LiabilitySchedule, effective.AddDate(0, 0, -1))
+	if kept != before {
+		t.Errorf("Entry before the effective date changed: got %+v, want %+v", kept, before)
+	}
+	if r.LiabilityBefore != before.ClosingBalance {
+		t.Errorf("LiabilityBefore = %s, want %s", r.LiabilityBefore, before.ClosingBalance)
+	}
+
+	// Twelve remaining payments of 1200, the first a full period after the day before
+	rate := 0.05 / 12
+	expectedAfter := 1200 * (1 - math.Pow(1+rate, -12)) / rate
+	if r.LiabilityAfter != money.FromFloat(expectedAfter) {
+		t.Errorf("LiabilityAfter = %s, want %.2f", r.LiabilityAfter, expectedAfter)
+	}
+
+	// Without a scope change the whole remeasurement adjusts the RoU asset
+	if r.RoUAssetAfter-r.RoUAssetBefore != r.LiabilityAfter-r.LiabilityBefore {
+		t.Errorf("RoU adjustment %s does not match liability remeasurement %s",
+			r.RoUAssetAfter-r.RoUAssetBefore, r.LiabilityAfter-r.LiabilityBefore)
+	}
+	if r.GainLoss != 0 {
+		t.Errorf("GainLoss = %s, want 0", r.GainLoss)
+	}
+
+	// The effective date entry opens at the old carrying amount and shows the adjustment
+	first, ok := entryOn(result.LiabilitySchedule, effective)
+	if !ok {
+		t.Fatalf("No liability entry on the effective date")
+	}
+	if first.OpeningBalance != r.LiabilityBefore {
+		t.Errorf("Opening balance on effective date = %s, want %s", first.OpeningBalance, r.LiabilityBefore)
+	}
+	if first.Remeasurement != r.LiabilityAfter-r.LiabilityBefore {
+		t.Errorf("Remeasurement on effective date = %s, want %s", first.Remeasurement, r.LiabilityAfter-r.LiabilityBefore)
+	}
+
+	totalPayments := money.Amount(0)
+	for _, entry := range result.LiabilitySchedule {
+		if !entry.Date.Before(effective) {
+			totalPayments += entry.Payment
+		}
+	}
+	if totalPayments != 12*1200*money.Unit {
+		t.Errorf("Payments after the effective date = %s, want 14400.00", totalPayments)
+	}
+
+	last := result.LiabilitySchedule[len(result.LiabilitySchedule)-1]
+	if last.ClosingBalance != 0 {
+		t.Errorf("Final liability balance = %s, want 0", last.ClosingBalance)
+	}
+	lastRoU := result.RoUAssetSchedule[len(result.RoUAssetSchedule)-1]
+	if lastRoU.ClosingBalance != 0 || !lastRoU.Date.Equal(l.EndDate) {
+		t.Errorf("RoU asset should be fully depreciated on %s, got %s on %s",
+			l.EndDate.Format("2006-01-02"), lastRoU.ClosingBalance, lastRoU.Date.Format("2006-01-02"))
+	}
+}
+
+func TestApplyModificationsScopeDecrease(t *testing.T) {
+	l := testLease("2024-01-01", "2026-01-01")
+	effective := mustParseDate(testDateLayout, "2025-01-02")
+	l.Modifications = []lease.Modification{{
+		EffectiveDate:       effective,
+		NewPaymentAmount:    500 * money.Unit,
+		RevisedDiscountRate: 0.05,
+		ScopeChangePercent:  -0.5,
+	}}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	rouAsset := liability + 3000*money.Unit // Initial direct costs put the asset above the liability
+
+	result, err := ApplyModifications(l, liability, rouAsset)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+	r := result.Remeasurements[0]
+
+	// Half the liability and half the asset are derecognised
+	expectedGainLoss := money.FromFloat(0.5*r.LiabilityBefore.Float64() - 0.5*r.RoUAssetBefore.Float64())
+	if (r.GainLoss - expectedGainLoss).Abs() > money.Cent {
+		t.Errorf("GainLoss = %s, want %s", r.GainLoss, expectedGainLoss)
+	}
+	if r.GainLoss >= 0 {
+		t.Errorf("Expected a loss when the asset exceeds the liability, got %s", r.GainLoss)
+	}
+
+	expectedRoU := money.FromFloat(0.5*r.RoUAssetBefore.Float64() + r.LiabilityAfter.Float64() - 0.5*r.LiabilityBefore.Float64())
+	if (r.RoUAssetAfter - expectedRoU).Abs() > money.Cent {
+		t.Errorf("RoUAssetAfter = %s, want %s", r.RoUAssetAfter, expectedRoU)
+	}
+
+	// The period summary keeps the adjustments out of principal and depreciation
+	calcResult := CalculationResult{
+		LiabilitySchedule: result.LiabilitySchedule,
+		RoUAssetSchedule:  result.RoUAssetSchedule,
+		Remeasurements:    result.Remeasurements,
+	}
+	if err := CalculateAccountingPeriodSummary(&calcResult, "2025-01-01", "2025-12-31"); err != nil {
+		t.Fatalf("CalculateAccountingPeriodSummary() error = %v", err)
+	}
+	// 1 January is paid under the old terms, the rest of the year at the reduced rent
+	if calcResult.PeriodPayments != (1000+11*500)*money.Unit {
+		t.Errorf("PeriodPayments = %s, want 6500.00", calcResult.PeriodPayments)
+	}
+	if calcResult.PeriodPrincipalPayment+calcResult.PeriodInterestPaid != calcResult.PeriodPayments {
+		t.Errorf("Principal %s + interest paid %s != payments %s",
+			calcResult.PeriodPrincipalPayment, calcResult.PeriodInterestPaid, calcResult.PeriodPayments)
+	}
+	// The period's movements reconcile the balances exactly, without any balancing figure
+	movement := calcResult.PeriodLiabilityRemeasurement + calcResult.PeriodInterestExpense - calcResult.PeriodPayments
+	if calcResult.PeriodLiabilityStart+movement != calcResult.PeriodLiabilityEnd {
+		t.Errorf("Liability %s + movements %s != %s", calcResult.PeriodLiabilityStart, movement, calcResult.PeriodLiabilityEnd)
+	}
+	rouMovement := calcResult.PeriodRoUAssetRemeasurement - calcResult.PeriodDepreciation
+	if calcResult.PeriodRoUAssetStart+rouMovement != calcResult.PeriodRoUAssetEnd {
+		t.Errorf("RoU asset %s + movements %s != %s", calcResult.PeriodRoUAssetStart, rouMovement, calcResult.PeriodRoUAssetEnd)
+	}
+	if calcResult.PeriodInterestExpense <= 0 {
+		t.Errorf("PeriodInterestExpense = %s, want positive", calcResult.PeriodInterestExpense)
+	}
+	if calcResult.PeriodRemeasurementGainLoss != r.GainLoss {
+		t.Errorf("PeriodRemeasurementGainLoss = %s, want %s", calcResult.PeriodRemeasurementGainLoss, r.GainLoss)
+	}
+	if calcResult.PeriodRoUAssetRemeasurement != r.RoUAssetAfter-r.RoUAssetBefore {
+		t.Errorf("PeriodRoUAssetRemeasurement = %s, want %s",
+			calcResult.PeriodRoUAssetRemeasurement, r.RoUAssetAfter-r.RoUAssetBefore)
+	}
+}
+
+func TestApplyModificationsTermAndRate(t *testing.T) {
+	l := testLease("2024-01-01", "2026-01-01")
+	l.Modifications = []lease.Modification{{
+		EffectiveDate:       mustParseDate(testDateLayout, "2025-07-01"),
+		NewEndDate:          mustParseDate(testDateLayout, "2027-01-01"),
+		RevisedDiscountRate: 0.06,
+	}}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	result, err := ApplyModifications(l, liability, liability)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+
+	if !result.Lease.EndDate.Equal(mustParseDate(testDateLayout, "2027-01-01")) || result.Lease.DiscountRate != 0.06 {
+		t.Errorf("Modified terms not applied: end %s, rate %.2f", result.Lease.EndDate.Format("2006-01-02"), result.Lease.DiscountRate)
+	}
+	r := result.Remeasurements[0]
+	if r.LiabilityAfter <= r.LiabilityBefore {
+		t.Errorf("Extending the term should increase the liability: before %s, after %s", r.LiabilityBefore, r.LiabilityAfter)
+	}
+
+	last := result.LiabilitySchedule[len(result.LiabilitySchedule)-1]
+	if !last.Date.Equal(result.Lease.EndDate) || last.ClosingBalance != 0 {
+		t.Errorf("Liability should be settled on %s, got %s on %s",
+			result.Lease.EndDate.Format("2006-01-02"), last.ClosingBalance, last.Date.Format("2006-01-02"))
+	}
+}
+
+func TestApplyModificationsTermReduction(t *testing.T) {
+	l := testLease("2024-01-01", "2026-01-01")
+	effective := mustParseDate(testDateLayout, "2025-01-02")
+	newEnd := mustParseDate(testDateLayout, "2025-07-01")
+	l.Modifications = []lease.Modification{{EffectiveDate: effective, NewEndDate: newEnd, RevisedDiscountRate: 0.05}}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	rouAsset := liability + 3000*money.Unit // Initial direct costs put the asset above the liability
+
+	result, err := ApplyModifications(l, liability, rouAsset)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+	r := result.Remeasurements[0]
+
+	// Six of the twelve remaining payments are given up at the unchanged rate
+	rate := 0.05 / 12
+	expectedAfter := 1000 * (1 - math.Pow(1+rate, -6)) / rate
+	if r.LiabilityAfter != money.FromFloat(expectedAfter) {
+		t.Errorf("LiabilityAfter = %s, want %.2f", r.LiabilityAfter, expectedAfter)
+	}
+
+	// The RoU asset keeps the share of the remaining term left, 181 of 365 days, and the
+	// difference to the liability derecognised is a loss on partial termination
+	expectedRoU := money.FromFloat(r.RoUAssetBefore.Float64() * 181 / 365)
+	if (r.RoUAssetAfter - expectedRoU).Abs() > money.Cent {
+		t.Errorf("RoUAssetAfter = %s, want %s", r.RoUAssetAfter, expectedRoU)
+	}
+	expectedGainLoss := (r.LiabilityBefore - r.LiabilityAfter) - (r.RoUAssetBefore - r.RoUAssetAfter)
+	if r.GainLoss != expectedGainLoss || r.GainLoss >= 0 {
+		t.Errorf("GainLoss = %s, want a loss of %s", r.GainLoss, expectedGainLoss)
+	}
+
+	// Shortening the term through an option reassessment is not a modification
+	withOption := testLease("2024-01-01", "2026-01-01")
+	withOption.Options = []lease.Option{{Type: lease.TerminationOption, EndDate: newEnd.AddDate(0, 0, -1)}}
+	withOption.Modifications = []lease.Modification{{
+		Type:                    lease.OptionReassessment,
+		EffectiveDate:           effective,
+		RevisedDiscountRate:     0.05,
+		Option:                  1,
+		OptionReasonablyCertain: true,
+	}}
+	withOption, err = LeaseTerm(withOption)
+	if err != nil {
+		t.Fatalf("LeaseTerm() error = %v", err)
+	}
+	reassessed, err := ApplyModifications(withOption, liability, rouAsset)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+	if rr := reassessed.Remeasurements[0]; rr.GainLoss != 0 || rr.RoUAssetAfter-rr.RoUAssetBefore != rr.LiabilityAfter-rr.LiabilityBefore {
+		t.Errorf("Option reassessment = %+v, want the whole change against the RoU asset", rr)
+	}
+}
+
+func TestApplyModificationsRestorationTermChange(t *testing.T) {
+	l := testLease("2024-01-01", "2026-01-01")
+	l.RestorationCost = 10000 * money.Unit
+	l.RestorationDiscountRate = 0.04
+	effective := mustParseDate(testDateLayout, "2025-01-02")
+	newEnd := mustParseDate(testDateLayout, "2025-06-30")
+	l.Modifications = []lease.Modification{{EffectiveDate: effective, NewEndDate: newEnd, RevisedDiscountRate: 0.05}}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	components, err := CalculateInitialRoUAssetComponents(liability, l)
+	if err != nil {
+		t.Fatalf("CalculateInitialRoUAssetComponents() error = %v", err)
+	}
+	result, err := ApplyModifications(l, liability, components.Total)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+
+	// The asset is now restored six months earlier, so less of the discount is left to unwind
+	r := result.Remeasurements[0]
+	entry, ok := entryOn(result.RestorationSchedule, effective)
+	if !ok {
+		t.Fatalf("No restoration provision entry on %s", effective.Format("2006-01-02"))
+	}
+	shortened := l
+	shortened.EndDate = newEnd
+	if r.ProvisionChange <= 0 || entry.Remeasurement != r.ProvisionChange ||
+		entry.OpeningBalance+entry.Remeasurement != restorationProvisionAt(shortened, effective) {
+		t.Errorf("Provision remeasured by %s, entry %+v, want an increase to %s", r.ProvisionChange, entry, restorationProvisionAt(shortened, effective))
+	}
+
+	// The provision reaches the restoration cost on the new end date, when the RoU asset is used up
+	last := result.RestorationSchedule[len(result.RestorationSchedule)-1]
+	if !last.Date.Equal(newEnd) || last.ClosingBalance != l.RestorationCost {
+		t.Errorf("Provision closes at %s on %s, want %s on %s", last.ClosingBalance, last.Date.Format("2006-01-02"), l.RestorationCost, newEnd.Format("2006-01-02"))
+	}
+	lastRoU := result.RoUAssetSchedule[len(result.RoUAssetSchedule)-1]
+	if !lastRoU.Date.Equal(newEnd) || lastRoU.ClosingBalance != 0 {
+		t.Errorf("RoU asset closes at %s on %s, want 0 on %s", lastRoU.ClosingBalance, lastRoU.Date.Format("2006-01-02"), newEnd.Format("2006-01-02"))
+	}
+}
+
+func TestApplyModificationsResidualValueReview(t *testing.T) {
+	l := testLease("2024-01-01", "2026-01-01")
+	l.ResidualValue = 4000 * money.Unit
+	effective := mustParseDate(testDateLayout, "2025-07-01")
+	revisedAmount := 1500 * money.Unit
+	l.Modifications = []lease.Modification{{Type: lease.ResidualValueReview, EffectiveDate: effective, NewResidualValue: &revisedAmount}}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	result, err := ApplyModifications(l, liability, liability)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+
+	// The lower expected payment is remeasured at the unchanged rate
+	revised := l
+	revised.ResidualValue = revisedAmount
+	want, err := remainingPresentValue(revised, effective)
+	if err != nil {
+		t.Fatalf("remainingPresentValue() error = %v", err)
+	}
+	r := result.Remeasurements[0]
+	if r.Reason != string(lease.ResidualValueReview) || r.LiabilityAfter != want || r.LiabilityAfter >= r.LiabilityBefore {
+		t.Errorf("Remeasurement = %+v, want a decrease to %s", r, want)
+	}
+
+	last := result.LiabilitySchedule[len(result.LiabilitySchedule)-1]
+	if last.ResidualValue != revisedAmount || last.ClosingBalance != 0 {
+		t.Errorf("Last entry = %+v, want %s under the guarantee settling the liability", last, revisedAmount)
+	}
+
+	l.Modifications[0].RevisedDiscountRate = 0.06
+	if _, err := ApplyModifications(l, liability, liability); err == nil {
+		t.Errorf("ApplyModifications() expected an error for a residual value review with a revised rate")
+	}
+}
+
+func TestApplyModificationsInvalid(t *testing.T) {
+	tests := []struct {
+		name         string
+		modification lease.Modification
+	}{
+		{"Effective on commencement", lease.Modification{EffectiveDate: mustParseDate(testDateLayout, "2024-01-01"), NewPaymentAmount: 1100 * money.Unit, RevisedDiscountRate: 0.05}},
+		{"Effective after the term", lease.Modification{EffectiveDate: mustParseDate(testDateLayout, "2026-02-01"), NewPaymentAmount: 1100 * money.Unit, RevisedDiscountRate: 0.05}},
+		{"Full scope decrease", lease.Modification{EffectiveDate: mustParseDate(testDateLayout, "2025-01-01"), ScopeChangePercent: -1, RevisedDiscountRate: 0.05}},
+		{"New end before effective date", lease.Modification{EffectiveDate: mustParseDate(testDateLayout, "2025-01-01"), NewEndDate: mustParseDate(testDateLayout, "2024-12-01"), RevisedDiscountRate: 0.05}},
+		{"No revised discount rate", lease.Modification{EffectiveDate: mustParseDate(testDateLayout, "2025-01-01"), NewPaymentAmount: 1100 * money.Unit}},
+		{"Increase in scope", lease.Modification{EffectiveDate: mustParseDate(testDateLayout, "2025-01-01"), ScopeChangePercent: 0.25, RevisedDiscountRate: 0.05}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease("2024-01-01", "2026-01-01")
+			l.Modifications = []lease.Modification{tt.modification}
+			if _, err := ApplyModifications(l, money.FromFloat(22793.43), money.FromFloat(22793.43)); err == nil {
+				t.Errorf("ApplyModifications() expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package calculation
+
+import (
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+)
+
+// LeaseTerm returns the lease with its end date set to the end of the lease term derived from
+// its extension and termination options (IFRS 16.18-19): the contract end date, extended by
+// the extension options the lessee is reasonably certain to exercise, or the end date of an
+// earlier termination option it is reasonably certain to exercise. The end date as entered
+// is kept as ContractEndDate, so the term can be derived again when an assessment changes.
+// A lease without options is returned unchanged.
+func LeaseTerm(l lease.Lease) (lease.Lease, error) {
+	if len(l.Options) == 0 {
+		return l, nil
+	}
+	if l.ContractEndDate.IsZero() {
+		l.ContractEndDate = l.EndDate
+	}
+
+	for i, o := range l.Options {
+		if err := validateOption(l, o); err != nil {
+			return l, fmt.Errorf("option %d: %w", i+1, err)
+		}
+	}
+
+	l.EndDate = l.ContractEndDate
+	if extensions := l.ExercisedExtensions(); len(extensions) > 0 {
+		l.EndDate = extensions[len(extensions)-1].EndDate
+	}
+	if termination, ok := l.ExercisedTermination(); ok {
+		l.EndDate = termination.EndDate
+	}
+
+	return l, nil
+}
+
+// validateOption checks that an option is consistent with the lease it belongs to.
+func validateOption(l lease.Lease, o lease.Option) error {
+	switch o.Type {
+	case lease.ExtensionOption:
+		if !o.EndDate.After(l.ContractEndDate) {
+			return fmt.Errorf("an extension must end after the contract end date %s", l.ContractEndDate.Format("2006-01-02"))
+		}
+		if o.Penalty != 0 {
+			return fmt.Errorf("only a termination option carries a penalty")
+		}
+		if o.PaymentAmount > 0 && l.PaymentFrequency == lease.Irregular {
+			return fmt.Errorf("a lease with an irregular payment schedule has no regular payment to set for an extension")
+		}
+	case lease.TerminationOption:
+		if !o.EndDate.After(l.StartDate) {
+			return fmt.Errorf("a termination must end the lease after the start date %s", l.StartDate.Format("2006-01-02"))
+		}
+		if o.PaymentAmount != 0 {
+			return fmt.Errorf("only an extension option sets a new payment")
+		}
+	default:
+		return fmt.Errorf("unsupported option type: %s", o.Type)
+	}
+
+	if o.PaymentAmount < 0 {
+		return fmt.Errorf("payment cannot be negative: %s", o.PaymentAmount)
+	}
+	if o.Penalty < 0 {
+		return fmt.Errorf("penalty cannot be negative: %s", o.Penalty)
+	}
+	if !o.ExerciseStart.IsZero() && !o.ExerciseEnd.IsZero() && o.ExerciseEnd.Before(o.ExerciseStart) {
+		return fmt.Errorf("exercise window closes on %s, before it opens", o.ExerciseEnd.Format("2006-01-02"))
+	}
+	if o.ExerciseEnd.After(o.EndDate) {
+		return fmt.Errorf("exercise window closes on %s, after the option's end date", o.ExerciseEnd.Format("2006-01-02"))
+	}
+	return nil
+}
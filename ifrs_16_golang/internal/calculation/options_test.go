@@ -0,0 +1,239 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"testing"
+	"time"
+)
+
+func TestLeaseTerm(t *testing.T) {
+	date := func(value string) time.Time { return mustParseDate(testDateLayout, value) }
+	extension := func(end string, certain bool) lease.Option {
+		return lease.Option{Type: lease.ExtensionOption, EndDate: date(end), ReasonablyCertain: certain}
+	}
+	termination := func(end string, certain bool) lease.Option {
+		return lease.Option{Type: lease.TerminationOption, EndDate: date(end), ReasonablyCertain: certain}
+	}
+
+	tests := []struct {
+		name    string
+		options []lease.Option
+		want    string
+		wantErr bool
+	}{
+		{"No options", nil, "2025-12-31", false},
+		{"Extension not reasonably certain", []lease.Option{extension("2027-12-31", false)}, "2025-12-31", false},
+		{"Extension reasonably certain", []lease.Option{extension("2027-12-31", true)}, "2027-12-31", false},
+		{"Second extension follows the first", []lease.Option{extension("2029-12-31", true), extension("2027-12-31", true)}, "2029-12-31", false},
+		{"Second extension needs the first", []lease.Option{extension("2027-12-31", false), extension("2029-12-31", true)}, "2025-12-31", false},
+		{"Termination reasonably certain", []lease.Option{termination("2024-12-31", true)}, "2024-12-31", false},
+		{"Termination not reasonably certain", []lease.Option{termination("2024-12-31", false)}, "2025-12-31", false},
+		{"Termination within an extension", []lease.Option{extension("2027-12-31", true), termination("2026-12-31", true)}, "2026-12-31", false},
+		{"Earliest termination", []lease.Option{termination("2025-06-30", true), termination("2024-12-31", true)}, "2024-12-31", false},
+		{"Extension ending before the contract", []lease.Option{extension("2025-06-30", true)}, "", true},
+		{"Termination before the start", []lease.Option{termination("2023-12-31", true)}, "", true},
+		{"Penalty on an extension", []lease.Option{{Type: lease.ExtensionOption, EndDate: date("2027-12-31"), Penalty: 100 * money.Unit}}, "", true},
+		{"Window closing after the end", []lease.Option{{Type: lease.TerminationOption, EndDate: date("2024-12-31"), ExerciseEnd: date("2025-01-31")}}, "", true},
+		{"Unknown type", []lease.Option{{Type: "Swap", EndDate: date("2027-12-31")}}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease("2024-01-01", "2025-12-31")
+			l.Options = tt.options
+			got, err := LeaseTerm(l)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LeaseTerm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if end := got.EndDate.Format(testDateLayout); end != tt.want {
+				t.Errorf("LeaseTerm() ends %s, want %s", end, tt.want)
+			}
+
+			// Deriving the term again gives the same term
+			again, err := LeaseTerm(got)
+			if err != nil || !again.EndDate.Equal(got.EndDate) {
+				t.Errorf("LeaseTerm() again = %s, %v; want %s", again.EndDate.Format(testDateLayout), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeaseTermPaymentsInLiability(t *testing.T) {
+	// A reasonably certain extension at a new rent is measured like a stepped rent
+	withExtension := testLease("2024-01-01", "2025-12-31")
+	withExtension.Options = []lease.Option{{
+		Type:              lease.ExtensionOption,
+		EndDate:           mustParseDate(testDateLayout, "2026-12-31"),
+		PaymentAmount:     1100 * money.Unit,
+		ReasonablyCertain: true,
+	}}
+	withExtension, err := LeaseTerm(withExtension)
+	if err != nil {
+		t.Fatalf("LeaseTerm() error = %v", err)
+	}
+	stepped := testLease("2024-01-01", "2026-12-31")
+	stepped.PaymentSteps = []lease.PaymentStep{{StartDate: mustParseDate(testDateLayout, "2026-01-01"), Amount: 1100 * money.Unit}}
+
+	got, err := CalculateLeaseLiability(withExtension)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	want, err := CalculateLeaseLiability(stepped)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("Liability with extension = %s, want %s", got, want)
+	}
+
+	// A reasonably certain termination shortens the term and adds its penalty
+	withTermination := testLease("2024-01-01", "2025-12-31")
+	withTermination.Options = []lease.Option{{
+		Type:              lease.TerminationOption,
+		EndDate:           mustParseDate(testDateLayout, "2024-12-31"),
+		Penalty:           3000 * money.Unit,
+		ReasonablyCertain: true,
+	}}
+	withTermination, err = LeaseTerm(withTermination)
+	if err != nil {
+		t.Fatalf("LeaseTerm() error = %v", err)
+	}
+	shortened := testLease("2024-01-01", "2024-12-31")
+	shortened.ExtraPayments = []lease.ExtraPayment{{Date: shortened.EndDate, Amount: 3000 * money.Unit, Type: lease.TerminationPenalty}}
+
+	got, err = CalculateLeaseLiability(withTermination)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	want, err = CalculateLeaseLiability(shortened)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("Liability with termination = %s, want %s", got, want)
+	}
+
+	schedule, err := GenerateLiabilitySchedule(withTermination, got)
+	if err != nil {
+		t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+	}
+	last := schedule[len(schedule)-1]
+	if last.Payment != 4000*money.Unit || last.ClosingBalance != 0 {
+		t.Errorf("Final entry = %+v, want the last rent and the penalty settling the liability", last)
+	}
+}
+
+func TestOptionReassessment(t *testing.T) {
+	extensionEnd := mustParseDate(testDateLayout, "2027-12-31")
+	l := testLease("2024-01-01", "2025-12-31")
+	l.Options = []lease.Option{{
+		Type:          lease.ExtensionOption,
+		ExerciseEnd:   mustParseDate(testDateLayout, "2025-06-30"),
+		EndDate:       extensionEnd,
+		PaymentAmount: 1200 * money.Unit,
+	}}
+	l, err := LeaseTerm(l)
+	if err != nil {
+		t.Fatalf("LeaseTerm() error = %v", err)
+	}
+	effective := mustParseDate(testDateLayout, "2025-03-01")
+	l.Modifications = []lease.Modification{{
+		Type:                    lease.OptionReassessment,
+		EffectiveDate:           effective,
+		RevisedDiscountRate:     0.07,
+		Option:                  1,
+		OptionReasonablyCertain: true,
+	}}
+
+	liability, err := CalculateLeaseLiability(l)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	result, err := ApplyModifications(l, liability, liability)
+	if err != nil {
+		t.Fatalf("ApplyModifications() error = %v", err)
+	}
+
+	if !result.Lease.EndDate.Equal(extensionEnd) || !result.Lease.Options[0].ReasonablyCertain {
+		t.Errorf("Lease term after reassessment ends %s, want %s", result.Lease.EndDate.Format(testDateLayout), extensionEnd.Format(testDateLayout))
+	}
+	if l.Options[0].ReasonablyCertain {
+		t.Errorf("Reassessment changed the options of the original lease")
+	}
+
+	// The remaining payments, including the extension, are discounted at the revised rate
+	extended := testLease("2024-01-01", "2027-12-31")
+	extended.DiscountRate = 0.07
+	extended.PaymentSteps = []lease.PaymentStep{{StartDate: mustParseDate(testDateLayout, "2026-01-01"), Amount: 1200 * money.Unit}}
+	want, err := remainingPresentValue(extended, effective)
+	if err != nil {
+		t.Fatalf("remainingPresentValue() error = %v", err)
+	}
+	r := result.Remeasurements[0]
+	if r.Reason != string(lease.OptionReassessment) || r.LiabilityAfter != want {
+		t.Errorf("Remeasurement = %+v, want %s liability after", r, want)
+	}
+	if r.RoUAssetAfter-r.RoUAssetBefore != r.LiabilityAfter-r.LiabilityBefore {
+		t.Errorf("RoU adjustment %s does not match liability remeasurement %s",
+			r.RoUAssetAfter-r.RoUAssetBefore, r.LiabilityAfter-r.LiabilityBefore)
+	}
+
+	lastLiability := result.LiabilitySchedule[len(result.LiabilitySchedule)-1]
+	lastRoU := result.RoUAssetSchedule[len(result.RoUAssetSchedule)-1]
+	if !lastLiability.Date.Equal(extensionEnd) || lastLiability.ClosingBalance != 0 {
+		t.Errorf("Liability closes at %s on %s, want 0 on %s", lastLiability.ClosingBalance, lastLiability.Date.Format(testDateLayout), extensionEnd.Format(testDateLayout))
+	}
+	if !lastRoU.Date.Equal(extensionEnd) || lastRoU.ClosingBalance != 0 {
+		t.Errorf("RoU asset closes at %s on %s, want 0 on %s", lastRoU.ClosingBalance, lastRoU.Date.Format(testDateLayout), extensionEnd.Format(testDateLayout))
+	}
+}
+
+func TestOptionReassessmentErrors(t *testing.T) {
+	reassessment := lease.Modification{
+		Type:                    lease.OptionReassessment,
+		EffectiveDate:           mustParseDate(testDateLayout, "2025-03-01"),
+		RevisedDiscountRate:     0.07,
+		Option:                  1,
+		OptionReasonablyCertain: true,
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*lease.Modification)
+	}{
+		{"No revised rate", func(m *lease.Modification) { m.RevisedDiscountRate = 0 }},
+		{"Unknown option", func(m *lease.Modification) { m.Option = 3 }},
+		{"Window closed", func(m *lease.Modification) { m.EffectiveDate = mustParseDate(testDateLayout, "2025-07-01") }},
+		{"Payment change", func(m *lease.Modification) { m.NewPaymentAmount = 900 * money.Unit }},
+		{"Termination date passed", func(m *lease.Modification) { m.Option = 2 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease("2024-01-01", "2025-12-31")
+			l.Options = []lease.Option{
+				{Type: lease.ExtensionOption, ExerciseEnd: mustParseDate(testDateLayout, "2025-06-30"), EndDate: mustParseDate(testDateLayout, "2027-12-31")},
+				{Type: lease.TerminationOption, EndDate: mustParseDate(testDateLayout, "2025-01-31")},
+			}
+			l, err := LeaseTerm(l)
+			if err != nil {
+				t.Fatalf("LeaseTerm() error = %v", err)
+			}
+			m := reassessment
+			tt.modify(&m)
+			l.Modifications = []lease.Modification{m}
+
+			liability, err := CalculateLeaseLiability(l)
+			if err != nil {
+				t.Fatalf("CalculateLeaseLiability() error = %v", err)
+			}
+			if _, err := ApplyModifications(l, liability, liability); err == nil {
+				t.Errorf("ApplyModifications() expected an error")
+			}
+		})
+	}
+}
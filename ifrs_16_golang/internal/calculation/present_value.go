@@ -4,14 +4,21 @@ import (
 	"errors"
 	"fmt"
 	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
 	"math"
+	"time"
 )
 
 // CalculateLeaseLiability calculates the initial lease liability based on IFRS 16.
-// It computes the present value of the lease payments.
-// Assumes payments are made at the end of each period.
-func CalculateLeaseLiability(l lease.Lease) (float64, error) {
-	if l.PaymentAmount <= 0 {
+// It computes the present value of the lease payments not yet paid at the commencement date,
+// discounting them payment by payment.
+// Payments are made at the end of each period unless the lease pays in advance, in which
+// case the payment due on the commencement date is left to the RoU asset (IFRS 16.24(b)).
+// An irregular payment calendar is discounted on exact day counts over a 365-day year, which
+// matches a spreadsheet XNPV of the same cash flows dated from commencement. The present
+// value is rounded to the cent once, after discounting.
+func CalculateLeaseLiability(l lease.Lease) (money.Amount, error) {
+	if l.PaymentAmount <= 0 && l.PaymentFrequency != lease.Irregular {
 		return 0, errors.New("payment amount must be positive")
 	}
 	if l.DiscountRate <= 0 {
@@ -28,78 +35,88 @@ func CalculateLeaseLiability(l lease.Lease) (float64, error) {
 
 	if periods == 0 {
 		// A lease with zero calculated periods should have zero liability.
-		// Return 0.0 and no error.
-		return 0.0, nil
+		// Return 0 and no error.
+		return 0, nil
 	}
 
-	// Enhanced PV calculation for more precision
-	// This uses a standard formula for calculating present value of regular payments
-	presentValue := 0.0
-
-	// Calculate the annuity formula (for payments at the end of each period)
-	// PV = PMT * [1 - (1+r)^-n] / r
-	if periodicRate > 0 {
-		// Standard formula for payments at the end of the period
-		presentValue = l.PaymentAmount * ((1 - math.Pow(1+periodicRate, float64(-periods))) / periodicRate)
-	} else {
-		// Fallback to simple sum if rate is effectively zero (though we check for this earlier)
-		presentValue = l.PaymentAmount * float64(periods)
+	// Each payment is discounted individually at the periodic rate, positioned by how far
+	// into the payment cycle it falls, so stepped, escalating and rent-free schedules are
+	// measured exactly. Payments in arrears fall at the end of periods 1..n; payments in
+	// advance at the start of each period, the one on commencement being left to the RoU
+	// asset. Fixed extra payments inside the term are included; variable payments are
+	// expensed as incurred and stay out of the liability.
+	flows, _, _, err := liabilityCashFlows(l)
+	if err != nil {
+		return 0, err
 	}
 
-	// Round to minimize floating point imprecision (to 6 decimal places)
-	presentValue = roundToDecimalPlaces(presentValue, 6)
-
-	// Change to 2 decimal places as financial calculations typically use currency precision
-	presentValue = roundToDecimalPlaces(presentValue, 2)
-
-	return presentValue, nil
-}
+	presentValue := 0.0
+	for _, flow := range flows {
+		presentValue += flow.amount.Float64() * math.Pow(1+periodicRate, -flow.position)
+	}
 
-// roundToDecimalPlaces rounds a float64 to the specified number of decimal places
-func roundToDecimalPlaces(value float64, places int) float64 {
-	multiplier := math.Pow10(places)
-	return math.Round(value*multiplier) / multiplier
+	// Round to minimize floating point imprecision (to 6 decimal places) before booking to the cent
+	return money.FromFloat(math.Round(presentValue*1e6) / 1e6), nil
 }
 
 // getPeriodsAndRate calculates the number of payment periods and the periodic discount rate.
 func getPeriodsAndRate(l lease.Lease) (int, float64, error) {
-	var periodsPerYear int
-	var monthsPerPeriod int // Added for date stepping
-
-	switch l.PaymentFrequency {
-	case lease.Monthly:
-		periodsPerYear = 12
-		monthsPerPeriod = 1
-	case lease.Quarterly:
-		periodsPerYear = 4
-		monthsPerPeriod = 3
-	case lease.Annually:
-		periodsPerYear = 1
-		monthsPerPeriod = 12
-	default:
-		return 0, 0, fmt.Errorf("unsupported payment frequency: %s", l.PaymentFrequency)
+	cycle, err := paymentCycleFor(l)
+	if err != nil {
+		return 0, 0, err
 	}
 
 	if l.DiscountRate <= 0 {
 		return 0, 0, fmt.Errorf("discount rate must be positive")
 	}
-	periodicRate := l.DiscountRate / float64(periodsPerYear)
+	if _, err := dayCountFor(l); err != nil {
+		return 0, 0, err
+	}
+	periodicRate, err := periodicRateFor(l, cycle)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	periods, err := paymentPeriods(l)
+	if err != nil {
+		return 0, 0, err
+	}
+	return periods, periodicRate, nil
+}
+
+// paymentPeriods calculates the number of payment periods of a lease, or the number of
+// scheduled payments after commencement on an irregular calendar. It needs no discount rate.
+func paymentPeriods(l lease.Lease) (int, error) {
+	cycle, err := paymentCycleFor(l)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := dayCountFor(l); err != nil {
+		return 0, err
+	}
+
+	if cycle.exact {
+		return scheduledPaymentCount(l)
+	}
 
 	// --- Accurate Period Calculation (Attempt 13) ---
 
 	// Explicit check for zero duration or leases shorter than one full period.
 	// Calculate the end date of the very first potential period.
-	firstPeriodEndDate := l.StartDate.AddDate(0, monthsPerPeriod, 0)
+	firstPeriodEndDate := cycle.date(l.StartDate, 1)
 
 	// If the lease ends strictly *before* the first period would have ended,
 	// then zero payment periods occur.
 	if l.EndDate.Before(firstPeriodEndDate) {
 		// Also explicitly handle zero-duration lease (start==end)
 		if l.EndDate.Equal(l.StartDate) {
-			return 0, periodicRate, nil
+			return 0, nil
+		}
+		// Otherwise it's just shorter than one period. A lease paid in arrears never
+		// reaches a payment date, but one paid in advance still pays on commencement.
+		if !l.PaysInAdvance() {
+			return 0, nil
 		}
-		// Otherwise it's just shorter than one period.
-		return 0, periodicRate, nil
 	}
 
 	// If the lease term is at least one period long, count the intervals.
@@ -109,24 +126,143 @@ func getPeriodsAndRate(l lease.Lease) (int, float64, error) {
 	// This counts the number of intervals starting before the EndDate.
 	for current.Before(l.EndDate) {
 		periodCount++
-		// Move to the start of the next interval.
-		current = current.AddDate(0, monthsPerPeriod, 0)
+		// Move to the start of the next interval. Stepping from the start date avoids
+		// month-end drift (e.g. Jan 31 -> Feb 29 -> Mar 29).
+		current = cycle.date(l.StartDate, periodCount)
 
 		// Safety break
 		if periodCount > 12000 {
-			return 0, 0, fmt.Errorf("period calculation safety limit exceeded (12000)")
+			return 0, fmt.Errorf("period calculation safety limit exceeded (12000)")
 		}
 	}
 	// --- End Accurate Period Calculation ---
 
-	return periodCount, periodicRate, nil
+	return periodCount, nil
 }
 
-// addPeriods is a helper placeholder - needs proper implementation
-// --> This specific helper might not be needed anymore if date stepping is done within schedule funcs.
-// func addPeriods(startDate time.Time, freq lease.PaymentFrequency, numPeriods int) time.Time {
-//     // Placeholder - requires careful implementation based on frequency
-//     // e.g., for monthly, add numPeriods months; for quarterly, add 3*numPeriods months, etc.
-//     // Need to handle date arithmetic correctly (e.g., month ends).
-//     return startDate // Replace with actual calculation
-// }
+// scheduledPaymentCount validates the payment calendar of an irregular lease and counts the
+// payments falling due after commencement, which are the ones in the lease liability.
+func scheduledPaymentCount(l lease.Lease) (int, error) {
+	if len(l.PaymentSchedule) == 0 {
+		return 0, fmt.Errorf("payment frequency %s requires a payment schedule", l.PaymentFrequency)
+	}
+
+	count := 0
+	for _, scheduled := range l.PaymentSchedule {
+		if scheduled.Amount < 0 {
+			return 0, fmt.Errorf("scheduled payment on %s cannot be negative: %s",
+				scheduled.Date.Format("2006-01-02"), scheduled.Amount)
+		}
+		if scheduled.Date.After(l.EndDate) {
+			return 0, fmt.Errorf("scheduled payment on %s falls after the end date %s",
+				scheduled.Date.Format("2006-01-02"), l.EndDate.Format("2006-01-02"))
+		}
+		if scheduled.Date.After(l.StartDate) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// regularPayment returns the amount of the i-th regular payment. In arrears it settles the
+// period ending on its date; in advance the period starting on it.
+func regularPayment(l lease.Lease, cycle paymentCycle, i int) money.Amount {
+	periodIndex := i - 1
+	if l.PaysInAdvance() {
+		periodIndex = i
+	}
+	return l.PaymentForPeriod(cycle.date(l.StartDate, periodIndex))
+}
+
+// paymentCycle is the interval between regular payments, in calendar months or in days.
+// An exact cycle has no regular interval: payments follow the lease's payment schedule and
+// are positioned in years of 365 days, so the periodic rate is the annual rate.
+type paymentCycle struct {
+	months int
+	days   int
+	exact  bool
+}
+
+// date returns the boundary k cycles after start. Stepping from the start date rather than
+// from the previous boundary avoids month-end drift.
+func (c paymentCycle) date(start time.Time, k int) time.Time {
+	return start.AddDate(0, k*c.months, k*c.days)
+}
+
+// periodsPerYear is the number of payment periods the annual discount rate is spread over.
+// Weekly and fortnightly cycles use a 52-week year.
+func (c paymentCycle) periodsPerYear() float64 {
+	if c.exact {
+		return 1
+	}
+	if c.days > 0 {
+		return 52 * 7 / float64(c.days)
+	}
+	return 12 / float64(c.months)
+}
+
+// paymentCycleFor returns the interval between the regular payments of a lease.
+func paymentCycleFor(l lease.Lease) (paymentCycle, error) {
+	switch l.PaymentFrequency {
+	case lease.Weekly:
+		return paymentCycle{days: 7}, nil
+	case lease.Fortnightly:
+		return paymentCycle{days: 14}, nil
+	case lease.Monthly:
+		return paymentCycle{months: 1}, nil
+	case lease.BiMonthly:
+		return paymentCycle{months: 2}, nil
+	case lease.Quarterly:
+		return paymentCycle{months: 3}, nil
+	case lease.SemiAnnually:
+		return paymentCycle{months: 6}, nil
+	case lease.Annually:
+		return paymentCycle{months: 12}, nil
+	case lease.EveryNMonths:
+		if l.PaymentIntervalMonths <= 0 {
+			return paymentCycle{}, fmt.Errorf("payment interval must be at least one month for frequency %s", l.PaymentFrequency)
+		}
+		return paymentCycle{months: l.PaymentIntervalMonths}, nil
+	case lease.Irregular:
+		return paymentCycle{exact: true}, nil
+	default:
+		return paymentCycle{}, fmt.Errorf("unsupported payment frequency: %s", l.PaymentFrequency)
+	}
+}
+
+// periodPosition expresses a date as a number of payment periods elapsed since commencement.
+//
+// Under Actual/Actual, whole periods are counted on the payment cycle and the remainder is
+// the fraction of days elapsed in the current period. Dates on or after EndDate map to the
+// final period boundary, so the last regular payment sits exactly at position `periods` even
+// when its date is clamped to EndDate. Under the other conventions, and on an exact cycle,
+// the position is the year fraction elapsed times the periods per year.
+func periodPosition(l lease.Lease, cycle paymentCycle, periods int, date time.Time) float64 {
+	dayCount, _ := dayCountFor(l) // Validated by paymentPeriods
+	if cycle.exact || dayCount != lease.ActualActual {
+		if date.After(l.EndDate) {
+			date = l.EndDate
+		}
+		if !date.After(l.StartDate) {
+			return 0
+		}
+		return yearFraction(dayCount, l.StartDate, date) * cycle.periodsPerYear()
+	}
+	if !date.Before(l.EndDate) {
+		return float64(periods)
+	}
+	if !date.After(l.StartDate) {
+		return 0
+	}
+
+	k := 0
+	for !cycle.date(l.StartDate, k+1).After(date) {
+		k++
+	}
+	periodStart := cycle.date(l.StartDate, k)
+	periodEnd := cycle.date(l.StartDate, k+1)
+
+	position := float64(k) + date.Sub(periodStart).Hours()/periodEnd.Sub(periodStart).Hours()
+	return math.Min(position, float64(periods))
+}
@@ -3,6 +3,7 @@ package calculation
 import (
 	"fmt"
 	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
 	"math"
 	"testing"
 	"time"
@@ -19,6 +20,19 @@ func mustParseDate(layout, value string) time.Time {
 
 var testDateLayout = "2006-01-02"
 
+// testLease returns a lease of 1,000 a month in arrears at 5% from start to end, which tests
+// adjust to the case at hand.
+func testLease(start, end string) lease.Lease {
+	return lease.Lease{
+		ID:               "TestLease",
+		StartDate:        mustParseDate(testDateLayout, start),
+		EndDate:          mustParseDate(testDateLayout, end),
+		PaymentAmount:    1000 * money.Unit,
+		PaymentFrequency: lease.Monthly,
+		DiscountRate:     0.05,
+	}
+}
+
 func TestGetPeriodsAndRate(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -92,13 +106,99 @@ func TestGetPeriodsAndRate(t *testing.T) {
 			lease: lease.Lease{
 				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
 				EndDate:          mustParseDate(testDateLayout, "2024-12-31"),
-				PaymentFrequency: "Weekly",
+				PaymentFrequency: "Daily",
 				DiscountRate:     0.05,
 			},
 			expectedPeriods: 0,
 			expectedRate:    0,
 			expectError:     true,
 		},
+		{
+			name: "Short Lease In Advance - One payment on commencement",
+			lease: lease.Lease{
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2024-01-20"),
+				PaymentFrequency: lease.Monthly,
+				PaymentTiming:    lease.Advance,
+				DiscountRate:     0.05,
+			},
+			expectedPeriods: 1,
+			expectedRate:    0.05 / 12,
+			expectError:     false,
+		},
+		{
+			name: "Weekly 1 Year",
+			lease: lease.Lease{
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2024-12-29"), // 52 weeks
+				PaymentFrequency: lease.Weekly,
+				DiscountRate:     0.05,
+			},
+			expectedPeriods: 52,
+			expectedRate:    0.05 / 52,
+			expectError:     false,
+		},
+		{
+			name: "Fortnightly 1 Year",
+			lease: lease.Lease{
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2024-12-29"),
+				PaymentFrequency: lease.Fortnightly,
+				DiscountRate:     0.05,
+			},
+			expectedPeriods: 26,
+			expectedRate:    0.05 / 26,
+			expectError:     false,
+		},
+		{
+			name: "BiMonthly 1 Year",
+			lease: lease.Lease{
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2024-12-31"),
+				PaymentFrequency: lease.BiMonthly,
+				DiscountRate:     0.06,
+			},
+			expectedPeriods: 6,
+			expectedRate:    0.06 / 6,
+			expectError:     false,
+		},
+		{
+			name: "SemiAnnually 3 Years",
+			lease: lease.Lease{
+				StartDate:        mustParseDate(testDateLayout, "2024-07-01"),
+				EndDate:          mustParseDate(testDateLayout, "2027-06-30"),
+				PaymentFrequency: lease.SemiAnnually,
+				DiscountRate:     0.06,
+			},
+			expectedPeriods: 6,
+			expectedRate:    0.03,
+			expectError:     false,
+		},
+		{
+			name: "Every 4 Months 2 Years",
+			lease: lease.Lease{
+				StartDate:             mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:               mustParseDate(testDateLayout, "2025-12-31"),
+				PaymentFrequency:      lease.EveryNMonths,
+				PaymentIntervalMonths: 4,
+				DiscountRate:          0.06,
+			},
+			expectedPeriods: 6,
+			expectedRate:    0.02,
+			expectError:     false,
+		},
+		{
+			name: "Every N Months Without Interval",
+			lease: lease.Lease{
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2025-12-31"),
+				PaymentFrequency: lease.EveryNMonths,
+				DiscountRate:     0.06,
+			},
+			expectedPeriods: 0,
+			expectedRate:    0,
+			expectError:     true,
+		},
 		// TODO: Add more edge cases (e.g., end date = start date, leap years?)
 	}
 
@@ -122,8 +222,6 @@ func TestGetPeriodsAndRate(t *testing.T) {
 }
 
 func TestCalculateLeaseLiability(t *testing.T) {
-	const tolerance = 1e-6 // Tolerance for comparing float results
-
 	tests := []struct {
 		name        string
 		lease       lease.Lease
@@ -136,7 +234,7 @@ func TestCalculateLeaseLiability(t *testing.T) {
 				ID:               "L001",
 				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
 				EndDate:          mustParseDate(testDateLayout, "2024-12-31"),
-				PaymentAmount:    1000,
+				PaymentAmount:    1000 * money.Unit,
 				PaymentFrequency: lease.Monthly,
 				DiscountRate:     0.05, // 5% annual
 			},
@@ -149,7 +247,7 @@ func TestCalculateLeaseLiability(t *testing.T) {
 				ID:               "L002",
 				StartDate:        mustParseDate(testDateLayout, "2024-01-15"),
 				EndDate:          mustParseDate(testDateLayout, "2026-01-14"),
-				PaymentAmount:    5000,
+				PaymentAmount:    5000 * money.Unit,
 				PaymentFrequency: lease.Quarterly,
 				DiscountRate:     0.08, // 8% annual
 			},
@@ -162,7 +260,7 @@ func TestCalculateLeaseLiability(t *testing.T) {
 				ID:               "L003",
 				StartDate:        mustParseDate(testDateLayout, "2024-03-01"),
 				EndDate:          mustParseDate(testDateLayout, "2027-02-28"),
-				PaymentAmount:    20000,
+				PaymentAmount:    20000 * money.Unit,
 				PaymentFrequency: lease.Annually,
 				DiscountRate:     0.06, // 6% annual
 			},
@@ -195,7 +293,7 @@ func TestCalculateLeaseLiability(t *testing.T) {
 			lease: lease.Lease{
 				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
 				EndDate:          mustParseDate(testDateLayout, "2024-12-31"),
-				PaymentAmount:    1000,
+				PaymentAmount:    1000 * money.Unit,
 				PaymentFrequency: lease.Monthly,
 				DiscountRate:     -0.05,
 			},
@@ -208,15 +306,157 @@ func TestCalculateLeaseLiability(t *testing.T) {
 				ID:               "L004",
 				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
 				EndDate:          mustParseDate(testDateLayout, "2024-01-20"), // Less than a month
-				PaymentAmount:    1000,
+				PaymentAmount:    1000 * money.Unit,
 				PaymentFrequency: lease.Monthly,
 				DiscountRate:     0.05,
 			},
 			expectedPV:  0.00, // No periods means PV of 0
 			expectError: false,
 		},
+		{
+			name: "Monthly 1 Year In Advance",
+			lease: lease.Lease{
+				ID:               "L005",
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2024-12-31"),
+				PaymentAmount:    1000 * money.Unit,
+				PaymentFrequency: lease.Monthly,
+				PaymentTiming:    lease.Advance,
+				DiscountRate:     0.05,
+			},
+			expectedPV:  10729.89, // 11 remaining payments; the first is paid on commencement
+			expectError: false,
+		},
+		{
+			name: "Quarterly 2 Years In Advance",
+			lease: lease.Lease{
+				ID:               "L006",
+				StartDate:        mustParseDate(testDateLayout, "2024-01-15"),
+				EndDate:          mustParseDate(testDateLayout, "2026-01-14"),
+				PaymentAmount:    5000 * money.Unit,
+				PaymentFrequency: lease.Quarterly,
+				PaymentTiming:    lease.Advance,
+				DiscountRate:     0.08,
+			},
+			expectedPV:  32359.96,
+			expectError: false,
+		},
+		{
+			name: "Short Lease In Advance - Paid entirely on commencement",
+			lease: lease.Lease{
+				ID:               "L007",
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2024-01-20"),
+				PaymentAmount:    1000 * money.Unit,
+				PaymentFrequency: lease.Monthly,
+				PaymentTiming:    lease.Advance,
+				DiscountRate:     0.05,
+			},
+			expectedPV:  0.00,
+			expectError: false,
+		},
+		{
+			name: "Monthly 3 Years Escalating 3% Annually",
+			lease: lease.Lease{
+				ID:               "L008",
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2026-12-31"),
+				PaymentAmount:    1000 * money.Unit,
+				PaymentFrequency: lease.Monthly,
+				DiscountRate:     0.05,
+				EscalationRate:   0.03,
+			},
+			expectedPV:  34342.90, // 12 x 1000, 12 x 1030, 12 x 1060.90
+			expectError: false,
+		},
+		{
+			name: "Monthly 1 Year With 3 Rent-Free Months",
+			lease: lease.Lease{
+				ID:               "L009",
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2024-12-31"),
+				PaymentAmount:    1000 * money.Unit,
+				PaymentFrequency: lease.Monthly,
+				DiscountRate:     0.05,
+				RentFreeMonths:   3,
+			},
+			expectedPV:  8706.05, // Payments 4..12 only
+			expectError: false,
+		},
+		{
+			name: "Monthly 1 Year In Advance With 3 Rent-Free Months",
+			lease: lease.Lease{
+				ID:               "L010",
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2024-12-31"),
+				PaymentAmount:    1000 * money.Unit,
+				PaymentFrequency: lease.Monthly,
+				PaymentTiming:    lease.Advance,
+				DiscountRate:     0.05,
+				RentFreeMonths:   3,
+			},
+			expectedPV:  8742.32, // Payments at positions 3..11
+			expectError: false,
+		},
+		{
+			name: "Monthly 2 Years Stepped Rent",
+			lease: lease.Lease{
+				ID:               "L011",
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2025-12-31"),
+				PaymentAmount:    1000 * money.Unit,
+				PaymentFrequency: lease.Monthly,
+				DiscountRate:     0.05,
+				PaymentSteps: []lease.PaymentStep{
+					{StartDate: mustParseDate(testDateLayout, "2025-01-01"), Amount: 1100 * money.Unit},
+				},
+			},
+			expectedPV:  23905.17,
+			expectError: false,
+		},
+		{
+			name: "Irregular Milestone Payments",
+			lease: lease.Lease{
+				ID:               "L012",
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2025-06-30"),
+				PaymentFrequency: lease.Irregular,
+				DiscountRate:     0.08,
+				PaymentSchedule: []lease.ScheduledPayment{
+					{Date: mustParseDate(testDateLayout, "2024-03-15"), Amount: 5000 * money.Unit},
+					{Date: mustParseDate(testDateLayout, "2024-09-30"), Amount: 12000 * money.Unit},
+					{Date: mustParseDate(testDateLayout, "2025-06-30"), Amount: 20000 * money.Unit},
+				},
+			},
+			expectedPV:  34076.43, // XNPV(8%, {0, 5000, 12000, 20000}, {start, ...dates})
+			expectError: false,
+		},
+		{
+			name: "Irregular Without Payment Schedule",
+			lease: lease.Lease{
+				ID:               "L013",
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2025-06-30"),
+				PaymentFrequency: lease.Irregular,
+				DiscountRate:     0.08,
+			},
+			expectError: true,
+		},
+		{
+			name: "Irregular Payment After End Date",
+			lease: lease.Lease{
+				ID:               "L014",
+				StartDate:        mustParseDate(testDateLayout, "2024-01-01"),
+				EndDate:          mustParseDate(testDateLayout, "2024-12-31"),
+				PaymentFrequency: lease.Irregular,
+				DiscountRate:     0.08,
+				PaymentSchedule: []lease.ScheduledPayment{
+					{Date: mustParseDate(testDateLayout, "2025-01-15"), Amount: 5000 * money.Unit},
+				},
+			},
+			expectError: true,
+		},
 		// TODO: Add tests for leases spanning leap years if precision requires it.
-		// TODO: Add tests assuming payments at beginning of period (requires calc logic change)
 	}
 
 	for _, tt := range tests {
@@ -228,8 +468,8 @@ func TestCalculateLeaseLiability(t *testing.T) {
 				return
 			}
 			if !tt.expectError {
-				if math.Abs(pv-tt.expectedPV) > tolerance {
-					t.Errorf("CalculateLeaseLiability() PV = %v, want %v", pv, tt.expectedPV)
+				if pv != money.FromFloat(tt.expectedPV) {
+					t.Errorf("CalculateLeaseLiability() PV = %s, want %.2f", pv, tt.expectedPV)
 				}
 			}
 		})
@@ -0,0 +1,107 @@
+package calculation
+
+import (
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"math"
+	"time"
+)
+
+// daysPerYear is the day basis used to unwind the restoration provision.
+const daysPerYear = 365.0
+
+// CalculateRestorationProvision measures the IAS 37 restoration (make-good) provision at the
+// commencement date. The cost expected at the end of the lease term is discounted at the
+// lease's pre-tax restoration rate; without a rate the cost is taken as already discounted.
+func CalculateRestorationProvision(l lease.Lease) (money.Amount, error) {
+	if l.RestorationCost < 0 {
+		return 0, fmt.Errorf("restoration cost cannot be negative: %s", l.RestorationCost)
+	}
+	if l.RestorationDiscountRate < 0 {
+		return 0, fmt.Errorf("restoration discount rate cannot be negative: %.4f", l.RestorationDiscountRate)
+	}
+	if l.RestorationCost == 0 || l.RestorationDiscountRate == 0 {
+		return l.RestorationCost, nil
+	}
+	if l.EndDate.Before(l.StartDate) {
+		return 0, fmt.Errorf("invalid start or end date")
+	}
+
+	years := l.EndDate.Sub(l.StartDate).Hours() / 24 / daysPerYear
+	return l.RestorationCost.Mul(1 / math.Pow(1+l.RestorationDiscountRate, years)), nil
+}
+
+// GenerateRestorationProvisionSchedule creates the unwinding schedule for the restoration
+// provision. The discount unwinds daily through finance cost (reported as InterestExpense)
+// so that the provision reaches the expected restoration cost at the end of the lease term.
+// The unwinding is booked to the cent each day, carrying the rounding difference forward.
+func GenerateRestorationProvisionSchedule(l lease.Lease, initialProvision money.Amount) ([]AmortizationEntry, error) {
+	if initialProvision < 0 {
+		return nil, fmt.Errorf("initial restoration provision cannot be negative: %s", initialProvision)
+	}
+	if initialProvision == 0 {
+		return []AmortizationEntry{}, nil
+	}
+
+	return restorationScheduleFrom(l, initialProvision, l.StartDate, 1), nil
+}
+
+// restorationProvisionAt measures the restoration provision at the close of the day before
+// date: the cost expected at the end of the lease term discounted over the days left to unwind.
+func restorationProvisionAt(l lease.Lease, date time.Time) money.Amount {
+	if l.RestorationCost == 0 || l.RestorationDiscountRate <= 0 {
+		return l.RestorationCost
+	}
+	years := (daysBetween(date, l.EndDate) + 1) / daysPerYear
+	return l.RestorationCost.Mul(1 / math.Pow(1+l.RestorationDiscountRate, years))
+}
+
+// restorationScheduleFrom unwinds the restoration provision daily from an opening balance on
+// from to the end of the lease term, numbering the entries from period. Nothing unwinds on the
+// commencement date, when the provision is recognised.
+func restorationScheduleFrom(l lease.Lease, openingBalance money.Amount, from time.Time, period int) []AmortizationEntry {
+	totalDays := int(daysBetween(from, l.EndDate)) + 1
+	if totalDays <= 0 {
+		return []AmortizationEntry{}
+	}
+
+	// Without a discount rate there is nothing to unwind and the provision stays flat.
+	dailyFactor := math.Pow(1+l.RestorationDiscountRate, 1/daysPerYear) - 1
+
+	schedule := make([]AmortizationEntry, 0, totalDays)
+	var accrual money.Accrual
+	currentDate := from
+
+	for day := 1; day <= totalDays; day++ {
+		unwinding := money.Amount(0)
+		if !currentDate.Equal(l.StartDate) {
+			unwinding = accrual.Book(openingBalance.Float64() * dailyFactor)
+		}
+
+		// The provision is measured to the cent, so the final day absorbs a rounding
+		// difference of a cent to the expected restoration cost.
+		if day == totalDays && l.RestorationDiscountRate > 0 {
+			residual := l.RestorationCost - (openingBalance + unwinding)
+			if residual.Abs() <= money.Cent {
+				unwinding += residual
+			}
+		}
+
+		closingBalance := openingBalance + unwinding
+
+		entry := AmortizationEntry{
+			Period:          period + day - 1,
+			Date:            currentDate,
+			OpeningBalance:  openingBalance,
+			InterestExpense: unwinding,
+			ClosingBalance:  closingBalance,
+		}
+		schedule = append(schedule, entry)
+
+		openingBalance = closingBalance
+		currentDate = currentDate.AddDate(0, 0, 1) // Move to next day
+	}
+
+	return schedule
+}
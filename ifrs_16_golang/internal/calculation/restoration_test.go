@@ -0,0 +1,116 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"testing"
+	"time"
+)
+
+func TestCalculateRestorationProvision(t *testing.T) {
+	tests := []struct {
+		name              string
+		lease             lease.Lease
+		expectedProvision float64
+		expectError       bool
+	}{
+		{
+			name: "Discounted at the pre-tax rate",
+			lease: lease.Lease{
+				StartDate:               time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:                 time.Date(2028, 12, 30, 0, 0, 0, 0, time.UTC), // 1825 days
+				RestorationCost:         50000 * money.Unit,
+				RestorationDiscountRate: 0.04,
+			},
+			expectedProvision: 41096.36, // 50000 / 1.04^5
+		},
+		{
+			name: "No rate means the cost is already discounted",
+			lease: lease.Lease{
+				StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:         time.Date(2028, 12, 30, 0, 0, 0, 0, time.UTC),
+				RestorationCost: 40000 * money.Unit,
+			},
+			expectedProvision: 40000,
+		},
+		{
+			name: "No restoration obligation",
+			lease: lease.Lease{
+				StartDate:               time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:                 time.Date(2028, 12, 30, 0, 0, 0, 0, time.UTC),
+				RestorationDiscountRate: 0.04,
+			},
+			expectedProvision: 0,
+		},
+		{
+			name: "Negative rate",
+			lease: lease.Lease{
+				StartDate:               time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:                 time.Date(2028, 12, 30, 0, 0, 0, 0, time.UTC),
+				RestorationCost:         50000 * money.Unit,
+				RestorationDiscountRate: -0.04,
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provision, err := CalculateRestorationProvision(tt.lease)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("CalculateRestorationProvision() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && provision != money.FromFloat(tt.expectedProvision) {
+				t.Errorf("CalculateRestorationProvision() = %s, want %.2f", provision, tt.expectedProvision)
+			}
+		})
+	}
+}
+
+func TestGenerateRestorationProvisionSchedule(t *testing.T) {
+	l := lease.Lease{
+		ID:                      "L-ARO",
+		StartDate:               time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:                 time.Date(2028, 12, 30, 0, 0, 0, 0, time.UTC),
+		PaymentAmount:           5000 * money.Unit,
+		PaymentFrequency:        lease.Monthly,
+		DiscountRate:            0.06,
+		RestorationCost:         50000 * money.Unit,
+		RestorationDiscountRate: 0.04,
+	}
+
+	provision, err := CalculateRestorationProvision(l)
+	if err != nil {
+		t.Fatalf("CalculateRestorationProvision() error = %v", err)
+	}
+
+	schedule, err := GenerateRestorationProvisionSchedule(l, provision)
+	if err != nil {
+		t.Fatalf("GenerateRestorationProvisionSchedule() error = %v", err)
+	}
+
+	if schedule[0].OpeningBalance != provision || schedule[0].InterestExpense != 0 {
+		t.Errorf("First entry = %+v, want opening %s with no unwinding on commencement", schedule[0], provision)
+	}
+
+	lastEntry := schedule[len(schedule)-1]
+	if lastEntry.ClosingBalance != l.RestorationCost {
+		t.Errorf("Last Entry ClosingBalance = %s, want %s", lastEntry.ClosingBalance, l.RestorationCost)
+	}
+
+	// The unwinding over a period is reported as finance cost in the period summary
+	result := CalculationResult{RestorationSchedule: schedule}
+	if err := CalculateAccountingPeriodSummary(&result, "2025-01-01", "2025-12-31"); err != nil {
+		t.Fatalf("CalculateAccountingPeriodSummary() error = %v", err)
+	}
+	if result.PeriodProvisionStart <= provision || result.PeriodProvisionEnd <= result.PeriodProvisionStart {
+		t.Errorf("Period provision balances = %s -> %s, want growth from %s", result.PeriodProvisionStart, result.PeriodProvisionEnd, provision)
+	}
+	if result.PeriodProvisionUnwinding != result.PeriodProvisionEnd-result.PeriodProvisionStart {
+		t.Errorf("PeriodProvisionUnwinding = %s, want %s", result.PeriodProvisionUnwinding, result.PeriodProvisionEnd-result.PeriodProvisionStart)
+	}
+
+	if _, err := GenerateRestorationProvisionSchedule(l, -1); err == nil {
+		t.Error("Expected error for negative initial provision, got nil")
+	}
+}
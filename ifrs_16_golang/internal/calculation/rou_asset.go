@@ -1,9 +1,25 @@
 package calculation
 
 import (
+	"fmt"
 	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"time"
 )
 
+// RoUAssetComponents breaks the initial Right-of-Use asset down into its IFRS 16.24 components.
+type RoUAssetComponents struct {
+	LeaseLiability         money.Amount `json:"leaseLiability"`         // (a) Initial measurement of the lease liability
+	PaymentsAtCommencement money.Amount `json:"paymentsAtCommencement"` // (b) Lease payments made at or before commencement
+	LeaseIncentives        money.Amount `json:"leaseIncentives"`        // (b) Less lease incentives received
+	InitialDirectCosts     money.Amount `json:"initialDirectCosts"`     // (c) Initial direct costs incurred by the lessee
+	RestorationCosts       money.Amount `json:"restorationCosts"`       // (d) Restoration provision recognised under IAS 37
+	Total                  money.Amount `json:"total"`                  // Initial RoU asset
+	// The RoU asset of a leaseback is measured instead at the part of the carrying amount of the
+	// asset sold that relates to the rights retained, in place of (a) and (b) (IFRS 16.100(a)).
+	RetainedCarryingAmount money.Amount `json:"retainedCarryingAmount,omitempty"`
+}
+
 // CalculateInitialRoUAsset calculates the initial value of the Right-of-Use asset.
 //
 // According to IFRS 16, the RoU asset initially comprises:
@@ -12,17 +28,105 @@ import (
 // 3. Initial direct costs incurred by the lessee.
 // 4. Estimated costs of dismantling/removing the asset (Asset Retirement Obligation).
 //
-// This initial implementation only includes component 1.
-// TODO: Enhance this function to include components 2, 3, and 4 by adding relevant fields to the lease.Lease struct.
-func CalculateInitialRoUAsset(leaseLiability float64, l lease.Lease) (float64, error) {
-	// Basic calculation: RoU Asset = Initial Lease Liability
-	rouAsset := leaseLiability
-
-	// TODO: Add adjustments here:
-	// + Payments made at/before commencement
-	// - Lease incentives received
-	// + Initial direct costs
-	// + Estimated dismantling costs
-
-	return rouAsset, nil
+// See CalculateInitialRoUAssetComponents for the amount of each component.
+func CalculateInitialRoUAsset(leaseLiability money.Amount, l lease.Lease) (money.Amount, error) {
+	components, err := CalculateInitialRoUAssetComponents(leaseLiability, l)
+	if err != nil {
+		return 0, err
+	}
+	return components.Total, nil
+}
+
+// CalculateInitialRoUAssetComponents measures the Right-of-Use asset from the four
+// IFRS 16.24 components and returns each of them alongside the total.
+func CalculateInitialRoUAssetComponents(leaseLiability money.Amount, l lease.Lease) (RoUAssetComponents, error) {
+	if l.InitialDirectCost < 0 {
+		return RoUAssetComponents{}, fmt.Errorf("initial direct cost cannot be negative: %s", l.InitialDirectCost)
+	}
+	if l.LeaseIncentives < 0 {
+		return RoUAssetComponents{}, fmt.Errorf("lease incentives cannot be negative: %s", l.LeaseIncentives)
+	}
+	if l.PrepaidRent < 0 {
+		return RoUAssetComponents{}, fmt.Errorf("prepaid rent cannot be negative: %s", l.PrepaidRent)
+	}
+	restorationProvision, err := CalculateRestorationProvision(l)
+	if err != nil {
+		return RoUAssetComponents{}, err
+	}
+
+	components := RoUAssetComponents{
+		LeaseLiability:         leaseLiability,
+		PaymentsAtCommencement: paymentsAtCommencement(l) + l.PrepaidRent,
+		LeaseIncentives:        l.LeaseIncentives,
+		InitialDirectCosts:     l.InitialDirectCost,
+		RestorationCosts:       restorationProvision,
+	}
+
+	components.Total = components.LeaseLiability +
+		components.PaymentsAtCommencement -
+		components.LeaseIncentives +
+		components.InitialDirectCosts +
+		components.RestorationCosts
+
+	return components, nil
+}
+
+// paymentsAtCommencement totals the lease payments made at or before the commencement date:
+// the lease component of the regular payments made by then and any fixed extra payments dated
+// on or before the start date.
+func paymentsAtCommencement(l lease.Lease) money.Amount {
+	total := money.Amount(0)
+	for _, payment := range regularPaymentsAtCommencement(l) {
+		total += l.LeaseComponent(payment.Amount)
+	}
+	for _, extra := range l.ExtraPayments {
+		if !extra.Variable && !extra.Date.After(l.StartDate) {
+			total += extra.Amount
+		}
+	}
+	return total
+}
+
+// regularPaymentsAtCommencement returns the regular payments made at or before the
+// commencement date, non-lease components included: the first regular payment of a lease paid
+// in advance or, in an irregular payment calendar, the scheduled payments dated on or before
+// the start date.
+func regularPaymentsAtCommencement(l lease.Lease) []lease.ScheduledPayment {
+	var payments []lease.ScheduledPayment
+	if l.PaymentFrequency == lease.Irregular {
+		for _, scheduled := range l.PaymentSchedule {
+			if !scheduled.Date.After(l.StartDate) {
+				payments = append(payments, scheduled)
+			}
+		}
+	} else if l.PaysInAdvance() {
+		if periods, err := paymentPeriods(l); err == nil && periods > 0 {
+			payments = append(payments, lease.ScheduledPayment{Date: l.StartDate, Amount: l.PaymentForPeriod(l.StartDate)})
+		}
+	}
+	return payments
+}
+
+// DepreciationEndDate returns the last day over which the RoU asset of a lease is depreciated.
+// A lease that transfers ownership of the underlying asset, or has a purchase option the lessee
+// is reasonably certain to exercise, is depreciated to the end of the asset's useful life;
+// any other lease to the earlier of the end of its useful life and the end of the lease term
+// (IFRS 16.32). Without a useful life, a lease that does not transfer ownership is depreciated
+// over its lease term.
+func DepreciationEndDate(l lease.Lease) (time.Time, error) {
+	if l.UsefulLifeMonths < 0 {
+		return time.Time{}, fmt.Errorf("useful life cannot be negative: %d months", l.UsefulLifeMonths)
+	}
+	if l.UsefulLifeMonths == 0 {
+		if l.TransfersOwnership() {
+			return time.Time{}, fmt.Errorf("a useful life is required when ownership transfers or a purchase option is reasonably certain")
+		}
+		return l.EndDate, nil
+	}
+
+	usefulLifeEnd := l.StartDate.AddDate(0, l.UsefulLifeMonths, -1)
+	if !l.TransfersOwnership() && usefulLifeEnd.After(l.EndDate) {
+		return l.EndDate, nil
+	}
+	return usefulLifeEnd, nil
 }
@@ -2,6 +2,8 @@ package calculation
 
 import (
 	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"math"
 	"testing"
 	"time"
 )
@@ -15,7 +17,7 @@ func TestCalculateInitialRoUAsset(t *testing.T) {
 		ID:               "TestLease",
 		StartDate:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
 		EndDate:          time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
-		PaymentAmount:    1000,
+		PaymentAmount:    1000 * money.Unit,
 		PaymentFrequency: lease.Monthly,
 		DiscountRate:     0.05,
 	}
@@ -56,15 +58,225 @@ func TestCalculateInitialRoUAsset(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			rouAsset, err := CalculateInitialRoUAsset(tt.leaseLiability, tt.lease)
+			rouAsset, err := CalculateInitialRoUAsset(money.FromFloat(tt.leaseLiability), tt.lease)
 
 			if (err != nil) != tt.expectError {
 				t.Errorf("CalculateInitialRoUAsset() error = %v, expectError %v", err, tt.expectError)
 				return
 			}
-			if rouAsset != tt.expectedRoUAsset { // Direct comparison ok for now
-				t.Errorf("CalculateInitialRoUAsset() = %v, want %v", rouAsset, tt.expectedRoUAsset)
+			if rouAsset != money.FromFloat(tt.expectedRoUAsset) { // Amounts are exact to the cent
+				t.Errorf("CalculateInitialRoUAsset() = %s, want %.2f", rouAsset, tt.expectedRoUAsset)
 			}
 		})
 	}
 }
+
+func TestCalculateInitialRoUAssetPaymentsAtCommencement(t *testing.T) {
+	inAdvance := lease.Lease{
+		ID:               "TestLeaseAdvance",
+		StartDate:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:          time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+		PaymentAmount:    1000 * money.Unit,
+		PaymentFrequency: lease.Monthly,
+		PaymentTiming:    lease.Advance,
+		DiscountRate:     0.05,
+	}
+
+	withKeyMoney := inAdvance
+	withKeyMoney.PaymentTiming = lease.Arrears
+	withKeyMoney.ExtraPayments = []lease.ExtraPayment{
+		{Date: time.Date(2023, 12, 15, 0, 0, 0, 0, time.UTC), Amount: 2500 * money.Unit, Type: lease.KeyMoney},
+		{Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Amount: 300 * money.Unit, Type: lease.OtherPayment, Variable: true},
+	}
+
+	tests := []struct {
+		name             string
+		leaseLiability   float64
+		lease            lease.Lease
+		expectedRoUAsset float64
+	}{
+		{
+			name:             "Payment in advance on commencement",
+			leaseLiability:   10729.89,
+			lease:            inAdvance,
+			expectedRoUAsset: 11729.89,
+		},
+		{
+			name:             "Fixed key money paid before commencement",
+			leaseLiability:   11681.22,
+			lease:            withKeyMoney,
+			expectedRoUAsset: 14181.22,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rouAsset, err := CalculateInitialRoUAsset(money.FromFloat(tt.leaseLiability), tt.lease)
+			if err != nil {
+				t.Fatalf("CalculateInitialRoUAsset() error = %v", err)
+			}
+			if rouAsset != money.FromFloat(tt.expectedRoUAsset) {
+				t.Errorf("CalculateInitialRoUAsset() = %s, want %.2f", rouAsset, tt.expectedRoUAsset)
+			}
+		})
+	}
+}
+
+func TestCalculateInitialRoUAssetComponents(t *testing.T) {
+	sampleLease := lease.Lease{
+		ID:                "TestLeaseComponents",
+		StartDate:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:           time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+		PaymentAmount:     1000 * money.Unit,
+		PaymentFrequency:  lease.Monthly,
+		PaymentTiming:     lease.Advance,
+		DiscountRate:      0.05,
+		InitialDirectCost: 750 * money.Unit,
+		LeaseIncentives:   2000 * money.Unit,
+		PrepaidRent:       500 * money.Unit,
+		RestorationCost:   1500 * money.Unit,
+	}
+
+	components, err := CalculateInitialRoUAssetComponents(money.FromFloat(10729.89), sampleLease)
+	if err != nil {
+		t.Fatalf("CalculateInitialRoUAssetComponents() error = %v", err)
+	}
+
+	expected := RoUAssetComponents{
+		LeaseLiability:         money.FromFloat(10729.89),
+		PaymentsAtCommencement: 1500 * money.Unit, // First payment in advance plus prepaid rent
+		LeaseIncentives:        2000 * money.Unit,
+		InitialDirectCosts:     750 * money.Unit,
+		RestorationCosts:       1500 * money.Unit,
+		Total:                  money.FromFloat(12479.89),
+	}
+	if components.Total != expected.Total {
+		t.Errorf("Total = %s, want %s", components.Total, expected.Total)
+	}
+	if components != expected {
+		t.Errorf("CalculateInitialRoUAssetComponents() = %+v, want %+v", components, expected)
+	}
+
+	negative := sampleLease
+	negative.LeaseIncentives = -100 * money.Unit
+	if _, err := CalculateInitialRoUAssetComponents(money.FromFloat(10729.89), negative); err == nil {
+		t.Error("Expected error for negative lease incentives, got nil")
+	}
+}
+
+func TestDepreciationEndDate(t *testing.T) {
+	base := lease.Lease{
+		ID:               "TestLeaseUsefulLife",
+		StartDate:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:          time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+		PaymentAmount:    1000 * money.Unit,
+		PaymentFrequency: lease.Monthly,
+		DiscountRate:     0.05,
+	}
+
+	tests := []struct {
+		name        string
+		modify      func(l *lease.Lease)
+		expected    string
+		expectError bool
+	}{
+		{"Lease term without a useful life", func(l *lease.Lease) {}, "2026-12-31", false},
+		{"Useful life shorter than the term", func(l *lease.Lease) { l.UsefulLifeMonths = 24 }, "2025-12-31", false},
+		{"Useful life longer than the term", func(l *lease.Lease) { l.UsefulLifeMonths = 60 }, "2026-12-31", false},
+		{"Purchase option not reasonably certain", func(l *lease.Lease) {
+			l.UsefulLifeMonths = 60
+			l.PurchaseOptionPrice = 5000 * money.Unit
+		}, "2026-12-31", false},
+		{"Ownership transfers", func(l *lease.Lease) {
+			l.UsefulLifeMonths = 60
+			l.OwnershipTransfer = true
+		}, "2028-12-31", false},
+		{"Purchase option reasonably certain", func(l *lease.Lease) {
+			l.UsefulLifeMonths = 60
+			l.PurchaseOptionPrice = 5000 * money.Unit
+			l.PurchaseOptionReasonablyCertain = true
+		}, "2028-12-31", false},
+		{"Ownership transfers without a useful life", func(l *lease.Lease) { l.OwnershipTransfer = true }, "", true},
+		{"Negative useful life", func(l *lease.Lease) { l.UsefulLifeMonths = -1 }, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := base
+			tt.modify(&l)
+			end, err := DepreciationEndDate(l)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("DepreciationEndDate() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && end.Format(testDateLayout) != tt.expected {
+				t.Errorf("DepreciationEndDate() = %s, want %s", end.Format(testDateLayout), tt.expected)
+			}
+		})
+	}
+}
+
+func TestPurchaseOptionReasonablyCertain(t *testing.T) {
+	base := lease.Lease{
+		ID:               "TestLeasePurchaseOption",
+		StartDate:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:          time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+		PaymentAmount:    1000 * money.Unit,
+		PaymentFrequency: lease.Monthly,
+		DiscountRate:     0.06,
+		UsefulLifeMonths: 96,
+	}
+	withOption := base
+	withOption.PurchaseOptionPrice = 8000 * money.Unit
+	withOption.PurchaseOptionReasonablyCertain = true
+
+	baseLiability, err := CalculateLeaseLiability(base)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	liability, err := CalculateLeaseLiability(withOption)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+
+	// The exercise price is discounted with the final payment, 36 months out
+	optionValue := money.FromFloat(8000 * math.Pow(1+0.06/12, -36))
+	if (liability - baseLiability - optionValue).Abs() > money.Cent {
+		t.Errorf("Liability with purchase option = %s, want %s plus %s", liability, baseLiability, optionValue)
+	}
+
+	// The option is paid off on the end date
+	schedule, err := GenerateLiabilitySchedule(withOption, liability)
+	if err != nil {
+		t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+	}
+	last := schedule[len(schedule)-1]
+	if !last.Date.Equal(withOption.EndDate) || last.Payment != 9000*money.Unit || last.ClosingBalance != 0 {
+		t.Errorf("Last entry = %+v, want the final rent and option price paid on the end date", last)
+	}
+
+	// The RoU asset is depreciated over the eight-year useful life, to exactly zero
+	rou, err := GenerateRoUAssetSchedule(withOption, liability)
+	if err != nil {
+		t.Fatalf("GenerateRoUAssetSchedule() error = %v", err)
+	}
+	if len(rou) != 2922 {
+		t.Fatalf("GenerateRoUAssetSchedule() returned %d entries, want 2922 days to 2031-12-31", len(rou))
+	}
+	total := money.Amount(0)
+	for _, entry := range rou {
+		total += entry.Depreciation
+	}
+	if total != liability || rou[len(rou)-1].ClosingBalance != 0 {
+		t.Errorf("Depreciation totals %s closing at %s, want %s closing at 0.00", total, rou[len(rou)-1].ClosingBalance, liability)
+	}
+
+	// Without the option being reasonably certain, neither applies
+	notCertain := withOption
+	notCertain.PurchaseOptionReasonablyCertain = false
+	if got, _ := CalculateLeaseLiability(notCertain); got != baseLiability {
+		t.Errorf("Liability with an uncertain option = %s, want %s", got, baseLiability)
+	}
+	if rou, _ := GenerateRoUAssetSchedule(notCertain, baseLiability); len(rou) != 1096 {
+		t.Errorf("GenerateRoUAssetSchedule() returned %d entries, want 1096 days over the lease term", len(rou))
+	}
+}
@@ -0,0 +1,122 @@
+package calculation
+
+import (
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"sort"
+)
+
+// SubleaseReason is the reason recorded on a head lease for the part of its RoU asset
+// derecognised under a finance sublease.
+const SubleaseReason = "Sublease"
+
+// ClassifySublease classifies a sublease by reference to the RoU asset arising from the head
+// lease rather than the underlying asset (IFRS 16.B58(b)). The economic life assessed is the
+// rest of the depreciation period of the head lease's RoU asset from the start of the
+// sublease, and the sublease's FairValue is the fair value of the part of that asset it
+// sublets. The sublease must fall within the term of the head lease.
+func ClassifySublease(sub, head lease.Lease) (lease.Classification, []string, error) {
+	if sub.StartDate.Before(head.StartDate) || sub.EndDate.After(head.EndDate) {
+		return "", nil, fmt.Errorf("the sublease from %s to %s falls outside the term of head lease %s (%s to %s)",
+			sub.StartDate.Format("2006-01-02"), sub.EndDate.Format("2006-01-02"), head.ID,
+			head.StartDate.Format("2006-01-02"), head.EndDate.Format("2006-01-02"))
+	}
+	if sub.SubleasedShare < 0 || sub.SubleasedShare > 1 {
+		return "", nil, fmt.Errorf("subleased share must be between 0%% and 100%%: %.2f", sub.SubleasedShare)
+	}
+	depreciationEnd, err := DepreciationEndDate(head)
+	if err != nil {
+		return "", nil, err
+	}
+	return classifyLessorLease(sub, depreciationEnd.AddDate(0, 0, 1), "right-of-use asset")
+}
+
+// DerecogniseSubleasedRoUAsset derecognises the part of a head lease's RoU asset sublet under
+// a finance sublease at the start of the sublease's commencement date (IFRS 16.B58) and
+// returns the carrying amount derecognised. The head lease keeps its lease liability.
+//
+// retained is the share of the head lease's underlying asset not yet sublet under finance
+// subleases, 1 before the first; the sublease derecognises its SubleasedShare of the whole
+// asset out of it, and the share still retained is returned. The RoU asset schedule of the
+// head lease, which must not yet be rolled up, continues in proportion to the share
+// retained, and the derecognition is recorded among its remeasurements. Later remeasurements
+// and impairments of the RoU asset are reduced in the same proportion.
+func DerecogniseSubleasedRoUAsset(head *CalculationResult, sub lease.Lease, retained float64) (money.Amount, float64, error) {
+	if head.ScheduleGranularity != "" {
+		return 0, retained, fmt.Errorf("the schedules of head lease %s are already rolled up", head.LeaseID)
+	}
+	share := sub.SubleasedShare
+	if share == 0 {
+		share = 1
+	}
+	if share > retained+1e-9 {
+		return 0, retained, fmt.Errorf("only %.1f%% of the asset under head lease %s remains to sublet", retained*100, head.LeaseID)
+	}
+
+	schedule := head.RoUAssetSchedule
+	index := sort.Search(len(schedule), func(k int) bool {
+		return !schedule[k].Date.Before(sub.StartDate)
+	})
+	if index == len(schedule) {
+		return 0, retained, fmt.Errorf("the RoU asset of head lease %s is fully depreciated by %s", head.LeaseID, sub.StartDate.Format("2006-01-02"))
+	}
+
+	factor := max(retained-share, 0) / retained
+	before := carryingAmountOn(schedule[index])
+	after := before.Mul(factor)
+
+	// The first entry keeps what was already booked at the start of the day and shows the
+	// derecognition as a negative remeasurement; depreciation balances every entry.
+	adjusted := make([]AmortizationEntry, len(schedule))
+	copy(adjusted, schedule)
+	for k := index; k < len(adjusted); k++ {
+		entry := &adjusted[k]
+		if k == index {
+			entry.Remeasurement -= before - after
+		} else {
+			entry.OpeningBalance = adjusted[k-1].ClosingBalance
+			entry.Remeasurement = entry.Remeasurement.Mul(factor)
+			entry.Impairment = entry.Impairment.Mul(factor)
+		}
+		entry.ClosingBalance = entry.ClosingBalance.Mul(factor)
+		entry.Depreciation = carryingAmountOn(*entry) - entry.ClosingBalance
+	}
+	head.RoUAssetSchedule = adjusted
+
+	for k, r := range head.Remeasurements {
+		if !r.EffectiveDate.Before(sub.StartDate) {
+			head.Remeasurements[k].RoUAssetBefore = r.RoUAssetBefore.Mul(factor)
+			head.Remeasurements[k].RoUAssetAfter = r.RoUAssetAfter.Mul(factor)
+		}
+	}
+	for k, i := range head.Impairments {
+		if !i.Date.Before(sub.StartDate) {
+			head.Impairments[k].CarryingAmountBefore = i.CarryingAmountBefore.Mul(factor)
+			head.Impairments[k].Loss = i.Loss.Mul(factor)
+			head.Impairments[k].CarryingAmountAfter = i.CarryingAmountAfter.Mul(factor)
+		}
+	}
+
+	var liability money.Amount
+	k := sort.Search(len(head.LiabilitySchedule), func(k int) bool {
+		return !head.LiabilitySchedule[k].Date.Before(sub.StartDate)
+	})
+	if k < len(head.LiabilitySchedule) {
+		liability = carryingAmountOn(head.LiabilitySchedule[k])
+	}
+	head.Remeasurements = append(head.Remeasurements, Remeasurement{
+		EffectiveDate:   sub.StartDate,
+		Reason:          SubleaseReason,
+		Description:     fmt.Sprintf("Finance sublease %s of %.1f%% of the underlying asset (IFRS 16.B58)", sub.ID, share*100),
+		LiabilityBefore: liability,
+		LiabilityAfter:  liability,
+		RoUAssetBefore:  before,
+		RoUAssetAfter:   after,
+	})
+	sort.SliceStable(head.Remeasurements, func(i, j int) bool {
+		return head.Remeasurements[i].EffectiveDate.Before(head.Remeasurements[j].EffectiveDate)
+	})
+
+	return before - after, retained - share, nil
+}
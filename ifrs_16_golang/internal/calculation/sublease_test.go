@@ -0,0 +1,101 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"testing"
+)
+
+func TestClassifySublease(t *testing.T) {
+	head := testLease("2024-01-01", "2028-12-31")
+
+	tests := []struct {
+		name       string
+		end        string
+		usefulLife int
+		want       lease.Classification
+		indicators int
+		wantErr    bool
+	}{
+		{"Rest of the head lease's RoU asset", "2028-12-31", 0, lease.FinanceLease, 1, false},
+		{"One-year sublet, whatever the useful life of the building", "2025-12-31", 12, lease.OperatingLease, 0, false},
+		{"Beyond the head lease term", "2029-06-30", 0, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := testLease("2025-01-01", tt.end)
+			sub.Role = lease.LessorRole
+			sub.HeadLeaseID = head.ID
+			sub.SubleasedShare = 0.5
+			sub.UsefulLifeMonths = tt.usefulLife
+			got, indicators, err := ClassifySublease(sub, head)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ClassifySublease() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || len(indicators) != tt.indicators {
+				t.Errorf("ClassifySublease() = %q with indicators %q, want %q with %d", got, indicators, tt.want, tt.indicators)
+			}
+		})
+	}
+}
+
+func TestDerecogniseSubleasedRoUAsset(t *testing.T) {
+	head := testLease("2024-01-01", "2028-12-31")
+	sub := testLease("2025-01-01", "2028-12-31")
+	sub.Role = lease.LessorRole
+	sub.HeadLeaseID = head.ID
+	sub.SubleasedShare = 0.5
+	liability, err := CalculateLeaseLiability(head)
+	if err != nil {
+		t.Fatalf("CalculateLeaseLiability() error = %v", err)
+	}
+	liabilitySchedule, err := GenerateLiabilitySchedule(head, liability)
+	if err != nil {
+		t.Fatalf("GenerateLiabilitySchedule() error = %v", err)
+	}
+	rouSchedule, err := GenerateRoUAssetSchedule(head, liability)
+	if err != nil {
+		t.Fatalf("GenerateRoUAssetSchedule() error = %v", err)
+	}
+	result := CalculationResult{LeaseID: head.ID, LiabilitySchedule: liabilitySchedule, RoUAssetSchedule: rouSchedule}
+
+	derecognised, retained, err := DerecogniseSubleasedRoUAsset(&result, sub, 1)
+	if err != nil {
+		t.Fatalf("DerecogniseSubleasedRoUAsset() error = %v", err)
+	}
+	if retained != 0.5 {
+		t.Errorf("Retained share = %.2f, want 0.50", retained)
+	}
+
+	var before money.Amount
+	for i, entry := range result.RoUAssetSchedule {
+		if entry.OpeningBalance+entry.Remeasurement-entry.Impairment-entry.Depreciation != entry.ClosingBalance {
+			t.Fatalf("Entry %d does not reconcile: %+v", i+1, entry)
+		}
+		if entry.Date.Equal(sub.StartDate) {
+			before = entry.OpeningBalance
+			if entry.Remeasurement != -derecognised || (before/2-derecognised).Abs() > money.Cent {
+				t.Errorf("Entry on %s = %+v, want half of %s derecognised", sub.StartDate.Format(testDateLayout), entry, before)
+			}
+		}
+		if !entry.Date.Before(sub.StartDate) && (entry.Depreciation-rouSchedule[i].Depreciation/2).Abs() > money.Cent {
+			t.Fatalf("Entry %d depreciation = %s, want half of %s", i+1, entry.Depreciation, rouSchedule[i].Depreciation)
+		}
+	}
+	if last := result.RoUAssetSchedule[len(result.RoUAssetSchedule)-1]; last.ClosingBalance != 0 {
+		t.Errorf("RoU asset closes at %s, want zero", last.ClosingBalance)
+	}
+	if len(result.Remeasurements) != 1 || result.Remeasurements[0].Reason != SubleaseReason ||
+		result.Remeasurements[0].RoUAssetBefore-result.Remeasurements[0].RoUAssetAfter != derecognised ||
+		result.Remeasurements[0].LiabilityBefore != result.Remeasurements[0].LiabilityAfter {
+		t.Errorf("Remeasurements = %+v, want the derecognition recorded with the liability unchanged", result.Remeasurements)
+	}
+
+	// Another sublease may only sublet what is left of the asset
+	second := sub
+	second.SubleasedShare = 0.6
+	if _, _, err := DerecogniseSubleasedRoUAsset(&result, second, retained); err == nil {
+		t.Errorf("DerecogniseSubleasedRoUAsset() expected an error when subletting more than is retained")
+	}
+}
@@ -0,0 +1,277 @@
+package calculation
+
+import (
+	"errors"
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TransitionMethod identifies how a lease previously classified as an operating lease under
+// IAS 17 is brought onto the balance sheet at the date of initial application of IFRS 16.
+type TransitionMethod string
+
+const (
+	FullRetrospective              TransitionMethod = "FullRetrospective" // As if IFRS 16 had always applied (IFRS 16.C5(a))
+	ModifiedRetrospectiveAsIf      TransitionMethod = "AsIfApplied"       // RoU asset as if IFRS 16 had always applied, at the rate at initial application (IFRS 16.C8(b)(i))
+	ModifiedRetrospectiveLiability TransitionMethod = "EqualToLiability"  // RoU asset equal to the liability, adjusted by prepaid or accrued payments (IFRS 16.C8(b)(ii))
+)
+
+// TransitionPolicy sets how leases in place at the date of initial application are measured
+// on transition. The practical expedients of IFRS 16.C10 are only available under the
+// modified retrospective approach.
+type TransitionPolicy struct {
+	Method             TransitionMethod
+	InitialApplication time.Time // Start of the annual reporting period in which IFRS 16 is first applied
+	// PortfolioRates gives a single discount rate, as a decimal, for each portfolio of leases
+	// with reasonably similar characteristics, keyed by asset class; "*" covers every class
+	// (IFRS 16.C10(a)). Other leases are discounted at their own TransitionDiscountRate or, if
+	// it is not given, at the incremental borrowing rate at the date of initial application
+	// built from RateCurves, read at RateTenor.
+	PortfolioRates            map[string]float64
+	ExcludeInitialDirectCosts bool // Leave initial direct costs out of the RoU asset (IFRS 16.C10(d))
+	// Hindsight applies the option reassessments made before the date of initial application
+	// from commencement, as if known then, when determining the lease term (IFRS 16.C10(e)).
+	Hindsight bool
+	// RateCurves are the yield curves and spreads incremental borrowing rates are built from.
+	RateCurves lease.RateCurves
+	RateTenor  RateTenor
+}
+
+// TransitionAdjustment is the measurement of a lease at the date of initial application and
+// its reconciliation from the IAS 17 operating lease commitment (IFRS 16.C12(b)):
+// Commitment + OptionPayments - ExemptPayments - Discounting = OpeningLiability.
+type TransitionAdjustment struct {
+	Method                 TransitionMethod `json:"method"`
+	InitialApplicationDate string           `json:"initialApplicationDate"`
+	DiscountRate           float64          `json:"discountRate"`               // Rate the opening liability is measured at
+	Exemption              Exemption        `json:"exemption,omitempty"`        // Recognition exemption that keeps the lease off the balance sheet
+	Commitment             money.Amount     `json:"commitment"`                 // IAS 17 operating lease commitment: undiscounted payments left in the non-cancellable period
+	OptionPayments         money.Amount     `json:"optionPayments,omitempty"`   // Undiscounted payments of the periods options add to the lease term, negative for a termination
+	ExemptPayments         money.Amount     `json:"exemptPayments,omitempty"`   // Undiscounted payments of an exempt lease
+	Discounting            money.Amount     `json:"discounting"`                // Effect of discounting the remaining lease payments
+	OpeningLiability       money.Amount     `json:"openingLiability"`           // Lease liability at the date of initial application
+	OpeningRoUAsset        money.Amount     `json:"openingRoUAsset"`            // RoU asset at the date of initial application
+	AccruedLeasePayments   money.Amount     `json:"accruedLeasePayments"`       // IAS 17 accrued rent derecognised, negative for prepaid rent
+	RetainedEarnings       money.Amount     `json:"retainedEarningsAdjustment"` // Increase (positive) or decrease (negative) in opening retained earnings
+	// DiscountRateSource records how a rate built from yield curves at the date of initial
+	// application was derived.
+	DiscountRateSource *lease.RateProvenance `json:"discountRateSource,omitempty"`
+}
+
+// CalculateTransition measures a lessee's lease, previously an operating lease under IAS 17,
+// at the date of initial application of IFRS 16 and returns the adjustment to opening retained
+// earnings. A lease that commences on or after that date, or has ended before it, is accounted
+// for under IFRS 16 alone and has no adjustment (nil).
+//
+// The lease is rebuilt as if IFRS 16 had applied since commencement: modifications effective
+// before the date of initial application are applied in turn, and later ones are left to the
+// IFRS 16 accounting that follows. Under the modified retrospective approach the rebuilt lease
+// is discounted throughout at the incremental borrowing rate at initial application, so its
+// liability at that date is the present value of the remaining payments (IFRS 16.C8(a)); the
+// rate is the portfolio rate of the lease's class, the lease's TransitionDiscountRate or one
+// built from the policy's yield curves at that date, and the lease's own DiscountRate, priced
+// at commencement, is not used. Under the full retrospective approach the rebuilt lease keeps
+// the rates of the lease and its modifications. The RoU asset
+// is the rebuilt carrying amount at that date, or, under IFRS 16.C8(b)(ii), the liability
+// less the IAS 17 rent accrued (plus the rent prepaid). The accrual is the balance of the
+// straight-line expense of the lease as entered, and the retained earnings adjustment is
+// the RoU asset less the liability plus the accrual derecognised.
+//
+// Restoration obligations remain measured under IAS 37 and impairments are not rebuilt. An
+// exempt lease is reconciled from its commitment but keeps its IAS 17 accounting.
+func CalculateTransition(l lease.Lease, p TransitionPolicy, exemption Exemption) (*TransitionAdjustment, error) {
+	dia := p.InitialApplication
+	if dia.IsZero() {
+		return nil, errors.New("the transition needs the date of initial application")
+	}
+	switch p.Method {
+	case FullRetrospective:
+		if len(p.PortfolioRates) > 0 || p.ExcludeInitialDirectCosts || p.Hindsight {
+			return nil, errors.New("the practical expedients of IFRS 16.C10 apply only to the modified retrospective approach")
+		}
+	case ModifiedRetrospectiveAsIf, ModifiedRetrospectiveLiability:
+	default:
+		return nil, fmt.Errorf("unsupported transition method: %s", p.Method)
+	}
+	if !l.StartDate.Before(dia) || l.EndDate.Before(dia) {
+		return nil, nil
+	}
+
+	modifications := make([]lease.Modification, len(l.Modifications))
+	copy(modifications, l.Modifications)
+	sort.SliceStable(modifications, func(i, j int) bool {
+		return modifications[i].EffectiveDate.Before(modifications[j].EffectiveDate)
+	})
+
+	rebuilt := l
+	rebuilt.Modifications = nil
+	rebuilt.Impairments = nil
+	rebuilt.RestorationCost = 0
+	for _, m := range modifications {
+		if !m.EffectiveDate.Before(dia) {
+			break
+		}
+		if p.Hindsight && m.Type == lease.OptionReassessment {
+			if m.Option < 1 || m.Option > len(rebuilt.Options) {
+				return nil, fmt.Errorf("the lease has no option %d", m.Option)
+			}
+			rebuilt.Options = append([]lease.Option(nil), rebuilt.Options...)
+			rebuilt.Options[m.Option-1].ReasonablyCertain = m.OptionReasonablyCertain
+			continue
+		}
+		rebuilt.Modifications = append(rebuilt.Modifications, m)
+	}
+
+	// Hindsight may change the lease term
+	rebuilt, err := LeaseTerm(rebuilt)
+	if err != nil {
+		return nil, err
+	}
+	if rebuilt.EndDate.Before(dia) {
+		return nil, nil
+	}
+
+	rate := l.DiscountRate
+	var source *lease.RateProvenance
+	if p.Method != FullRetrospective {
+		portfolioRate, ok := portfolioRateFor(p.PortfolioRates, l.AssetClass)
+		switch {
+		case ok:
+			rate = portfolioRate
+		case l.TransitionDiscountRate != 0:
+			rate = l.TransitionDiscountRate
+		case len(p.RateCurves) > 0:
+			built, err := incrementalBorrowingRateAt(rebuilt, p.RateCurves, p.RateTenor, dia)
+			if err != nil {
+				return nil, fmt.Errorf("incremental borrowing rate at the date of initial application: %w", err)
+			}
+			rate = built.Rate
+			source = &built
+		case exemption != NoExemption:
+			// An exempt lease is only reconciled from its commitment
+		default:
+			return nil, errors.New("the modified retrospective approach needs the incremental borrowing rate at the date of initial application: give a TransitionDiscountRate, a portfolio rate or yield curves")
+		}
+		rebuilt.DiscountRate = rate
+		for i := range rebuilt.Modifications {
+			if rebuilt.Modifications[i].RevisedDiscountRate != 0 {
+				rebuilt.Modifications[i].RevisedDiscountRate = rate
+			}
+		}
+		if p.ExcludeInitialDirectCosts {
+			rebuilt.InitialDirectCost = 0
+		}
+	}
+	if rate <= 0 {
+		return nil, errors.New("the transition needs a discount rate at the date of initial application")
+	}
+
+	t := TransitionAdjustment{
+		Method:                 p.Method,
+		InitialApplicationDate: dia.Format("2006-01-02"),
+		DiscountRate:           rate,
+		DiscountRateSource:     source,
+		Exemption:              exemption,
+	}
+
+	if exemption != NoExemption {
+		if err := t.reconcile(rebuilt, dia, 0); err != nil {
+			return nil, err
+		}
+		return &t, nil
+	}
+
+	liability, err := CalculateLeaseLiability(rebuilt)
+	if err != nil {
+		return nil, err
+	}
+	components, err := CalculateInitialRoUAssetComponents(liability, rebuilt)
+	if err != nil {
+		return nil, err
+	}
+	modified, err := ApplyModifications(rebuilt, liability, components.Total)
+	if err != nil {
+		return nil, err
+	}
+	openingLiability, _, _ := splitSchedule(modified.LiabilitySchedule, dia)
+	openingRoUAsset, _, _ := splitSchedule(modified.RoUAssetSchedule, dia)
+	if err := t.reconcile(modified.Lease, dia, openingLiability); err != nil {
+		return nil, err
+	}
+
+	// IAS 17 spread the payments straight-line over the lease term as entered
+	straightLine, err := straightLineSchedule(l)
+	if err != nil {
+		return nil, err
+	}
+	t.AccruedLeasePayments, _, _ = splitSchedule(straightLine, dia)
+
+	t.OpeningRoUAsset = openingRoUAsset
+	if p.Method == ModifiedRetrospectiveLiability {
+		t.OpeningRoUAsset = openingLiability - t.AccruedLeasePayments
+	}
+	t.RetainedEarnings = t.OpeningRoUAsset - t.OpeningLiability + t.AccruedLeasePayments
+	return &t, nil
+}
+
+// reconcile fills in the reconciliation from the commitment under the terms in force at the
+// date of initial application to the opening liability. The commitment covers the
+// non-cancellable period, the contract end date before options.
+func (t *TransitionAdjustment) reconcile(inForce lease.Lease, dia time.Time, openingLiability money.Amount) error {
+	contract := inForce
+	if len(contract.Options) > 0 {
+		contract.EndDate = contract.ContractEndDate
+		contract.Options = nil
+	}
+	commitment, err := undiscountedPaymentsFrom(contract, dia)
+	if err != nil {
+		return err
+	}
+	payments, err := undiscountedPaymentsFrom(inForce, dia)
+	if err != nil {
+		return err
+	}
+
+	t.Commitment = commitment
+	t.OptionPayments = payments - commitment
+	if t.Exemption != NoExemption {
+		t.ExemptPayments = payments
+		return nil
+	}
+	t.Discounting = payments - openingLiability
+	t.OpeningLiability = openingLiability
+	return nil
+}
+
+// undiscountedPaymentsFrom totals the lease payments included in the liability that fall due
+// on or after date.
+func undiscountedPaymentsFrom(l lease.Lease, date time.Time) (money.Amount, error) {
+	flows, _, _, err := liabilityCashFlows(l)
+	if err != nil {
+		return 0, err
+	}
+	total := money.Amount(0)
+	for _, flow := range flows {
+		if !flow.date.Before(date) {
+			total += flow.amount
+		}
+	}
+	return total, nil
+}
+
+// portfolioRateFor returns the portfolio rate for an asset class: the rate given for the
+// class itself, or else the one given for "*".
+func portfolioRateFor(rates map[string]float64, class string) (float64, bool) {
+	class = strings.TrimSpace(class)
+	for c, rate := range rates {
+		if c != "*" && strings.EqualFold(strings.TrimSpace(c), class) {
+			return rate, true
+		}
+	}
+	rate, ok := rates["*"]
+	return rate, ok
+}
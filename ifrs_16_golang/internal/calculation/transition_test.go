@@ -0,0 +1,212 @@
+package calculation
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"math"
+	"testing"
+)
+
+func TestCalculateTransition(t *testing.T) {
+	dia := mustParseDate(testDateLayout, "2024-01-01")
+	// An IAS 17 operating lease of office space that commenced two years before the date of
+	// initial application, with three years of monthly rent left, at 5% at both dates
+	l := testLease("2022-01-01", "2026-12-31")
+	l.TransitionDiscountRate = 0.05
+	l.InitialDirectCost = 1200 * money.Unit
+	l.AssetClass = "Property"
+
+	reconciles := func(tr *TransitionAdjustment) bool {
+		return tr.Commitment+tr.OptionPayments-tr.ExemptPayments-tr.Discounting == tr.OpeningLiability
+	}
+
+	asIf, err := CalculateTransition(l, TransitionPolicy{Method: ModifiedRetrospectiveAsIf, InitialApplication: dia}, NoExemption)
+	if err != nil {
+		t.Fatalf("CalculateTransition() error = %v", err)
+	}
+	// 37 monthly payments of 1000 left, the one for December falling due that day, discounted at 5%
+	if asIf.Commitment != 37000*money.Unit || asIf.OptionPayments != 0 || !reconciles(asIf) {
+		t.Errorf("Reconciliation = %+v, want a commitment of 37000 reconciling to the liability", asIf)
+	}
+	if (asIf.OpeningLiability - 34365*money.Unit).Abs() > 10*money.Unit {
+		t.Errorf("Opening liability = %s, want about 34365", asIf.OpeningLiability)
+	}
+	// Depreciation runs ahead of the liability's repayment, so retained earnings fall
+	if asIf.RetainedEarnings >= 0 || asIf.RetainedEarnings != asIf.OpeningRoUAsset-asIf.OpeningLiability+asIf.AccruedLeasePayments {
+		t.Errorf("Retained earnings adjustment = %s for %+v, want a decrease", asIf.RetainedEarnings, asIf)
+	}
+
+	equal, err := CalculateTransition(l, TransitionPolicy{Method: ModifiedRetrospectiveLiability, InitialApplication: dia}, NoExemption)
+	if err != nil {
+		t.Fatalf("CalculateTransition() error = %v", err)
+	}
+	if equal.OpeningLiability != asIf.OpeningLiability || equal.OpeningRoUAsset != equal.OpeningLiability-equal.AccruedLeasePayments || equal.RetainedEarnings != 0 {
+		t.Errorf("RoU asset equal to the liability = %+v, want no retained earnings adjustment", equal)
+	}
+
+	// Without the initial direct costs the RoU asset is lower by their carrying amount
+	noIDC, err := CalculateTransition(l, TransitionPolicy{Method: ModifiedRetrospectiveAsIf, InitialApplication: dia, ExcludeInitialDirectCosts: true}, NoExemption)
+	if err != nil {
+		t.Fatalf("CalculateTransition() error = %v", err)
+	}
+	if diff := asIf.OpeningRoUAsset - noIDC.OpeningRoUAsset; (diff - 720*money.Unit).Abs() > money.Unit {
+		t.Errorf("Initial direct costs carried = %s, want 720 of 1200 left after two of five years", diff)
+	}
+
+	portfolio, err := CalculateTransition(l, TransitionPolicy{Method: ModifiedRetrospectiveAsIf, InitialApplication: dia,
+		PortfolioRates: map[string]float64{"property": 0.08, "*": 0.03}}, NoExemption)
+	if err != nil {
+		t.Fatalf("CalculateTransition() error = %v", err)
+	}
+	if portfolio.DiscountRate != 0.08 || portfolio.OpeningLiability >= asIf.OpeningLiability || !reconciles(portfolio) {
+		t.Errorf("Portfolio rate measurement = %+v, want the Property rate of 8%%", portfolio)
+	}
+
+	exempt, err := CalculateTransition(l, TransitionPolicy{Method: ModifiedRetrospectiveAsIf, InitialApplication: dia}, LowValueExemption)
+	if err != nil {
+		t.Fatalf("CalculateTransition() error = %v", err)
+	}
+	if exempt.OpeningLiability != 0 || exempt.ExemptPayments != exempt.Commitment || exempt.RetainedEarnings != 0 || !reconciles(exempt) {
+		t.Errorf("Exempt lease = %+v, want its commitment reconciled away", exempt)
+	}
+}
+
+func TestCalculateTransitionNotApplied(t *testing.T) {
+	dia := mustParseDate(testDateLayout, "2024-01-01")
+
+	tests := []struct {
+		name    string
+		start   string
+		policy  TransitionPolicy
+		wantErr bool
+	}{
+		{"Expedient under the full retrospective approach", "2022-01-01", TransitionPolicy{Method: FullRetrospective, InitialApplication: dia, Hindsight: true}, true},
+		{"Commencing on the date of initial application", "2024-01-01", TransitionPolicy{Method: FullRetrospective, InitialApplication: dia}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := testLease(tt.start, "2026-12-31")
+			l.TransitionDiscountRate = 0.05
+			tr, err := CalculateTransition(l, tt.policy, NoExemption)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalculateTransition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tr != nil {
+				t.Errorf("CalculateTransition() = %+v, want no transition adjustment", tr)
+			}
+		})
+	}
+}
+
+func TestCalculateTransitionHindsight(t *testing.T) {
+	dia := mustParseDate(testDateLayout, "2024-01-01")
+	l := testLease("2022-01-01", "2026-12-31")
+	l.TransitionDiscountRate = 0.05
+	l.AssetClass = "Property"
+	l.Options = []lease.Option{{Type: lease.ExtensionOption, EndDate: mustParseDate(testDateLayout, "2028-12-31")}}
+	l.Modifications = []lease.Modification{{
+		Type:                    lease.OptionReassessment,
+		EffectiveDate:           mustParseDate(testDateLayout, "2023-07-01"),
+		RevisedDiscountRate:     0.06,
+		Option:                  1,
+		OptionReasonablyCertain: true,
+	}}
+	l, err := LeaseTerm(l)
+	if err != nil {
+		t.Fatalf("LeaseTerm() error = %v", err)
+	}
+
+	policy := TransitionPolicy{Method: ModifiedRetrospectiveAsIf, InitialApplication: dia}
+	inTurn, err := CalculateTransition(l, policy, NoExemption)
+	if err != nil {
+		t.Fatalf("CalculateTransition() error = %v", err)
+	}
+	policy.Hindsight = true
+	hindsight, err := CalculateTransition(l, policy, NoExemption)
+	if err != nil {
+		t.Fatalf("CalculateTransition() error = %v", err)
+	}
+
+	// Both measure the five years left at 5%, the extension beyond the commitment included
+	if (inTurn.OpeningLiability-hindsight.OpeningLiability).Abs() > money.Cent || hindsight.Commitment != 37000*money.Unit || hindsight.OptionPayments != 24000*money.Unit {
+		t.Errorf("Liability = %+v with hindsight, %+v without, want the same extended liability", hindsight, inTurn)
+	}
+	// Known from commencement, the extension is depreciated from then rather than from the
+	// reassessment, so less of the RoU asset is left
+	if hindsight.OpeningRoUAsset >= inTurn.OpeningRoUAsset || hindsight.RetainedEarnings >= inTurn.RetainedEarnings {
+		t.Errorf("RoU asset = %s with hindsight, %s without, want less carried with hindsight", hindsight.OpeningRoUAsset, inTurn.OpeningRoUAsset)
+	}
+}
+
+func TestCalculateTransitionRateAtInitialApplication(t *testing.T) {
+	dia := mustParseDate(testDateLayout, "2024-01-01")
+	l := testLease("2022-01-01", "2026-12-31")
+	l.TransitionDiscountRate = 0.05
+	l.InitialDirectCost = 1200 * money.Unit
+	l.AssetClass = "Property"
+	policy := TransitionPolicy{Method: ModifiedRetrospectiveAsIf, InitialApplication: dia}
+
+	atCommencementRate, err := CalculateTransition(l, policy, NoExemption)
+	if err != nil {
+		t.Fatalf("CalculateTransition() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		transitionRate float64
+		method         TransitionMethod
+		wantRate       float64
+		wantErr        bool
+	}{
+		// Rates have risen since commencement; the liability is discounted at the rate at transition
+		{"Rate risen by the date of initial application", 0.07, ModifiedRetrospectiveAsIf, 0.07, false},
+		// Without a rate at transition the commencement rate is not used in its place
+		{"No rate at the date of initial application", 0, ModifiedRetrospectiveAsIf, 0, true},
+		{"Full retrospective approach keeps the rate at commencement", 0, FullRetrospective, 0.05, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := l
+			l.TransitionDiscountRate = tt.transitionRate
+			tr, err := CalculateTransition(l, TransitionPolicy{Method: tt.method, InitialApplication: dia}, NoExemption)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalculateTransition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tr.DiscountRate != tt.wantRate {
+				t.Errorf("Transition rate = %v, want %v", tr.DiscountRate, tt.wantRate)
+			}
+			if tt.wantRate > l.DiscountRate && tr.OpeningLiability >= atCommencementRate.OpeningLiability {
+				t.Errorf("Opening liability = %s, want less than %s at the rate at commencement", tr.OpeningLiability, atCommencementRate.OpeningLiability)
+			}
+		})
+	}
+
+	l.TransitionDiscountRate = 0
+	// With yield curves the rate is built from those published by the date of initial
+	// application, over the three years left
+	l.Currency = "USD"
+	l.Entity = "Acme Ltd"
+	policy.RateCurves = lease.RateCurves{
+		{Component: lease.RiskFreeRate, Currency: "USD"}: {
+			{Date: mustParseDate(testDateLayout, "2021-12-31"), Points: []lease.CurvePoint{{TenorMonths: 60, Rate: 0.03}}},
+			{Date: mustParseDate(testDateLayout, "2023-12-29"), Points: []lease.CurvePoint{{TenorMonths: 12, Rate: 0.05}, {TenorMonths: 60, Rate: 0.07}}},
+		},
+		{Component: lease.CreditSpread, Name: "Acme Ltd"}: {
+			{Date: mustParseDate(testDateLayout, "2021-12-31"), Points: []lease.CurvePoint{{Rate: 0.01}}},
+		},
+	}
+	built, err := CalculateTransition(l, policy, NoExemption)
+	if err != nil {
+		t.Fatalf("CalculateTransition() error = %v", err)
+	}
+	source := built.DiscountRateSource
+	if source == nil || !source.CurveDate.Equal(mustParseDate(testDateLayout, "2023-12-29")) || math.Abs(source.TenorMonths-36) > 0.1 ||
+		math.Abs(built.DiscountRate-0.07) > 1e-4 || built.DiscountRate != source.Rate {
+		t.Errorf("Rate built at transition = %.4f from %+v, want 6%% + 1%% read at 36 months from the curve of 2023-12-29", built.DiscountRate, source)
+	}
+}
@@ -1,35 +1,555 @@
 package lease
 
-import "time"
+import (
+	"ifrs16_calculator/internal/money"
+	"sort"
+	"time"
+)
 
 // PaymentFrequency defines the possible frequencies for lease payments.
 type PaymentFrequency string
 
 const (
-	Monthly   PaymentFrequency = "Monthly"
-	Quarterly PaymentFrequency = "Quarterly"
-	Annually  PaymentFrequency = "Annually"
-	// Add other frequencies as needed (e.g., SemiAnnually)
+	Weekly       PaymentFrequency = "Weekly"
+	Fortnightly  PaymentFrequency = "Fortnightly"
+	Monthly      PaymentFrequency = "Monthly"
+	BiMonthly    PaymentFrequency = "BiMonthly" // Every two months
+	Quarterly    PaymentFrequency = "Quarterly"
+	SemiAnnually PaymentFrequency = "SemiAnnually"
+	Annually     PaymentFrequency = "Annually"
+	EveryNMonths PaymentFrequency = "EveryNMonths" // Every PaymentIntervalMonths months
+	Irregular    PaymentFrequency = "Irregular"    // Explicit dated payments in PaymentSchedule
+)
+
+// PaymentTiming defines whether regular payments fall due at the start or the end of each period.
+type PaymentTiming string
+
+const (
+	Arrears PaymentTiming = "Arrears" // Paid at the end of each period (the default)
+	Advance PaymentTiming = "Advance" // Paid at the start of each period, the first on the commencement date
+)
+
+// DayCountConvention defines how elapsed time is measured when discounting and accruing interest.
+type DayCountConvention string
+
+const (
+	Actual365Fixed DayCountConvention = "Actual/365F"   // Actual days over a 365-day year
+	Actual360      DayCountConvention = "Actual/360"    // Actual days over a 360-day year
+	Thirty360      DayCountConvention = "30/360"        // 30-day months over a 360-day year (bond basis)
+	ActualActual   DayCountConvention = "Actual/Actual" // Actual days within each payment period, or within each calendar year for an irregular schedule
+)
+
+// RateBasis defines how the annual discount rate is converted into a rate per payment period.
+type RateBasis string
+
+const (
+	NominalRate   RateBasis = "Nominal"   // Annual rate divided by the payment periods per year (the default)
+	EffectiveRate RateBasis = "Effective" // Annual effective rate, compounded once a year
+)
+
+// DepreciationMethod defines how the right-of-use asset is depreciated.
+type DepreciationMethod string
+
+const (
+	StraightLine       DepreciationMethod = "StraightLine"       // Evenly over each day (the default)
+	UnitsOfProduction  DepreciationMethod = "UnitsOfProduction"  // In proportion to the forecast use of the asset
+	DiminishingBalance DepreciationMethod = "DiminishingBalance" // A fixed annual rate of the carrying amount
+)
+
+// UsageEstimate is the forecast use of the underlying asset, such as machine hours or
+// kilometres driven, from the day after the previous estimate (or the start date) up to and
+// including Date.
+type UsageEstimate struct {
+	Date  time.Time `json:"date"`
+	Units float64   `json:"units"`
+}
+
+// ExtraPaymentType labels the nature of a one-time lease payment.
+type ExtraPaymentType string
+
+const (
+	OtherPayment       ExtraPaymentType = "Other"
+	Prepayment         ExtraPaymentType = "Prepayment"
+	TerminationPenalty ExtraPaymentType = "TerminationPenalty"
+	KeyMoney           ExtraPaymentType = "KeyMoney"
 )
 
 // ExtraPayment represents a one-time payment for a lease
 type ExtraPayment struct {
-	Date   time.Time `json:"date"`
-	Amount float64   `json:"amount"`
+	Date   time.Time        `json:"date"`
+	Amount money.Amount     `json:"amount"`
+	Type   ExtraPaymentType `json:"type,omitempty"`
+	// Variable marks a variable payment that is expensed as incurred rather than
+	// included in the initial measurement of the lease liability.
+	Variable bool `json:"variable,omitempty"`
 }
 
+// InLiability reports whether the payment forms part of the lease liability for a lease
+// running from start to end. Payments made at or before the commencement date are not
+// part of the liability; they are included in the right-of-use asset instead.
+func (p ExtraPayment) InLiability(start, end time.Time) bool {
+	return !p.Variable && p.Date.After(start) && !p.Date.After(end)
+}
+
+// ScheduledPayment is a dated payment in an irregular payment calendar.
+type ScheduledPayment struct {
+	Date   time.Time    `json:"date"`
+	Amount money.Amount `json:"amount"`
+}
+
+// PaymentStep sets the regular payment for the periods starting on or after StartDate and
+// before EndDate. A zero EndDate leaves the step open to the end of the lease.
+type PaymentStep struct {
+	StartDate time.Time    `json:"startDate"`
+	EndDate   time.Time    `json:"endDate,omitempty"`
+	Amount    money.Amount `json:"amount"`
+}
+
+// NonLeaseComponent is a service paid for with the regular payments, such as a service charge
+// or maintenance, that does not convey a right to use the underlying asset (IFRS 16.12 and
+// B33). Each regular payment is split either by Amount, the fixed part of every payment that
+// pays for the component, or by the relative stand-alone prices of the components and the
+// lease (IFRS 16.13-14).
+type NonLeaseComponent struct {
+	Description     string       `json:"description,omitempty"`
+	Amount          money.Amount `json:"amount,omitempty"`          // Part of each regular payment for the component
+	StandAlonePrice money.Amount `json:"standAlonePrice,omitempty"` // Stand-alone price of the component
+}
+
+// OptionType distinguishes an option to extend a lease from an option to terminate it early.
+type OptionType string
+
+const (
+	ExtensionOption   OptionType = "Extension"   // Extends the lease to the option's end date (IFRS 16.18(a))
+	TerminationOption OptionType = "Termination" // Ends the lease early on the option's end date (IFRS 16.18(b))
+)
+
+// Option is an option to extend or terminate a lease. The lease term includes the periods
+// covered by an extension option the lessee is reasonably certain to exercise and ends on
+// the date of a termination option it is reasonably certain to exercise (IFRS 16.18-19).
+type Option struct {
+	Type          OptionType `json:"type"`
+	ExerciseStart time.Time  `json:"exerciseStart,omitempty"` // First day the option can be exercised
+	ExerciseEnd   time.Time  `json:"exerciseEnd,omitempty"`   // Last day the option can be exercised
+	EndDate       time.Time  `json:"endDate"`                 // End of the lease term when the option is exercised
+	// PaymentAmount is the regular payment over an extension; zero continues the payments in
+	// force. Penalty is payable on the end date when a termination option is exercised.
+	PaymentAmount     money.Amount `json:"paymentAmount,omitempty"`
+	Penalty           money.Amount `json:"penalty,omitempty"`
+	ReasonablyCertain bool         `json:"reasonablyCertain,omitempty"`
+}
+
+// ModificationType distinguishes a negotiated change to a lease from a remeasurement
+// triggered by the lease's own terms.
+type ModificationType string
+
+const (
+	ContractModification ModificationType = "Modification" // Change to the scope or consideration (IFRS 16.44-46)
+	IndexReview          ModificationType = "IndexReview"  // Change in payments from an index or rate (IFRS 16.42(b))
+	// Change in the assessment of an extension or termination option (IFRS 16.20 and 16.40(a))
+	OptionReassessment ModificationType = "OptionReassessment"
+	// Change in the amount expected to be payable under a residual value guarantee (IFRS 16.42(a))
+	ResidualValueReview ModificationType = "ResidualValueReview"
+)
+
+// Modification describes a change to the terms of a lease that takes effect after the
+// commencement date (IFRS 16.44-46). Zero-valued fields leave the corresponding term unchanged.
+type Modification struct {
+	Type                ModificationType `json:"type,omitempty"`                // Defaults to ContractModification
+	EffectiveDate       time.Time        `json:"effectiveDate"`                 // Date from which the modified terms apply
+	NewPaymentAmount    money.Amount     `json:"newPaymentAmount,omitempty"`    // Revised regular payment, replacing any steps or escalation
+	NewEndDate          time.Time        `json:"newEndDate,omitempty"`          // Revised end date of the lease term
+	RevisedDiscountRate float64          `json:"revisedDiscountRate,omitempty"` // Discount rate at the effective date, as a decimal
+	// ScopeChangePercent is the change in the right to use the underlying asset as a
+	// decimal, e.g. -0.25 when a quarter of the leased space is handed back.
+	ScopeChangePercent float64 `json:"scopeChangePercent,omitempty"`
+	// Option and OptionReasonablyCertain give the revised assessment of an option for an
+	// OptionReassessment. Options are numbered from 1 in the order of Lease.Options.
+	Option                  int  `json:"option,omitempty"`
+	OptionReasonablyCertain bool `json:"optionReasonablyCertain,omitempty"`
+	// NewResidualValue is the revised amount expected to be payable under a residual value
+	// guarantee; nil leaves it unchanged.
+	NewResidualValue *money.Amount `json:"newResidualValue,omitempty"`
+	Description      string        `json:"description,omitempty"`
+}
+
+// Impairment records the outcome of an IAS 36 impairment test of the right-of-use asset on
+// Date. Either RecoverableAmount is set, and the carrying amount is written down to it (or an
+// earlier impairment reversed when it is higher), or Loss gives the impairment loss directly,
+// negative for a reversal.
+type Impairment struct {
+	Date              time.Time     `json:"date"`
+	RecoverableAmount *money.Amount `json:"recoverableAmount,omitempty"`
+	Loss              money.Amount  `json:"loss,omitempty"`
+	Description       string        `json:"description,omitempty"`
+}
+
+// IndexLink describes regular payments that are revised by reference to an index such as CPI.
+// PaymentAmount is the payment at BaseValue; at each review it moves with the index,
+// limited per review by the optional floor and cap.
+type IndexLink struct {
+	IndexName    string   `json:"indexName"`
+	BaseValue    float64  `json:"baseValue"`       // Index value the PaymentAmount is based on
+	ReviewMonths int      `json:"reviewMonths"`    // Months between reviews, counted from the start date
+	Floor        *float64 `json:"floor,omitempty"` // Minimum change per review as a decimal, e.g. 0 for upward-only
+	Cap          *float64 `json:"cap,omitempty"`   // Maximum change per review as a decimal, e.g. 0.05
+}
+
+// IndexValue is a published value of an index.
+type IndexValue struct {
+	Date  time.Time `json:"date"`
+	Value float64   `json:"value"`
+}
+
+// IndexTable holds published index values by index name, each series sorted by date.
+type IndexTable map[string][]IndexValue
+
+// ValueAt returns the latest value of the named index published on or before date.
+func (t IndexTable) ValueAt(name string, date time.Time) (IndexValue, bool) {
+	var latest IndexValue
+	found := false
+	for _, v := range t[name] {
+		if v.Date.After(date) {
+			break
+		}
+		latest = v
+		found = true
+	}
+	return latest, found
+}
+
+// RateComponent identifies a building block of an incremental borrowing rate.
+type RateComponent string
+
+const (
+	RiskFreeRate       RateComponent = "RiskFree" // Risk-free yield curve of a currency
+	CreditSpread       RateComponent = "Spread"   // Credit spread of an entity borrowing in a currency
+	SecurityAdjustment RateComponent = "Security" // Adjustment for borrowing secured on a class of asset, usually negative
+)
+
+// CurvePoint is the annual rate, as a decimal, of a curve at a tenor.
+type CurvePoint struct {
+	TenorMonths float64 `json:"tenorMonths"`
+	Rate        float64 `json:"rate"`
+}
+
+// RateCurve is a term structure of rates published on Date, its points sorted by tenor. A
+// curve of a single point is flat.
+type RateCurve struct {
+	Date   time.Time    `json:"date"`
+	Points []CurvePoint `json:"points"`
+}
+
+// RateAt returns the rate of the curve at a tenor, interpolated linearly between its points
+// and held flat beyond the first and last.
+func (c RateCurve) RateAt(tenorMonths float64) float64 {
+	if len(c.Points) == 0 {
+		return 0
+	}
+	if tenorMonths <= c.Points[0].TenorMonths {
+		return c.Points[0].Rate
+	}
+	for i := 1; i < len(c.Points); i++ {
+		lower, upper := c.Points[i-1], c.Points[i]
+		if tenorMonths <= upper.TenorMonths {
+			weight := (tenorMonths - lower.TenorMonths) / (upper.TenorMonths - lower.TenorMonths)
+			return lower.Rate + weight*(upper.Rate-lower.Rate)
+		}
+	}
+	return c.Points[len(c.Points)-1].Rate
+}
+
+// RateCurveKey identifies a series of curves. Name is the entity of a credit spread or the
+// asset class of a security adjustment, "*" covering any; Currency is empty for a curve that
+// applies in every currency.
+type RateCurveKey struct {
+	Component RateComponent
+	Name      string
+	Currency  string
+}
+
+// RateCurves holds published curves by key, each series sorted by date.
+type RateCurves map[RateCurveKey][]RateCurve
+
+// CurveAt returns the latest curve of a series published on or before date.
+func (c RateCurves) CurveAt(key RateCurveKey, date time.Time) (RateCurve, bool) {
+	var latest RateCurve
+	found := false
+	for _, curve := range c[key] {
+		if curve.Date.After(date) {
+			break
+		}
+		latest = curve
+		found = true
+	}
+	return latest, found
+}
+
+// RateProvenance records how a discount rate built from yield curves was derived: the
+// risk-free rate of the lease currency at the tenor, plus the credit spread of the entity and
+// the adjustment for security over the asset class, read from the curves dated on or before
+// the start date.
+type RateProvenance struct {
+	CurveDate          time.Time `json:"curveDate"`                    // Date of the risk-free curve
+	Currency           string    `json:"currency"`                     // Currency of the risk-free curve and spread
+	Entity             string    `json:"entity,omitempty"`             // Entity whose credit spread was added
+	TenorBasis         string    `json:"tenorBasis"`                   // Whether the tenor is the lease term or the weighted-average payment duration
+	TenorMonths        float64   `json:"tenorMonths"`                  // Tenor the curves were read at
+	RiskFreeRate       float64   `json:"riskFreeRate"`                 // Interpolated risk-free rate
+	CreditSpread       float64   `json:"creditSpread"`                 // Interpolated credit spread of the entity in the currency
+	SecurityAdjustment float64   `json:"securityAdjustment,omitempty"` // Interpolated adjustment for the asset class
+	Rate               float64   `json:"rate"`                         // Discount rate derived
+}
+
+// Role distinguishes a lease the entity holds as lessee from one it grants as lessor.
+type Role string
+
+const (
+	LesseeRole Role = "Lessee" // The entity has the right to use the underlying asset (the default)
+	LessorRole Role = "Lessor" // The entity provides the right to use the underlying asset (IFRS 16.61-97)
+)
+
+// Classification is a lessor's classification of a lease at its inception date (IFRS 16.61-66).
+type Classification string
+
+const (
+	FinanceLease   Classification = "Finance"   // Transfers substantially all the risks and rewards incidental to ownership
+	OperatingLease Classification = "Operating" // Does not transfer substantially all the risks and rewards incidental to ownership
+)
+
 // Lease represents the core data for an IFRS 16 lease agreement.
 type Lease struct {
-	ID                string           `json:"id" csv:"ID"`                             // Unique identifier for the lease
-	Description       string           `json:"description" csv:"Description"`           // Description of the lease
-	Lessor            string           `json:"lessor" csv:"Lessor"`                     // Name of the lessor
-	StartDate         time.Time        `json:"startDate" csv:"StartDate"`               // Commencement date of the lease
-	EndDate           time.Time        `json:"endDate" csv:"EndDate"`                   // End date of the lease term
-	PaymentAmount     float64          `json:"paymentAmount" csv:"PaymentAmount"`       // Amount of each regular lease payment
-	PaymentFrequency  PaymentFrequency `json:"paymentFrequency" csv:"PaymentFrequency"` // How often payments are made
-	DiscountRate      float64          `json:"discountRate" csv:"DiscountRate"`         // Annual discount rate (e.g., IBR), expressed as a decimal (e.g., 0.05 for 5%)
-	InitialDirectCost float64          `json:"initialDirectCost" csv:"InitialDirectCost"`
-	ResidualValue     float64          `json:"residualValue" csv:"ResidualValue"`
-	ExtraPayments     []ExtraPayment   `json:"extraPayments" csv:"ExtraPayments"`
-	// TODO: Add fields for Lease Incentives, Residual Value Guarantees, Purchase Options, etc.
+	ID               string           `json:"id" csv:"ID"`                             // Unique identifier for the lease
+	Description      string           `json:"description" csv:"Description"`           // Description of the lease
+	Lessor           string           `json:"lessor" csv:"Lessor"`                     // Name of the lessor
+	AssetClass       string           `json:"assetClass,omitempty" csv:"AssetClass"`   // Class of underlying asset, e.g. "Vehicles"
+	Currency         string           `json:"currency,omitempty" csv:"Currency"`       // Currency of the lease payments
+	StartDate        time.Time        `json:"startDate" csv:"StartDate"`               // Commencement date of the lease
+	EndDate          time.Time        `json:"endDate" csv:"EndDate"`                   // End date of the lease term
+	PaymentAmount    money.Amount     `json:"paymentAmount" csv:"PaymentAmount"`       // Amount of each regular lease payment
+	PaymentFrequency PaymentFrequency `json:"paymentFrequency" csv:"PaymentFrequency"` // How often payments are made
+	// PaymentIntervalMonths is the number of months between payments when PaymentFrequency is EveryNMonths.
+	PaymentIntervalMonths int           `json:"paymentIntervalMonths,omitempty" csv:"PaymentIntervalMonths"`
+	PaymentTiming         PaymentTiming `json:"paymentTiming" csv:"PaymentTiming"` // Whether payments are made in advance or in arrears
+	// PaymentSchedule lists every regular payment when PaymentFrequency is Irregular; PaymentAmount,
+	// PaymentTiming and the step, escalation and rent-free fields do not apply to it.
+	PaymentSchedule []ScheduledPayment `json:"paymentSchedule,omitempty" csv:"-"`
+	DiscountRate    float64            `json:"discountRate" csv:"DiscountRate"` // Annual discount rate (e.g., IBR), expressed as a decimal (e.g., 0.05 for 5%)
+	// DayCount and RateBasis set how DiscountRate is applied over time. When DayCount is empty,
+	// regular payments use Actual/Actual and an irregular payment schedule Actual/365F.
+	DayCount          DayCountConvention `json:"dayCount,omitempty" csv:"DayCount"`
+	RateBasis         RateBasis          `json:"rateBasis,omitempty" csv:"RateBasis"`
+	InitialDirectCost money.Amount       `json:"initialDirectCost" csv:"InitialDirectCost"`
+	LeaseIncentives   money.Amount       `json:"leaseIncentives" csv:"LeaseIncentives"` // Lease incentives received from the lessor at or before commencement
+	PrepaidRent       money.Amount       `json:"prepaidRent" csv:"PrepaidRent"`         // Rent paid before the commencement date
+	RestorationCost   money.Amount       `json:"restorationCost" csv:"RestorationCost"` // Estimated cost of dismantling, removal or restoration
+	// RestorationDiscountRate is the pre-tax rate used to discount the restoration provision (IAS 37.47).
+	// When set, RestorationCost is the undiscounted cost expected at EndDate; when zero it is taken
+	// as the provision already measured at commencement.
+	RestorationDiscountRate float64 `json:"restorationDiscountRate" csv:"RestorationDiscountRate"`
+	// UnderlyingAssetValue is the value of the underlying asset when new, used to assess
+	// whether it is of low value (IFRS 16.B3-B8).
+	UnderlyingAssetValue money.Amount `json:"underlyingAssetValue,omitempty" csv:"UnderlyingAssetValue"`
+	// ResidualValue is the amount the lessee expects to pay under a residual value guarantee,
+	// payable at the end of the lease term (IFRS 16.27(c)).
+	ResidualValue money.Amount   `json:"residualValue" csv:"ResidualValue"`
+	ExtraPayments []ExtraPayment `json:"extraPayments" csv:"ExtraPayments"`
+	// PaymentSteps, EscalationRate and RentFreeMonths vary the regular payment over the term;
+	// see PaymentForPeriod.
+	PaymentSteps     []PaymentStep  `json:"paymentSteps,omitempty" csv:"PaymentSteps"`
+	EscalationRate   float64        `json:"escalationRate,omitempty" csv:"EscalationRate"`     // Fixed increase per escalation period, as a decimal
+	EscalationMonths int            `json:"escalationMonths,omitempty" csv:"EscalationMonths"` // Months between escalations (defaults to 12)
+	RentFreeMonths   int            `json:"rentFreeMonths,omitempty" csv:"RentFreeMonths"`     // Months from commencement with no regular payment
+	IndexLink        *IndexLink     `json:"indexLink,omitempty" csv:"IndexName"`               // Set when payments are linked to an index
+	Modifications    []Modification `json:"modifications,omitempty" csv:"Modifications"`       // Changes to the lease after commencement
+	Impairments      []Impairment   `json:"impairments,omitempty" csv:"Impairments"`           // IAS 36 impairment tests of the RoU asset
+	// A purchase option the lessee is reasonably certain to exercise is included in the liability
+	// at its exercise price, payable on EndDate (IFRS 16.27(d)). When it is, or when ownership
+	// transfers by the end of the lease term, the RoU asset is depreciated over the useful life
+	// of the underlying asset instead of the lease term (IFRS 16.32).
+	PurchaseOptionPrice             money.Amount `json:"purchaseOptionPrice,omitempty" csv:"PurchaseOptionPrice"`
+	PurchaseOptionReasonablyCertain bool         `json:"purchaseOptionReasonablyCertain,omitempty" csv:"PurchaseOptionReasonablyCertain"`
+	OwnershipTransfer               bool         `json:"ownershipTransfer,omitempty" csv:"OwnershipTransfer"`
+	UsefulLifeMonths                int          `json:"usefulLifeMonths,omitempty" csv:"UsefulLifeMonths"` // Remaining useful life of the underlying asset from the start date
+	// DepreciationMethod sets how the RoU asset is depreciated. Units of production follows
+	// UsageForecast; the diminishing-balance method applies DiminishingBalanceRate a year.
+	DepreciationMethod     DepreciationMethod `json:"depreciationMethod,omitempty" csv:"DepreciationMethod"`
+	DiminishingBalanceRate float64            `json:"diminishingBalanceRate,omitempty" csv:"DiminishingBalanceRate"` // Annual rate as a decimal; defaults to double the straight-line rate
+	UsageForecast          []UsageEstimate    `json:"usageForecast,omitempty" csv:"UsageForecast"`
+	// Options to extend or terminate the lease. When a lease has options, EndDate is the lease
+	// term derived from them and ContractEndDate the end date before any option is exercised.
+	Options         []Option  `json:"options,omitempty" csv:"Options"`
+	ContractEndDate time.Time `json:"contractEndDate,omitempty" csv:"-"`
+	// NonLeaseComponents are paid for with the regular payments and kept out of the liability;
+	// see LeaseComponent. LeaseStandAlonePrice is the stand-alone price of the lease component
+	// when the payments are split by relative stand-alone price.
+	NonLeaseComponents   []NonLeaseComponent `json:"nonLeaseComponents,omitempty" csv:"NonLeaseComponents"`
+	LeaseStandAlonePrice money.Amount        `json:"leaseStandAlonePrice,omitempty" csv:"LeaseStandAlonePrice"`
+	// Role is LessorRole for a lease the entity grants. A lessor classifies the lease from the
+	// indicators of IFRS 16.63-64 unless Classification records its own judgement (IFRS 16.65).
+	// FairValue and CarryingAmount are those of the underlying asset at the commencement date;
+	// UnguaranteedResidualValue is the part of its residual value the lessor expects to realise
+	// that no party related to the lessee guarantees.
+	Role                      Role           `json:"role,omitempty" csv:"Role"`
+	Classification            Classification `json:"classification,omitempty" csv:"Classification"`
+	FairValue                 money.Amount   `json:"fairValue,omitempty" csv:"FairValue"`
+	CarryingAmount            money.Amount   `json:"carryingAmount,omitempty" csv:"CarryingAmount"`
+	UnguaranteedResidualValue money.Amount   `json:"unguaranteedResidualValue,omitempty" csv:"UnguaranteedResidualValue"`
+	// Indicators of a finance lease that depend on the facts of the lease (IFRS 16.63(e) and 16.64)
+	SpecialisedAsset              bool `json:"specialisedAsset,omitempty" csv:"SpecialisedAsset"`
+	LesseeBearsCancellationLosses bool `json:"lesseeBearsCancellationLosses,omitempty" csv:"LesseeBearsCancellationLosses"`
+	LesseeBearsResidualValueRisk  bool `json:"lesseeBearsResidualValueRisk,omitempty" csv:"LesseeBearsResidualValueRisk"`
+	BargainSecondaryPeriod        bool `json:"bargainSecondaryPeriod,omitempty" csv:"BargainSecondaryPeriod"`
+	// A lessor lease with a HeadLeaseID is a sublease of the right-of-use asset of that lease,
+	// which the entity holds as lessee. SubleasedShare is the part of the head lease's
+	// underlying asset it sublets, such as one floor of four; zero sublets all of it.
+	HeadLeaseID    string  `json:"headLeaseId,omitempty" csv:"HeadLeaseID"`
+	SubleasedShare float64 `json:"subleasedShare,omitempty" csv:"SubleasedShare"`
+	// A lessee lease with a SalePrice is the leaseback of an asset the entity sold to the
+	// lessor for that price at the start date; FairValue and CarryingAmount are then those of
+	// the asset sold (IFRS 16.98-103).
+	SalePrice money.Amount `json:"salePrice,omitempty" csv:"SalePrice"`
+	// Entity is the group entity that holds the lease, whose credit spread prices a discount
+	// rate built from yield curves; DiscountRateSource records how such a rate was built.
+	Entity             string          `json:"entity,omitempty" csv:"Entity"`
+	DiscountRateSource *RateProvenance `json:"discountRateSource,omitempty" csv:"-"`
+	// TransitionDiscountRate is the incremental borrowing rate at the date of initial application
+	// of IFRS 16, at which a lease in place then is measured under the modified retrospective
+	// approach (IFRS 16.C8(a)).
+	TransitionDiscountRate float64 `json:"transitionDiscountRate,omitempty" csv:"TransitionDiscountRate"`
+}
+
+// PaymentForPeriod returns the regular payment for the payment period starting on periodStart.
+// Periods starting within the rent-free months owe nothing; otherwise the rent of an exercised
+// extension or a payment step covering the period start sets the amount, and failing that
+// PaymentAmount applies, escalated by EscalationRate for every full escalation period elapsed
+// since the start date.
+func (l Lease) PaymentForPeriod(periodStart time.Time) money.Amount {
+	if l.RentFreeMonths > 0 && periodStart.Before(l.StartDate.AddDate(0, l.RentFreeMonths, 0)) {
+		return 0
+	}
+
+	// An exercised extension with its own rent sets the payment over the extension
+	previousEnd := l.ContractEndDate
+	for _, extension := range l.ExercisedExtensions() {
+		if periodStart.After(previousEnd) && !periodStart.After(extension.EndDate) && extension.PaymentAmount > 0 {
+			return extension.PaymentAmount
+		}
+		previousEnd = extension.EndDate
+	}
+
+	for _, step := range l.PaymentSteps {
+		if !periodStart.Before(step.StartDate) && (step.EndDate.IsZero() || periodStart.Before(step.EndDate)) {
+			return step.Amount
+		}
+	}
+
+	if l.EscalationRate == 0 {
+		return l.PaymentAmount
+	}
+	months := l.EscalationMonths
+	if months <= 0 {
+		months = 12
+	}
+	factor := 1.0
+	for k := 1; !l.StartDate.AddDate(0, k*months, 0).After(periodStart); k++ {
+		factor *= 1 + l.EscalationRate
+	}
+	return l.PaymentAmount.Mul(factor) // Escalated rents are payable to the cent
+}
+
+// LeaseComponent returns the part of a regular payment that pays for the right to use the
+// underlying asset. When the non-lease components have amounts, they are deducted from the
+// payment; otherwise the payment is allocated by relative stand-alone price, the lease
+// receiving LeaseStandAlonePrice over the total of the stand-alone prices. A payment with no
+// non-lease components, or nothing to allocate them by, is all lease component.
+func (l Lease) LeaseComponent(payment money.Amount) money.Amount {
+	if payment == 0 || len(l.NonLeaseComponents) == 0 {
+		return payment
+	}
+
+	var amounts, prices money.Amount
+	for _, c := range l.NonLeaseComponents {
+		amounts += c.Amount
+		prices += c.StandAlonePrice
+	}
+	if amounts != 0 {
+		return payment - amounts
+	}
+	if total := l.LeaseStandAlonePrice + prices; l.LeaseStandAlonePrice > 0 && total > 0 {
+		return payment.Share(int64(l.LeaseStandAlonePrice), int64(total))
+	}
+	return payment
+}
+
+// TransfersOwnership reports whether the lessee is expected to obtain ownership of the
+// underlying asset, either by transfer at the end of the lease term or by exercising a
+// purchase option it is reasonably certain to exercise.
+func (l Lease) TransfersOwnership() bool {
+	return l.OwnershipTransfer || l.PurchaseOptionReasonablyCertain
+}
+
+// ExercisedExtensions returns the extension options included in the lease term, in order of
+// their end dates. An extension counts only when the lessee is reasonably certain to exercise
+// it and every extension ending before it, as each continues from the one before.
+func (l Lease) ExercisedExtensions() []Option {
+	var extensions []Option
+	for _, o := range l.Options {
+		if o.Type == ExtensionOption {
+			extensions = append(extensions, o)
+		}
+	}
+	sort.SliceStable(extensions, func(i, j int) bool {
+		return extensions[i].EndDate.Before(extensions[j].EndDate)
+	})
+
+	for i, o := range extensions {
+		if !o.ReasonablyCertain {
+			return extensions[:i]
+		}
+	}
+	return extensions
+}
+
+// ExercisedTermination returns the termination option the lessee is reasonably certain to
+// exercise that ends the lease term, if any: the earliest one ending before the contract end
+// date extended by the exercised extensions.
+func (l Lease) ExercisedTermination() (Option, bool) {
+	end := l.ContractEndDate
+	if extensions := l.ExercisedExtensions(); len(extensions) > 0 {
+		end = extensions[len(extensions)-1].EndDate
+	}
+
+	var termination Option
+	found := false
+	for _, o := range l.Options {
+		if o.Type != TerminationOption || !o.ReasonablyCertain || !o.EndDate.Before(end) {
+			continue
+		}
+		if !found || o.EndDate.Before(termination.EndDate) {
+			termination, found = o, true
+		}
+	}
+	return termination, found
+}
+
+// IsLessor reports whether the entity is the lessor of the lease.
+func (l Lease) IsLessor() bool {
+	return l.Role == LessorRole
+}
+
+// IsLeaseback reports whether the entity leases back an asset it sold to the lessor.
+func (l Lease) IsLeaseback() bool {
+	return !l.IsLessor() && l.SalePrice > 0
+}
+
+// IsSublease reports whether the entity grants the lease as intermediate lessor of a head
+// lease it holds.
+func (l Lease) IsSublease() bool {
+	return l.IsLessor() && l.HeadLeaseID != ""
+}
+
+// PaysInAdvance reports whether regular payments fall due at the start of each period.
+func (l Lease) PaysInAdvance() bool {
+	return l.PaymentTiming == Advance
 }
@@ -0,0 +1,170 @@
+// Package money provides the fixed-point currency amount used by every lease, schedule and
+// result, so that balances carried from one entry to the next add up exactly.
+//
+// # Rounding policy
+//
+// An Amount is a whole number of cents. Amounts read from input are parsed exactly; input
+// with more than two decimal places is rounded to the cent. Amounts that have to be computed
+// (present values, interest, depreciation, unwinding, escalated or indexed rents) are worked
+// out in floating point and rounded to the cent once, when they are booked. Rounding is half
+// away from zero (commercial rounding, as used by a spreadsheet's ROUND), not banker's
+// rounding, so results can be reproduced in a spreadsheet.
+//
+// Booked amounts are never rounded again. Balances are carried forward as the opening balance
+// plus the booked movements, so opening + remeasurement + interest + expense + income -
+// payment - impairment - depreciation equals the closing balance of every schedule entry
+// exactly, and totals over any period are plain sums. A series of accruals carries each rounding difference
+// into the next (see Accrual), and an amount spread over several entries is allocated
+// cumulatively (see Share), so rounding never accumulates beyond half a cent.
+package money
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is a currency amount held as a whole number of cents.
+type Amount int64
+
+const (
+	Cent Amount = 1   // The smallest amount that can be booked
+	Unit Amount = 100 // One unit of currency
+)
+
+// FromFloat converts a computed amount to the nearest cent, rounding half away from zero.
+func FromFloat(v float64) Amount {
+	return Amount(math.Round(v * 100))
+}
+
+// Parse reads a decimal amount such as "1234.56" or "-0.5". Digits beyond the second decimal
+// place are rounded half away from zero. Other forms accepted by strconv.ParseFloat, such as
+// exponents, are converted with FromFloat.
+func Parse(s string) (Amount, error) {
+	trimmed := strings.TrimSpace(s)
+	units, fraction, _ := strings.Cut(strings.TrimLeft(trimmed, "+-"), ".")
+	if !isDigits(units) || !isDigits(fraction) || units+fraction == "" {
+		v, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil {
+			return 0, err
+		}
+		return FromFloat(v), nil
+	}
+
+	cents, rest := (fraction + "00")[:2], ""
+	if len(fraction) > 2 {
+		rest = fraction[2:]
+	}
+	v, err := strconv.ParseInt(units+cents, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if rest != "" && rest[0] >= '5' {
+		v++ // Half away from zero; the sign is applied below
+	}
+	if strings.HasPrefix(trimmed, "-") {
+		v = -v
+	}
+	return Amount(v), nil
+}
+
+// isDigits reports whether s consists of ASCII digits only. The empty string qualifies.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Float64 returns the amount in units of currency.
+func (a Amount) Float64() float64 {
+	return float64(a) / 100
+}
+
+// String formats the amount with two decimal places, e.g. "-1234.50".
+func (a Amount) String() string {
+	sign := ""
+	if a < 0 {
+		sign, a = "-", -a
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, a/Unit, a%Unit)
+}
+
+// Abs returns the absolute value of the amount.
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// Mul multiplies the amount by a factor and rounds the product to the cent.
+func (a Amount) Mul(factor float64) Amount {
+	return FromFloat(a.Float64() * factor)
+}
+
+// Share returns numerator/denominator of the amount, rounded half away from zero. Booking
+// a.Share(k, n) - a.Share(k-1, n) for k = 1..n spreads the amount over n entries that add
+// up to it exactly.
+func (a Amount) Share(numerator, denominator int64) Amount {
+	if denominator == 0 {
+		return 0
+	}
+	if denominator < 0 {
+		numerator, denominator = -numerator, -denominator
+	}
+	product := int64(a) * numerator
+	quotient, remainder := product/denominator, product%denominator
+	if 2*absInt64(remainder) >= denominator {
+		if product < 0 {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+	return Amount(quotient)
+}
+
+func absInt64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimal places.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON reads the amount from a JSON number or a quoted decimal.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" || text == "" {
+		return nil
+	}
+	v, err := Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid amount %s: %w", data, err)
+	}
+	*a = v
+	return nil
+}
+
+// Accrual books a running series of computed amounts, such as daily interest, to the cent.
+// The rounding difference of each booking is carried into the next, so the booked amounts
+// stay within half a cent of the exact running total. The zero value is ready to use.
+type Accrual struct {
+	carry float64
+}
+
+// Book rounds v, plus the difference carried from earlier bookings, to the cent.
+func (r *Accrual) Book(v float64) Amount {
+	v += r.carry
+	booked := FromFloat(v)
+	r.carry = v - booked.Float64()
+	return booked
+}
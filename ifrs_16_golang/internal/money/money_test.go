@@ -0,0 +1,129 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Amount
+		wantErr bool
+	}{
+		{input: "1234.56", want: 123456},
+		{input: " 1000 ", want: 100000},
+		{input: "0.5", want: 50},
+		{input: ".75", want: 75},
+		{input: "-12.3", want: -1230},
+		{input: "2.345", want: 235}, // Half away from zero, exactly
+		{input: "-2.345", want: -235},
+		{input: "2.3449", want: 234},
+		{input: "1.005", want: 101}, // 1.005 has no exact float representation
+		{input: "1e3", want: 100000},
+		{input: "", wantErr: true},
+		{input: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	tests := []struct {
+		input float64
+		want  Amount
+	}{
+		{11681.224, 1168122},
+		{0.125, 13},
+		{-0.125, -13},
+		{2.5, 250},
+	}
+
+	for _, tt := range tests {
+		if got := FromFloat(tt.input); got != tt.want {
+			t.Errorf("FromFloat(%v) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		input Amount
+		want  string
+	}{
+		{123456, "1234.56"},
+		{5, "0.05"},
+		{-1230, "-12.30"},
+		{0, "0.00"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.input.String(); got != tt.want {
+			t.Errorf("String() = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestShareAddsUpExactly(t *testing.T) {
+	total := FromFloat(11681.22)
+	days := int64(366)
+
+	sum := Amount(0)
+	for day := int64(1); day <= days; day++ {
+		portion := total.Share(day, days) - total.Share(day-1, days)
+		if portion < 3191 || portion > 3192 {
+			t.Errorf("Day %d portion = %s, want 31.91 or 31.92", day, portion)
+		}
+		sum += portion
+	}
+	if sum != total {
+		t.Errorf("Portions add up to %s, want %s", sum, total)
+	}
+
+	if got := Amount(-100).Share(1, 3); got != -33 {
+		t.Errorf("Share of a negative amount = %s, want -0.33", got)
+	}
+}
+
+func TestAccrualCarriesRounding(t *testing.T) {
+	var accrual Accrual
+	total := Amount(0)
+	for i := 0; i < 1000; i++ {
+		total += accrual.Book(0.004)
+	}
+	if total != 400 {
+		t.Errorf("1000 bookings of 0.004 = %s, want 4.00", total)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Amount Amount `json:"amount"`
+	}{FromFloat(-1234.5)})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(data) != `{"amount":-1234.50}` {
+		t.Errorf("Marshal() = %s", data)
+	}
+
+	var decoded struct {
+		Amount Amount `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount":11681.22}`), &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.Amount != 1168122 {
+		t.Errorf("Unmarshal() = %s, want 11681.22", decoded.Amount)
+	}
+}
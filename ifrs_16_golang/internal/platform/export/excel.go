@@ -3,6 +3,8 @@ package export
 import (
 	"fmt"
 	"ifrs16_calculator/internal/calculation"
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
 	"log"
 	"time"
 
@@ -11,28 +13,85 @@ import (
 
 // LeaseResultExport contains all calculation results for a single lease
 type LeaseResultExport struct {
-	LeaseID           string
-	StartDate         time.Time
-	EndDate           time.Time
-	PaymentAmount     float64
-	PaymentFrequency  string
-	DiscountRate      float64
-	InitialLiability  float64
-	InitialRoUAsset   float64
-	LiabilitySchedule []calculation.AmortizationEntry
-	RoUAssetSchedule  []calculation.AmortizationEntry
+	LeaseID              string
+	AssetClass           string
+	Exemption            string // Recognition exemption applied, if the lease is not recognised
+	StartDate            time.Time
+	EndDate              time.Time
+	ContractEndDate      time.Time      // End date before extension and termination options, if the lease has any
+	Options              []lease.Option // Extension and termination options as last assessed
+	DepreciationEndDate  time.Time      // Last day the RoU asset is depreciated, if known
+	DepreciationMethod   string         // How the RoU asset is depreciated; straight-line if empty
+	PaymentAmount        money.Amount
+	PaymentFrequency     string
+	PaymentTiming        string
+	DayCountConvention   string
+	RateBasis            string
+	ScheduleGranularity  string // Span covered by each schedule entry
+	DiscountRate         float64
+	InitialLiability     money.Amount
+	InitialRoUAsset      money.Amount
+	RoUAssetComponents   *calculation.RoUAssetComponents // Breakdown of the initial RoU asset, if available
+	SaleAndLeaseback     *calculation.SaleAndLeaseback   // Sale of the asset a leaseback leases back
+	LiabilitySchedule    []calculation.AmortizationEntry
+	RoUAssetSchedule     []calculation.AmortizationEntry
+	RestorationProvision money.Amount                       // Initial IAS 37 restoration provision
+	ResidualGuarantee    money.Amount                       // Amount initially expected to be payable under a residual value guarantee
+	RestorationSchedule  []calculation.AmortizationEntry    // Unwinding of the restoration provision
+	Remeasurements       []calculation.Remeasurement        // Modifications applied after commencement
+	Impairments          []calculation.ImpairmentAdjustment // IAS 36 impairment losses and reversals of the RoU asset
+	ExpenseSchedule      []calculation.AmortizationEntry    // Straight-line expense of an exempt lease
+	NonLeasePayments     []lease.ScheduledPayment           // Parts of the payments for non-lease components, expensed
+	ComponentsCombined   bool                               // Non-lease components accounted for with the lease (IFRS 16.15)
+	// Lessor accounting; DiscountRate is then the rate implicit in a finance lease
+	Role                  string                          // "Lessor" for a lease the entity grants
+	Classification        string                          // Finance or Operating
+	Indicators            []string                        // Indicators of a finance lease met (IFRS 16.63-64)
+	NetInvestment         money.Amount                    // Net investment in a finance lease at commencement
+	NetInvestmentSchedule []calculation.AmortizationEntry // Finance income and receipts on the net investment
+	DerecognitionGainLoss money.Amount                    // Gain or loss on derecognising the asset of a finance lease
+	IncomeSchedule        []calculation.AmortizationEntry // Straight-line income of an operating lease
+	AssetSchedule         []calculation.AmortizationEntry // Depreciation of the asset under an operating lease
+	HeadLeaseID           string                          // Head lease of a sublease
+	SubleasedRoUAsset     money.Amount                    // Head lease's RoU asset derecognised under a finance sublease
+	// Measurement at the date of initial application of IFRS 16, for a lease in place then
+	Transition *calculation.TransitionAdjustment
+	// Yield curves and spreads a discount rate was built from, if it was not entered
+	DiscountRateSource *lease.RateProvenance
 	// 账期摘要信息
-	AccountingPeriodStart  string  // 账期开始日期
-	AccountingPeriodEnd    string  // 账期结束日期
-	PeriodLiabilityStart   float64 // 账期期初负债
-	PeriodLiabilityEnd     float64 // 账期期末负债
-	PeriodRoUAssetStart    float64 // 账期期初使用权资产
-	PeriodRoUAssetEnd      float64 // 账期期末使用权资产
-	PeriodInterestExpense  float64 // 账期内利息费用总额
-	PeriodDepreciation     float64 // 账期内折旧费用总额
-	PeriodPayments         float64 // 账期内付款总额
-	PeriodPrincipalPayment float64 // 账期内本金偿还总额
-	LeaseTerm              float64 // 租赁期(年)
+	AccountingPeriodStart        string       // 账期开始日期
+	AccountingPeriodEnd          string       // 账期结束日期
+	PeriodLiabilityStart         money.Amount // 账期期初负债
+	PeriodLiabilityEnd           money.Amount // 账期期末负债
+	PeriodRoUAssetStart          money.Amount // 账期期初使用权资产
+	PeriodRoUAssetEnd            money.Amount // 账期期末使用权资产
+	PeriodInterestExpense        money.Amount // 账期内利息费用总额
+	PeriodDepreciation           money.Amount // 账期内折旧费用总额
+	PeriodPayments               money.Amount // 账期内付款总额
+	PeriodPrincipalPayment       money.Amount // 账期内本金偿还总额
+	PeriodInterestPaid           money.Amount // 账期内付款中支付的利息
+	PeriodVariablePayments       money.Amount // 账期内计入费用的可变租赁付款额
+	PeriodExemptLeaseExpense     money.Amount // 账期内豁免租赁的直线法租赁费用
+	PeriodNonLeaseExpense        money.Amount // 账期内非租赁组成部分计入的经营费用
+	PeriodProvisionStart         money.Amount // 账期期初复原准备金
+	PeriodProvisionEnd           money.Amount // 账期期末复原准备金
+	PeriodProvisionUnwinding     money.Amount // 账期内复原准备金折现摊销
+	PeriodLiabilityRemeasurement money.Amount // 账期内租赁负债重新计量调整
+	PeriodRoUAssetRemeasurement  money.Amount // 账期内使用权资产调整
+	PeriodRemeasurementGainLoss  money.Amount // 账期内租赁变更损益
+	PeriodLeasebackGainLoss      money.Amount // 账期内售后租回损益
+	PeriodImpairment             money.Amount // 账期内使用权资产减值损失(转回为负数)
+	PeriodImpairmentStart        money.Amount // 账期期初累计减值准备
+	PeriodImpairmentEnd          money.Amount // 账期期末累计减值准备
+	PeriodNetInvestmentStart     money.Amount // 账期期初融资租赁投资净额
+	PeriodNetInvestmentEnd       money.Amount // 账期期末融资租赁投资净额
+	PeriodFinanceIncome          money.Amount // 账期内融资租赁利息收入
+	PeriodOperatingLeaseIncome   money.Amount // 账期内经营租赁收入
+	PeriodLeaseReceipts          money.Amount // 账期内租赁收款额
+	PeriodAssetStart             money.Amount // 账期期初经营租出资产账面价值
+	PeriodAssetEnd               money.Amount // 账期期末经营租出资产账面价值
+	PeriodAssetDepreciation      money.Amount // 账期内经营租出资产折旧
+	LeaseTerm                    float64      // 租赁期(年)
 }
 
 // ExportToExcel creates an Excel file with the calculation results
@@ -72,17 +131,28 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 	}
 	f.SetCellStyle(summarySheet, "A1", string(rune('A'+len(headers)-1))+"1", headerStyle)
 
-	// Add data to summary sheet
-	for i, result := range results {
-		row := i + 2 // Row 1 is for headers
+	// Add data to summary sheet; leases under a recognition exemption and leases the entity
+	// grants as lessor are listed separately
+	var exempt, lessor []LeaseResultExport
+	row := 1 // Row 1 is for headers
+	for _, result := range results {
+		if result.Exemption != "" {
+			exempt = append(exempt, result)
+			continue
+		}
+		if result.Role == string(lease.LessorRole) {
+			lessor = append(lessor, result)
+			continue
+		}
+		row++
 		f.SetCellValue(summarySheet, fmt.Sprintf("A%d", row), result.LeaseID)
 		f.SetCellValue(summarySheet, fmt.Sprintf("B%d", row), result.StartDate.Format("2006-01-02"))
 		f.SetCellValue(summarySheet, fmt.Sprintf("C%d", row), result.EndDate.Format("2006-01-02"))
-		f.SetCellValue(summarySheet, fmt.Sprintf("D%d", row), result.PaymentAmount)
+		f.SetCellValue(summarySheet, fmt.Sprintf("D%d", row), result.PaymentAmount.Float64())
 		f.SetCellValue(summarySheet, fmt.Sprintf("E%d", row), result.PaymentFrequency)
 		f.SetCellValue(summarySheet, fmt.Sprintf("F%d", row), result.DiscountRate)
-		f.SetCellValue(summarySheet, fmt.Sprintf("G%d", row), result.InitialLiability)
-		f.SetCellValue(summarySheet, fmt.Sprintf("H%d", row), result.InitialRoUAsset)
+		f.SetCellValue(summarySheet, fmt.Sprintf("G%d", row), result.InitialLiability.Float64())
+		f.SetCellValue(summarySheet, fmt.Sprintf("H%d", row), result.InitialRoUAsset.Float64())
 	}
 
 	// Format numeric cells
@@ -92,8 +162,87 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 	if err != nil {
 		log.Printf("Warning: Failed to create number style: %v", err)
 	}
-	f.SetCellStyle(summarySheet, "D2", fmt.Sprintf("D%d", len(results)+1), numStyle)
-	f.SetCellStyle(summarySheet, "F2", fmt.Sprintf("H%d", len(results)+1), numStyle)
+	if row > 1 {
+		f.SetCellStyle(summarySheet, "D2", fmt.Sprintf("D%d", row), numStyle)
+		f.SetCellStyle(summarySheet, "F2", fmt.Sprintf("H%d", row), numStyle)
+	}
+
+	// Exempt leases are expensed straight-line and carry no liability or RoU asset (IFRS 16.6)
+	tableRow := row + 2
+	if len(exempt) > 0 {
+		exemptRow := tableRow
+		f.SetCellValue(summarySheet, fmt.Sprintf("A%d", exemptRow), "Exempt Leases (IFRS 16.5-8)")
+		exemptHeaders := []string{"Lease ID", "Asset Class", "Exemption", "Start Date", "End Date",
+			"Payment", "Frequency", "Total Lease Expense", "Period Lease Expense"}
+		for i, header := range exemptHeaders {
+			cell := fmt.Sprintf("%c%d", 'A'+i, exemptRow+1)
+			f.SetCellValue(summarySheet, cell, header)
+		}
+		exemptHeaderRange := fmt.Sprintf("A%d:%c%d", exemptRow+1, 'A'+len(exemptHeaders)-1, exemptRow+1)
+		f.SetCellStyle(summarySheet, exemptHeaderRange, exemptHeaderRange, headerStyle)
+
+		for i, result := range exempt {
+			row := exemptRow + 2 + i
+			var totalExpense money.Amount
+			for _, entry := range result.ExpenseSchedule {
+				totalExpense += entry.Expense
+			}
+			f.SetCellValue(summarySheet, fmt.Sprintf("A%d", row), result.LeaseID)
+			f.SetCellValue(summarySheet, fmt.Sprintf("B%d", row), result.AssetClass)
+			f.SetCellValue(summarySheet, fmt.Sprintf("C%d", row), exemptionLabel(result.Exemption))
+			f.SetCellValue(summarySheet, fmt.Sprintf("D%d", row), result.StartDate.Format("2006-01-02"))
+			f.SetCellValue(summarySheet, fmt.Sprintf("E%d", row), result.EndDate.Format("2006-01-02"))
+			f.SetCellValue(summarySheet, fmt.Sprintf("F%d", row), result.PaymentAmount.Float64())
+			f.SetCellValue(summarySheet, fmt.Sprintf("G%d", row), result.PaymentFrequency)
+			f.SetCellValue(summarySheet, fmt.Sprintf("H%d", row), totalExpense.Float64())
+			if result.AccountingPeriodStart != "" && result.AccountingPeriodEnd != "" {
+				f.SetCellValue(summarySheet, fmt.Sprintf("I%d", row), result.PeriodExemptLeaseExpense.Float64())
+			}
+		}
+		exemptDataRange := fmt.Sprintf("H%d:I%d", exemptRow+2, exemptRow+1+len(exempt))
+		f.SetCellStyle(summarySheet, exemptDataRange, exemptDataRange, numStyle)
+		f.SetCellStyle(summarySheet, fmt.Sprintf("F%d", exemptRow+2), fmt.Sprintf("F%d", exemptRow+1+len(exempt)), numStyle)
+		f.SetColWidth(summarySheet, "I", "I", 15)
+		tableRow = exemptRow + len(exempt) + 3
+	}
+
+	// Leases granted as lessor are finance leases carried as a net investment or operating
+	// leases earning straight-line income (IFRS 16.61-97)
+	if len(lessor) > 0 {
+		lessorRow := tableRow
+		f.SetCellValue(summarySheet, fmt.Sprintf("A%d", lessorRow), "Lessor Leases (IFRS 16.61-97)")
+		lessorHeaders := []string{"Lease ID", "Asset Class", "Classification", "Start Date", "End Date",
+			"Payment", "Frequency", "Implicit Rate", "Net Investment", "Period Lease Income", "Head Lease"}
+		for i, header := range lessorHeaders {
+			cell := fmt.Sprintf("%c%d", 'A'+i, lessorRow+1)
+			f.SetCellValue(summarySheet, cell, header)
+		}
+		lessorHeaderRange := fmt.Sprintf("A%d:%c%d", lessorRow+1, 'A'+len(lessorHeaders)-1, lessorRow+1)
+		f.SetCellStyle(summarySheet, lessorHeaderRange, lessorHeaderRange, headerStyle)
+
+		for i, result := range lessor {
+			row := lessorRow + 2 + i
+			f.SetCellValue(summarySheet, fmt.Sprintf("A%d", row), result.LeaseID)
+			f.SetCellValue(summarySheet, fmt.Sprintf("B%d", row), result.AssetClass)
+			f.SetCellValue(summarySheet, fmt.Sprintf("C%d", row), classificationLabel(result.Classification))
+			f.SetCellValue(summarySheet, fmt.Sprintf("D%d", row), result.StartDate.Format("2006-01-02"))
+			f.SetCellValue(summarySheet, fmt.Sprintf("E%d", row), result.EndDate.Format("2006-01-02"))
+			f.SetCellValue(summarySheet, fmt.Sprintf("F%d", row), result.PaymentAmount.Float64())
+			f.SetCellValue(summarySheet, fmt.Sprintf("G%d", row), result.PaymentFrequency)
+			if result.Classification == string(lease.FinanceLease) {
+				f.SetCellValue(summarySheet, fmt.Sprintf("H%d", row), result.DiscountRate)
+				f.SetCellValue(summarySheet, fmt.Sprintf("I%d", row), result.NetInvestment.Float64())
+			}
+			if result.AccountingPeriodStart != "" && result.AccountingPeriodEnd != "" {
+				f.SetCellValue(summarySheet, fmt.Sprintf("J%d", row), (result.PeriodFinanceIncome + result.PeriodOperatingLeaseIncome).Float64())
+			}
+			f.SetCellValue(summarySheet, fmt.Sprintf("K%d", row), result.HeadLeaseID)
+		}
+		lastLessorRow := lessorRow + 1 + len(lessor)
+		f.SetCellStyle(summarySheet, fmt.Sprintf("F%d", lessorRow+2), fmt.Sprintf("F%d", lastLessorRow), numStyle)
+		f.SetCellStyle(summarySheet, fmt.Sprintf("H%d", lessorRow+2), fmt.Sprintf("J%d", lastLessorRow), numStyle)
+		f.SetColWidth(summarySheet, "I", "J", 15)
+	}
 
 	// Auto-fit columns (approximate method since excelize doesn't have direct auto-fit)
 	for i := range headers {
@@ -101,6 +250,19 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 		f.SetColWidth(summarySheet, col, col, 15)
 	}
 
+	// Leases in place at the date of initial application are reconciled on a sheet of their own
+	var transitions []LeaseResultExport
+	for _, result := range results {
+		if result.Transition != nil {
+			transitions = append(transitions, result)
+		}
+	}
+	if len(transitions) > 0 {
+		if _, err := f.NewSheet("Transition"); err == nil {
+			writeTransitionSheet(f, "Transition", transitions, headerStyle, numStyle)
+		}
+	}
+
 	// Create detail sheets for each lease
 	for i, result := range results {
 		// Create sheet for this lease
@@ -116,6 +278,15 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 			continue // Skip this sheet if there's an error
 		}
 
+		if result.Exemption != "" {
+			writeExemptLeaseSheet(f, sheetName, result, headerStyle, numStyle)
+			continue
+		}
+		if result.Role == string(lease.LessorRole) {
+			writeLessorLeaseSheet(f, sheetName, result, headerStyle, numStyle)
+			continue
+		}
+
 		// 检查是否有账期摘要信息,如果有则优先添加到最上方
 		hasAccountingPeriod := result.AccountingPeriodStart != "" && result.AccountingPeriodEnd != ""
 		baseRow := 1 // 基础行号,如果有账期摘要则后续内容向下移动
@@ -140,11 +311,22 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 			// 计算租赁负债变动的组成部分
 			principalPayment := result.PeriodPrincipalPayment // 使用已计算好的本金偿还金额
 
-			// 计算RoU资产的累计折旧(期初资产价值 - 期初账面价值)
-			accumulatedDepreciation := result.InitialRoUAsset - result.PeriodRoUAssetStart
+			// 使用权资产原值包含账期开始前的租赁变更调整
+			startCost := result.InitialRoUAsset
+			if periodStart, err := time.Parse("2006-01-02", result.AccountingPeriodStart); err == nil {
+				for _, r := range result.Remeasurements {
+					if r.EffectiveDate.Before(periodStart) {
+						startCost += r.RoUAssetAfter - r.RoUAssetBefore
+					}
+				}
+			}
+			endCost := startCost + result.PeriodRoUAssetRemeasurement
+
+			// 计算RoU资产的累计折旧(资产原值 - 累计减值准备 - 账面价值)
+			accumulatedDepreciation := startCost - result.PeriodImpairmentStart - result.PeriodRoUAssetStart
 
 			// 计算期末累计折旧
-			endAccumulatedDepreciation := accumulatedDepreciation + result.PeriodDepreciation
+			endAccumulatedDepreciation := endCost - result.PeriodImpairmentEnd - result.PeriodRoUAssetEnd
 
 			// 主要财务指标表格
 			// 第一列: 项目名称
@@ -152,11 +334,19 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 				"项目",
 				"使用权资产原值",
 				"累计折旧",
+				"累计减值准备",
 				"使用权资产账面价值",
 				"租赁负债",
-				"本期折旧费用",
+				"复原准备金",
+				fmt.Sprintf("本期折旧费用(%s)", depreciationMethodLabel(result.DepreciationMethod)),
+				"本期减值损失",
 				"本期利息费用",
-				"本期费用支出合计", // 新增：费用支出合计 = 折旧费用 + 利息费用
+				"本期准备金折现摊销",
+				"本期可变租赁付款额",
+				"本期非租赁组成部分费用",
+				"本期租赁变更损益",
+				"本期售后租回损益",
+				"本期费用支出合计", // 费用支出合计 = 折旧费用 + 减值损失 + 利息费用 + 准备金折现摊销 + 可变租赁付款额 + 非租赁组成部分费用
 				"本期支付的租金",
 				"其中：本金偿还",
 				"其中：利息支付",
@@ -165,10 +355,18 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 			// 第二列: 期初余额
 			startValues := []interface{}{
 				"期初余额",
-				result.InitialRoUAsset,
+				startCost,
 				accumulatedDepreciation,
+				result.PeriodImpairmentStart,
 				result.PeriodRoUAssetStart,
 				result.PeriodLiabilityStart,
+				result.PeriodProvisionStart,
+				"",
+				"",
+				"",
+				"",
+				"",
+				"",
 				"",
 				"",
 				"",
@@ -180,10 +378,18 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 			// 第三列: 期末余额
 			endValues := []interface{}{
 				"期末余额",
-				result.InitialRoUAsset, // 原值不变
+				endCost, // 原值仅因租赁变更而调整
 				endAccumulatedDepreciation,
+				result.PeriodImpairmentEnd,
 				result.PeriodRoUAssetEnd,
 				result.PeriodLiabilityEnd,
+				result.PeriodProvisionEnd,
+				"",
+				"",
+				"",
+				"",
+				"",
+				"",
 				"",
 				"",
 				"",
@@ -193,19 +399,28 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 			}
 
 			// 第四列: 本期发生额
-			totalExpense := result.PeriodDepreciation + result.PeriodInterestExpense // 计算总费用支出
+			totalExpense := result.PeriodDepreciation + result.PeriodImpairment + result.PeriodInterestExpense +
+				result.PeriodProvisionUnwinding + result.PeriodVariablePayments + result.PeriodNonLeaseExpense // 计算总费用支出
 			periodValues := []interface{}{
 				"本期发生额",
-				"",
-				result.PeriodDepreciation, // 本期新增的折旧
+				result.PeriodRoUAssetRemeasurement, // 租赁变更调整
+				result.PeriodDepreciation,          // 本期新增的折旧
+				result.PeriodImpairment,            // 本期减值损失(转回为负数)
 				result.PeriodRoUAssetEnd - result.PeriodRoUAssetStart,
 				result.PeriodLiabilityEnd - result.PeriodLiabilityStart,
+				result.PeriodProvisionEnd - result.PeriodProvisionStart,
 				result.PeriodDepreciation,
+				result.PeriodImpairment,
 				result.PeriodInterestExpense,
-				totalExpense, // 折旧费用 + 利息费用
+				result.PeriodProvisionUnwinding,
+				result.PeriodVariablePayments,
+				result.PeriodNonLeaseExpense,       // 服务费等非租赁组成部分,计入经营费用
+				result.PeriodRemeasurementGainLoss, // 正数为收益,负数为损失
+				result.PeriodLeasebackGainLoss,     // 仅转让给出租人的权利部分
+				totalExpense,                       // 折旧费用 + 减值损失 + 利息费用 + 准备金折现摊销 + 可变租赁付款额 + 非租赁组成部分费用
 				result.PeriodPayments,
 				principalPayment,
-				result.PeriodInterestExpense, // 利息支付等于利息费用
+				result.PeriodInterestPaid, // 付款中结清的已计提利息
 			}
 
 			// 写入表格数据
@@ -214,11 +429,11 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 				// 项目名称
 				f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), header)
 				// 期初值
-				f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), startValues[i])
+				f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), cellValue(startValues[i]))
 				// 期末值
-				f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), endValues[i])
+				f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), cellValue(endValues[i]))
 				// 本期发生额
-				f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), periodValues[i])
+				f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), cellValue(periodValues[i]))
 			}
 
 			// 设置列标题
@@ -250,31 +465,207 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 		}
 
 		// Add lease details below the summary (if any)
+		paymentTiming := result.PaymentTiming
+		if paymentTiming == "" {
+			paymentTiming = "Arrears"
+		}
+		depreciationEnd := result.DepreciationEndDate
+		if depreciationEnd.IsZero() {
+			depreciationEnd = result.EndDate
+		}
+		contractEnd := result.ContractEndDate
+		if contractEnd.IsZero() {
+			contractEnd = result.EndDate
+		}
+		depreciationMethod := result.DepreciationMethod
+		if depreciationMethod == "" {
+			depreciationMethod = string(lease.StraightLine)
+		}
+		details := []struct {
+			label string
+			value interface{}
+		}{
+			{"Lease ID:", result.LeaseID},
+			{"Start Date:", result.StartDate.Format("2006-01-02")},
+			{"End Date:", result.EndDate.Format("2006-01-02")},
+			{"Contract End Date:", contractEnd.Format("2006-01-02")},
+			{"Depreciation End Date:", depreciationEnd.Format("2006-01-02")},
+			{"Depreciation Method:", depreciationMethod},
+			{"Payment Amount:", result.PaymentAmount.Float64()},
+			{"Payment Frequency:", result.PaymentFrequency},
+			{"Payment Timing:", paymentTiming},
+			{"Discount Rate:", result.DiscountRate},
+			{"Discount Rate Source:", rateSourceLabel(result.DiscountRateSource)},
+			{"Day Count:", result.DayCountConvention},
+			{"Rate Basis:", result.RateBasis},
+			{"Schedule Granularity:", result.ScheduleGranularity},
+			{"Initial Lease Liability:", result.InitialLiability.Float64()},
+			{"Initial RoU Asset:", result.InitialRoUAsset.Float64()},
+			{"Residual Value Guarantee:", result.ResidualGuarantee.Float64()},
+			{"Non-Lease Components:", componentsLabel(result)},
+		}
 		f.SetCellValue(sheetName, fmt.Sprintf("A%d", baseRow), "Lease Details")
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", baseRow+1), "Lease ID:")
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", baseRow+1), result.LeaseID)
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", baseRow+2), "Start Date:")
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", baseRow+2), result.StartDate.Format("2006-01-02"))
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", baseRow+3), "End Date:")
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", baseRow+3), result.EndDate.Format("2006-01-02"))
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", baseRow+4), "Payment Amount:")
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", baseRow+4), result.PaymentAmount)
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", baseRow+5), "Payment Frequency:")
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", baseRow+5), result.PaymentFrequency)
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", baseRow+6), "Discount Rate:")
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", baseRow+6), result.DiscountRate)
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", baseRow+7), "Initial Lease Liability:")
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", baseRow+7), result.InitialLiability)
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", baseRow+8), "Initial RoU Asset:")
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", baseRow+8), result.InitialRoUAsset)
+		for i, detail := range details {
+			f.SetCellValue(sheetName, fmt.Sprintf("A%d", baseRow+1+i), detail.label)
+			f.SetCellValue(sheetName, fmt.Sprintf("B%d", baseRow+1+i), detail.value)
+		}
 
 		// 调整后续表格的位置
-		liabilityHeaderRow := baseRow + 10
+		liabilityHeaderRow := baseRow + len(details) + 2
+
+		// Show how the initial RoU asset was derived (IFRS 16.24)
+		if result.RoUAssetComponents != nil {
+			components := result.RoUAssetComponents
+			measurementRow := liabilityHeaderRow
+			measurement := []struct {
+				label string
+				value money.Amount
+			}{
+				{"Initial Lease Liability", components.LeaseLiability},
+				{"Add: Payments at or before Commencement", components.PaymentsAtCommencement},
+				{"Less: Lease Incentives Received", -components.LeaseIncentives},
+				{"Add: Initial Direct Costs", components.InitialDirectCosts},
+				{"Add: Restoration Costs", components.RestorationCosts},
+				{"Initial RoU Asset", components.Total},
+			}
+			title := "RoU Asset Measurement"
+			// A leaseback retains part of the carrying amount of the asset sold (IFRS 16.100-101)
+			if sale := result.SaleAndLeaseback; sale != nil {
+				title = "RoU Asset Measurement (Sale and Leaseback)"
+				measurement = []struct {
+					label string
+					value money.Amount
+				}{
+					{"Sale Price", sale.SalePrice},
+					{"Fair Value of the Asset Sold", sale.FairValue},
+					{"Carrying Amount of the Asset Sold", sale.CarryingAmount},
+					{"Prepayment of Lease Payments", sale.Prepayment},
+					{"Additional Financing", sale.AdditionalFinancing},
+					{"Present Value of the Lease Payments", sale.LeasePayments},
+					{"Rights Retained at Fair Value", sale.RightsRetained},
+					{"Retained Part of the Carrying Amount", components.RetainedCarryingAmount},
+					{"Add: Initial Direct Costs", components.InitialDirectCosts},
+					{"Add: Restoration Costs", components.RestorationCosts},
+					{"Initial RoU Asset", components.Total},
+					{"Gain/(Loss) on Rights Transferred", sale.GainLoss},
+				}
+			}
+			f.SetCellValue(sheetName, fmt.Sprintf("A%d", measurementRow), title)
+			for i, line := range measurement {
+				f.SetCellValue(sheetName, fmt.Sprintf("A%d", measurementRow+1+i), line.label)
+				f.SetCellValue(sheetName, fmt.Sprintf("B%d", measurementRow+1+i), line.value.Float64())
+			}
+			measurementRange := fmt.Sprintf("B%d:B%d", measurementRow+1, measurementRow+len(measurement))
+			f.SetCellStyle(sheetName, measurementRange, measurementRange, numStyle)
+			liabilityHeaderRow = measurementRow + len(measurement) + 2
+		}
+
+		// List the extension and termination options that decide the lease term (IFRS 16.18-21)
+		if len(result.Options) > 0 {
+			optionRow := liabilityHeaderRow
+			f.SetCellValue(sheetName, fmt.Sprintf("A%d", optionRow), "Lease Options")
+			optionHeaders := []string{"Option", "Type", "Exercise From", "Exercise To", "End Date",
+				"Payment", "Penalty", "Reasonably Certain"}
+			for i, header := range optionHeaders {
+				cell := fmt.Sprintf("%c%d", 'A'+i, optionRow+1)
+				f.SetCellValue(sheetName, cell, header)
+			}
+			optionHeaderRange := fmt.Sprintf("A%d:%c%d", optionRow+1, 'A'+len(optionHeaders)-1, optionRow+1)
+			f.SetCellStyle(sheetName, optionHeaderRange, optionHeaderRange, headerStyle)
+
+			for i, o := range result.Options {
+				row := optionRow + 2 + i
+				f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), i+1)
+				f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), string(o.Type))
+				if !o.ExerciseStart.IsZero() {
+					f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), o.ExerciseStart.Format("2006-01-02"))
+				}
+				if !o.ExerciseEnd.IsZero() {
+					f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), o.ExerciseEnd.Format("2006-01-02"))
+				}
+				f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), o.EndDate.Format("2006-01-02"))
+				f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), o.PaymentAmount.Float64())
+				f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), o.Penalty.Float64())
+				certain := "No"
+				if o.ReasonablyCertain {
+					certain = "Yes"
+				}
+				f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), certain)
+			}
+			optionDataRange := fmt.Sprintf("F%d:G%d", optionRow+2, optionRow+1+len(result.Options))
+			f.SetCellStyle(sheetName, optionDataRange, optionDataRange, numStyle)
+			liabilityHeaderRow = optionRow + len(result.Options) + 3
+		}
+
+		// List the remeasurements applied after commencement (IFRS 16.39-46)
+		if len(result.Remeasurements) > 0 {
+			modificationRow := liabilityHeaderRow
+			f.SetCellValue(sheetName, fmt.Sprintf("A%d", modificationRow), "Lease Modifications")
+			modificationHeaders := []string{"Effective Date", "Reason", "Liability Before", "Liability After",
+				"RoU Asset Before", "RoU Asset After", "Gain/(Loss)"}
+			for i, header := range modificationHeaders {
+				cell := fmt.Sprintf("%c%d", 'A'+i, modificationRow+1)
+				f.SetCellValue(sheetName, cell, header)
+			}
+			modificationHeaderRange := fmt.Sprintf("A%d:%c%d", modificationRow+1, 'A'+len(modificationHeaders)-1, modificationRow+1)
+			f.SetCellStyle(sheetName, modificationHeaderRange, modificationHeaderRange, headerStyle)
+
+			for i, r := range result.Remeasurements {
+				row := modificationRow + 2 + i
+				f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), r.EffectiveDate.Format("2006-01-02"))
+				f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), r.Reason)
+				f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), r.LiabilityBefore.Float64())
+				f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), r.LiabilityAfter.Float64())
+				f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), r.RoUAssetBefore.Float64())
+				f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), r.RoUAssetAfter.Float64())
+				f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), r.GainLoss.Float64())
+			}
+			modificationDataRange := fmt.Sprintf("C%d:G%d", modificationRow+2, modificationRow+1+len(result.Remeasurements))
+			f.SetCellStyle(sheetName, modificationDataRange, modificationDataRange, numStyle)
+			liabilityHeaderRow = modificationRow + len(result.Remeasurements) + 3
+		}
+
+		// List the impairment losses and reversals of the RoU asset (IAS 36)
+		if len(result.Impairments) > 0 {
+			impairmentRow := liabilityHeaderRow
+			f.SetCellValue(sheetName, fmt.Sprintf("A%d", impairmentRow), "RoU Asset Impairments")
+			impairmentHeaders := []string{"Date", "Description", "Carrying Amount Before", "Recoverable Amount",
+				"Impairment Loss/(Reversal)", "Carrying Amount After"}
+			for i, header := range impairmentHeaders {
+				cell := fmt.Sprintf("%c%d", 'A'+i, impairmentRow+1)
+				f.SetCellValue(sheetName, cell, header)
+			}
+			impairmentHeaderRange := fmt.Sprintf("A%d:%c%d", impairmentRow+1, 'A'+len(impairmentHeaders)-1, impairmentRow+1)
+			f.SetCellStyle(sheetName, impairmentHeaderRange, impairmentHeaderRange, headerStyle)
+
+			for i, impairment := range result.Impairments {
+				row := impairmentRow + 2 + i
+				f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), impairment.Date.Format("2006-01-02"))
+				f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), impairment.Description)
+				f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), impairment.CarryingAmountBefore.Float64())
+				if impairment.RecoverableAmount != nil {
+					f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), impairment.RecoverableAmount.Float64())
+				}
+				f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), impairment.Loss.Float64())
+				f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), impairment.CarryingAmountAfter.Float64())
+			}
+			impairmentDataRange := fmt.Sprintf("C%d:F%d", impairmentRow+2, impairmentRow+1+len(result.Impairments))
+			f.SetCellStyle(sheetName, impairmentDataRange, impairmentDataRange, numStyle)
+			liabilityHeaderRow = impairmentRow + len(result.Impairments) + 3
+		}
 		f.SetCellValue(sheetName, fmt.Sprintf("A%d", liabilityHeaderRow), "Lease Liability Schedule")
 
-		// Lease Liability headers
+		// Lease Liability headers; the part of a payment made under a residual value guarantee
+		// is shown on its own when there is one
 		liabHeaders := []string{"Period", "Date", "Opening Balance", "Payment",
-			"Interest Expense", "Principal Repayment", "Closing Balance"}
+			"Interest Expense", "Principal Repayment", "Closing Balance", "Remeasurement"}
+		showResidualValue := result.ResidualGuarantee != 0
+		for _, entry := range result.LiabilitySchedule {
+			showResidualValue = showResidualValue || entry.ResidualValue != 0
+		}
+		if showResidualValue {
+			liabHeaders = append(liabHeaders, "Residual Value Guarantee")
+		}
 		for i, header := range liabHeaders {
 			cell := fmt.Sprintf("%c%d", 'A'+i, liabilityHeaderRow+1)
 			f.SetCellValue(sheetName, cell, header)
@@ -289,23 +680,35 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 			row := i + liabilityHeaderRow + 2
 			f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), entry.Period)
 			f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), entry.Date.Format("2006-01-02"))
-			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), entry.OpeningBalance)
-			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), entry.Payment)
-			f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), entry.InterestExpense)
-			f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), entry.PrincipalRepayment)
-			f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), entry.ClosingBalance)
+			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), entry.OpeningBalance.Float64())
+			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), entry.Payment.Float64())
+			f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), entry.InterestExpense.Float64())
+			f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), entry.PrincipalRepayment.Float64())
+			f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), entry.ClosingBalance.Float64())
+			if entry.Remeasurement != 0 {
+				f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), entry.Remeasurement.Float64())
+			}
+			if entry.ResidualValue != 0 {
+				f.SetCellValue(sheetName, fmt.Sprintf("I%d", row), entry.ResidualValue.Float64())
+			}
 		}
 
 		// Format liability schedule numbers
-		liabDataRange := fmt.Sprintf("C%d:G%d", liabilityHeaderRow+2, liabilityHeaderRow+1+len(result.LiabilitySchedule))
+		liabDataRange := fmt.Sprintf("C%d:%c%d", liabilityHeaderRow+2, 'A'+len(liabHeaders)-1, liabilityHeaderRow+1+len(result.LiabilitySchedule))
 		f.SetCellStyle(sheetName, liabDataRange, liabDataRange, numStyle)
 
 		// Add RoU Asset Schedule
 		firstRoURow := liabilityHeaderRow + len(result.LiabilitySchedule) + 3
 		f.SetCellValue(sheetName, fmt.Sprintf("A%d", firstRoURow), "Right-of-Use Asset Schedule")
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", firstRoURow), "Depreciation Method:")
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", firstRoURow), depreciationMethod)
 
-		// RoU Asset headers
-		rouHeaders := []string{"Period", "Date", "Opening Balance", "Depreciation", "Closing Balance"}
+		// RoU Asset headers; units of production also shows the forecast use driving depreciation
+		rouHeaders := []string{"Period", "Date", "Opening Balance", "Depreciation", "Closing Balance", "Adjustment", "Impairment"}
+		showUnits := depreciationMethod == string(lease.UnitsOfProduction)
+		if showUnits {
+			rouHeaders = append(rouHeaders, "Units Used")
+		}
 		for i, header := range rouHeaders {
 			cell := fmt.Sprintf("%c%d", 'A'+i, firstRoURow+1)
 			f.SetCellValue(sheetName, cell, header)
@@ -320,19 +723,57 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 			row := i + firstRoURow + 2
 			f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), entry.Period)
 			f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), entry.Date.Format("2006-01-02"))
-			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), entry.OpeningBalance)
-			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), entry.Depreciation)
-			f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), entry.ClosingBalance)
+			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), entry.OpeningBalance.Float64())
+			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), entry.Depreciation.Float64())
+			f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), entry.ClosingBalance.Float64())
+			if entry.Remeasurement != 0 {
+				f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), entry.Remeasurement.Float64())
+			}
+			if entry.Impairment != 0 {
+				f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), entry.Impairment.Float64())
+			}
+			if showUnits {
+				f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), entry.UnitsUsed)
+			}
 		}
 
 		// Format RoU asset schedule numbers
-		rouDataRange := fmt.Sprintf("C%d:E%d", firstRoURow+2, firstRoURow+1+len(result.RoUAssetSchedule))
+		rouDataRange := fmt.Sprintf("C%d:G%d", firstRoURow+2, firstRoURow+1+len(result.RoUAssetSchedule))
 		f.SetCellStyle(sheetName, rouDataRange, rouDataRange, numStyle)
 
+		// Add Restoration Provision Schedule (IAS 37), if the lease carries one
+		if len(result.RestorationSchedule) > 0 {
+			firstProvisionRow := firstRoURow + len(result.RoUAssetSchedule) + 3
+			f.SetCellValue(sheetName, fmt.Sprintf("A%d", firstProvisionRow), "Restoration Provision Schedule")
+			f.SetCellValue(sheetName, fmt.Sprintf("D%d", firstProvisionRow), "Initial Provision:")
+			f.SetCellValue(sheetName, fmt.Sprintf("E%d", firstProvisionRow), result.RestorationProvision.Float64())
+
+			provisionHeaders := []string{"Period", "Date", "Opening Balance", "Remeasurement", "Unwinding", "Closing Balance"}
+			for i, header := range provisionHeaders {
+				cell := fmt.Sprintf("%c%d", 'A'+i, firstProvisionRow+1)
+				f.SetCellValue(sheetName, cell, header)
+			}
+			provisionHeaderRange := fmt.Sprintf("A%d:%c%d", firstProvisionRow+1, 'A'+len(provisionHeaders)-1, firstProvisionRow+1)
+			f.SetCellStyle(sheetName, provisionHeaderRange, provisionHeaderRange, headerStyle)
+
+			for i, entry := range result.RestorationSchedule {
+				row := i + firstProvisionRow + 2
+				f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), entry.Period)
+				f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), entry.Date.Format("2006-01-02"))
+				f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), entry.OpeningBalance.Float64())
+				f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), entry.Remeasurement.Float64())
+				f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), entry.InterestExpense.Float64())
+				f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), entry.ClosingBalance.Float64())
+			}
+
+			provisionDataRange := fmt.Sprintf("C%d:F%d", firstProvisionRow+2, firstProvisionRow+1+len(result.RestorationSchedule))
+			f.SetCellStyle(sheetName, provisionDataRange, provisionDataRange, numStyle)
+		}
+
 		// 删除原来的账期摘要部分(已经移到顶部了)
 
 		// Adjust column widths
-		for i := 0; i < 7; i++ {
+		for i := 0; i < 8; i++ {
 			col := string(rune('A' + i))
 			f.SetColWidth(sheetName, col, col, 15)
 		}
@@ -348,3 +789,375 @@ func ExportToExcel(results []LeaseResultExport) ([]byte, error) {
 	}
 	return buffer.Bytes(), nil
 }
+
+// writeExemptLeaseSheet writes the detail sheet of a lease under a recognition exemption: its
+// details, the expense recognised in the accounting period, if any, and the straight-line
+// expense schedule, whose balance is the lease expense accrued (or, if negative, prepaid).
+func writeExemptLeaseSheet(f *excelize.File, sheetName string, result LeaseResultExport, headerStyle, numStyle int) {
+	paymentTiming := result.PaymentTiming
+	if paymentTiming == "" {
+		paymentTiming = "Arrears"
+	}
+	details := []struct {
+		label string
+		value interface{}
+	}{
+		{"Lease ID:", result.LeaseID},
+		{"Asset Class:", result.AssetClass},
+		{"Exemption:", exemptionLabel(result.Exemption)},
+		{"Start Date:", result.StartDate.Format("2006-01-02")},
+		{"End Date:", result.EndDate.Format("2006-01-02")},
+		{"Payment Amount:", result.PaymentAmount.Float64()},
+		{"Payment Frequency:", result.PaymentFrequency},
+		{"Payment Timing:", paymentTiming},
+		{"Schedule Granularity:", result.ScheduleGranularity},
+	}
+	if result.AccountingPeriodStart != "" && result.AccountingPeriodEnd != "" {
+		details = append(details, []struct {
+			label string
+			value interface{}
+		}{
+			{"Accounting Period:", result.AccountingPeriodStart + " - " + result.AccountingPeriodEnd},
+			{"Period Lease Expense:", result.PeriodExemptLeaseExpense.Float64()},
+			{"Period Variable Payments:", result.PeriodVariablePayments.Float64()},
+			{"Period Non-Lease Expense:", result.PeriodNonLeaseExpense.Float64()},
+			{"Period Payments:", result.PeriodPayments.Float64()},
+		}...)
+	}
+	f.SetCellValue(sheetName, "A1", "Lease Details")
+	for i, detail := range details {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", 2+i), detail.label)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", 2+i), detail.value)
+	}
+
+	expenseRow := len(details) + 3
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", expenseRow), "Lease Expense Schedule")
+	expenseHeaders := []string{"Period", "Date", "Opening Accrual", "Lease Expense", "Payment", "Closing Accrual"}
+	for i, header := range expenseHeaders {
+		cell := fmt.Sprintf("%c%d", 'A'+i, expenseRow+1)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	expenseHeaderRange := fmt.Sprintf("A%d:%c%d", expenseRow+1, 'A'+len(expenseHeaders)-1, expenseRow+1)
+	f.SetCellStyle(sheetName, expenseHeaderRange, expenseHeaderRange, headerStyle)
+
+	for i, entry := range result.ExpenseSchedule {
+		row := i + expenseRow + 2
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), entry.Period)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), entry.Date.Format("2006-01-02"))
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), entry.OpeningBalance.Float64())
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), entry.Expense.Float64())
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), entry.Payment.Float64())
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), entry.ClosingBalance.Float64())
+	}
+	expenseDataRange := fmt.Sprintf("C%d:F%d", expenseRow+2, expenseRow+1+len(result.ExpenseSchedule))
+	f.SetCellStyle(sheetName, expenseDataRange, expenseDataRange, numStyle)
+
+	for i := 0; i < len(expenseHeaders); i++ {
+		col := string(rune('A' + i))
+		f.SetColWidth(sheetName, col, col, 15)
+	}
+}
+
+// writeLessorLeaseSheet writes the detail sheet of a lease the entity grants as lessor: its
+// details and classification with the indicators of a finance lease it meets, the amounts of
+// the accounting period, if any, and either the net investment schedule of a finance lease or
+// the straight-line income and underlying asset schedules of an operating lease.
+func writeLessorLeaseSheet(f *excelize.File, sheetName string, result LeaseResultExport, headerStyle, numStyle int) {
+	paymentTiming := result.PaymentTiming
+	if paymentTiming == "" {
+		paymentTiming = "Arrears"
+	}
+	finance := result.Classification == string(lease.FinanceLease)
+	type detail struct {
+		label string
+		value interface{}
+	}
+	details := []detail{
+		{"Lease ID:", result.LeaseID},
+		{"Asset Class:", result.AssetClass},
+		{"Role:", result.Role},
+		{"Classification:", classificationLabel(result.Classification)},
+		{"Start Date:", result.StartDate.Format("2006-01-02")},
+		{"End Date:", result.EndDate.Format("2006-01-02")},
+		{"Payment Amount:", result.PaymentAmount.Float64()},
+		{"Payment Frequency:", result.PaymentFrequency},
+		{"Payment Timing:", paymentTiming},
+		{"Schedule Granularity:", result.ScheduleGranularity},
+	}
+	if result.HeadLeaseID != "" {
+		details = append(details, detail{"Head Lease ID:", result.HeadLeaseID})
+	}
+	if finance {
+		details = append(details, []detail{
+			{"Implicit Rate:", result.DiscountRate},
+			{"Day Count:", result.DayCountConvention},
+			{"Rate Basis:", result.RateBasis},
+			{"Net Investment at Commencement:", result.NetInvestment.Float64()},
+			{"Gain/(Loss) on Derecognition:", result.DerecognitionGainLoss.Float64()},
+		}...)
+		if result.HeadLeaseID != "" {
+			details = append(details, detail{"Head Lease RoU Asset Derecognised:", result.SubleasedRoUAsset.Float64()})
+		}
+	}
+	if result.AccountingPeriodStart != "" && result.AccountingPeriodEnd != "" {
+		details = append(details, detail{"Accounting Period:", result.AccountingPeriodStart + " - " + result.AccountingPeriodEnd})
+		if finance {
+			details = append(details, []detail{
+				{"Period Opening Net Investment:", result.PeriodNetInvestmentStart.Float64()},
+				{"Period Finance Income:", result.PeriodFinanceIncome.Float64()},
+				{"Period Closing Net Investment:", result.PeriodNetInvestmentEnd.Float64()},
+			}...)
+		} else {
+			details = append(details, []detail{
+				{"Period Lease Income:", result.PeriodOperatingLeaseIncome.Float64()},
+				{"Period Opening Asset:", result.PeriodAssetStart.Float64()},
+				{"Period Asset Depreciation:", result.PeriodAssetDepreciation.Float64()},
+				{"Period Closing Asset:", result.PeriodAssetEnd.Float64()},
+			}...)
+		}
+		details = append(details, detail{"Period Lease Receipts:", result.PeriodLeaseReceipts.Float64()})
+	}
+	f.SetCellValue(sheetName, "A1", "Lease Details")
+	for i, d := range details {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", 2+i), d.label)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", 2+i), d.value)
+	}
+
+	// The indicators of a finance lease the lease meets (IFRS 16.63-64)
+	row := len(details) + 3
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), "Finance Lease Indicators")
+	indicators := result.Indicators
+	if len(indicators) == 0 {
+		indicators = []string{"None"}
+	}
+	for i, indicator := range indicators {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row+1+i), indicator)
+	}
+	row += len(indicators) + 2
+
+	// writeSchedule writes a titled schedule with the given columns after the period and date
+	writeSchedule := func(title string, headers []string, schedule []calculation.AmortizationEntry, values func(calculation.AmortizationEntry) []money.Amount) {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), title)
+		headers = append([]string{"Period", "Date"}, headers...)
+		for i, header := range headers {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+i, row+1), header)
+		}
+		headerRange := fmt.Sprintf("A%d:%c%d", row+1, 'A'+len(headers)-1, row+1)
+		f.SetCellStyle(sheetName, headerRange, headerRange, headerStyle)
+
+		for i, entry := range schedule {
+			r := row + 2 + i
+			f.SetCellValue(sheetName, fmt.Sprintf("A%d", r), entry.Period)
+			f.SetCellValue(sheetName, fmt.Sprintf("B%d", r), entry.Date.Format("2006-01-02"))
+			for j, value := range values(entry) {
+				f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'C'+j, r), value.Float64())
+			}
+		}
+		dataRange := fmt.Sprintf("C%d:%c%d", row+2, 'A'+len(headers)-1, row+1+len(schedule))
+		f.SetCellStyle(sheetName, dataRange, dataRange, numStyle)
+		row += len(schedule) + 3
+	}
+
+	if finance {
+		writeSchedule("Net Investment Schedule",
+			[]string{"Opening Balance", "Receipt", "Finance Income", "Principal Recovered", "Closing Balance", "Residual Value"},
+			result.NetInvestmentSchedule, func(e calculation.AmortizationEntry) []money.Amount {
+				return []money.Amount{e.OpeningBalance, e.Payment, e.Income, e.PrincipalRepayment, e.ClosingBalance, e.ResidualValue}
+			})
+	} else {
+		writeSchedule("Lease Income Schedule",
+			[]string{"Opening Accrual", "Lease Income", "Receipt", "Closing Accrual"},
+			result.IncomeSchedule, func(e calculation.AmortizationEntry) []money.Amount {
+				return []money.Amount{e.OpeningBalance, e.Income, e.Payment, e.ClosingBalance}
+			})
+		if len(result.AssetSchedule) > 0 {
+			writeSchedule("Underlying Asset Schedule",
+				[]string{"Opening Balance", "Depreciation", "Closing Balance"},
+				result.AssetSchedule, func(e calculation.AmortizationEntry) []money.Amount {
+					return []money.Amount{e.OpeningBalance, e.Depreciation, e.ClosingBalance}
+				})
+		}
+	}
+
+	for i := 0; i < 8; i++ {
+		col := string(rune('A' + i))
+		f.SetColWidth(sheetName, col, col, 15)
+	}
+}
+
+// writeTransitionSheet writes the measurement of the leases in place at the date of initial
+// application of IFRS 16: each lease's opening balances and retained earnings adjustment, and
+// the reconciliation of the IAS 17 operating lease commitments to the opening lease
+// liabilities, with their weighted average discount rate (IFRS 16.C12).
+func writeTransitionSheet(f *excelize.File, sheetName string, results []LeaseResultExport, headerStyle, numStyle int) {
+	initialApplication := results[0].Transition.InitialApplicationDate
+	f.SetCellValue(sheetName, "A1", "Transition to IFRS 16")
+	f.SetCellValue(sheetName, "A2", "Date of Initial Application:")
+	f.SetCellValue(sheetName, "B2", initialApplication)
+
+	f.SetCellValue(sheetName, "A4", "Opening Retained Earnings Adjustment")
+	headers := []string{"Lease ID", "Asset Class", "Approach", "Discount Rate", "Opening Liability",
+		"Opening RoU Asset", "Accrued/(Prepaid) Rent", "Retained Earnings"}
+	for i, header := range headers {
+		f.SetCellValue(sheetName, fmt.Sprintf("%c5", 'A'+i), header)
+	}
+	headerRange := fmt.Sprintf("A5:%c5", 'A'+len(headers)-1)
+	f.SetCellStyle(sheetName, headerRange, headerRange, headerStyle)
+
+	var commitments, options, shortTerm, lowValue, discounting, liabilities, retained, weightedRate money.Amount
+	for i, result := range results {
+		t := result.Transition
+		row := 6 + i
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), result.LeaseID)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), result.AssetClass)
+		if t.Exemption != "" {
+			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), exemptionLabel(string(t.Exemption)))
+		} else {
+			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), transitionMethodLabel(t.Method))
+			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), t.DiscountRate)
+			f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), t.OpeningLiability.Float64())
+			f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), t.OpeningRoUAsset.Float64())
+			f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), t.AccruedLeasePayments.Float64())
+			f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), t.RetainedEarnings.Float64())
+		}
+
+		commitments += t.Commitment
+		options += t.OptionPayments
+		switch t.Exemption {
+		case calculation.ShortTermExemption:
+			shortTerm += t.ExemptPayments
+		case calculation.LowValueExemption:
+			lowValue += t.ExemptPayments
+		}
+		discounting += t.Discounting
+		liabilities += t.OpeningLiability
+		retained += t.RetainedEarnings
+		weightedRate += t.OpeningLiability.Mul(t.DiscountRate)
+	}
+	totalRow := 6 + len(results)
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", totalRow), "Total")
+	f.SetCellValue(sheetName, fmt.Sprintf("E%d", totalRow), liabilities.Float64())
+	f.SetCellValue(sheetName, fmt.Sprintf("H%d", totalRow), retained.Float64())
+	f.SetCellStyle(sheetName, "D6", fmt.Sprintf("H%d", totalRow), numStyle)
+
+	// The commitments disclosed under IAS 17 at the end of the previous period reconcile to the
+	// opening liabilities, the less lines shown as negative amounts
+	row := totalRow + 2
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), "Reconciliation of Operating Lease Commitments")
+	lines := []struct {
+		label  string
+		amount money.Amount
+	}{
+		{"Operating lease commitments under IAS 17", commitments},
+		{"Add: payments in periods covered by extension options, less termination options", options},
+		{"Less: short-term leases recognised as an expense", -shortTerm},
+		{"Less: low-value leases recognised as an expense", -lowValue},
+		{"Less: effect of discounting", -discounting},
+		{"Lease liabilities at " + initialApplication, liabilities},
+	}
+	for i, line := range lines {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row+1+i), line.label)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row+1+i), line.amount.Float64())
+	}
+	f.SetCellStyle(sheetName, fmt.Sprintf("B%d", row+1), fmt.Sprintf("B%d", row+len(lines)), numStyle)
+	row += len(lines) + 1
+	if liabilities != 0 {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), "Weighted Average Discount Rate")
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), fmt.Sprintf("%.2f%%", weightedRate.Float64()/liabilities.Float64()*100))
+	}
+
+	f.SetColWidth(sheetName, "A", "A", 30)
+	f.SetColWidth(sheetName, "B", "H", 18)
+}
+
+// transitionMethodLabel describes a transition approach for the export.
+func transitionMethodLabel(method calculation.TransitionMethod) string {
+	switch method {
+	case calculation.FullRetrospective:
+		return "Full retrospective"
+	case calculation.ModifiedRetrospectiveAsIf:
+		return "Modified, RoU as if always applied"
+	case calculation.ModifiedRetrospectiveLiability:
+		return "Modified, RoU equal to liability"
+	default:
+		return string(method)
+	}
+}
+
+// classificationLabel describes a lessor's classification of a lease for the export.
+func classificationLabel(classification string) string {
+	switch lease.Classification(classification) {
+	case lease.FinanceLease:
+		return "Finance lease"
+	case lease.OperatingLease:
+		return "Operating lease"
+	default:
+		return classification
+	}
+}
+
+// componentsLabel describes how the non-lease components of a lease are accounted for, with
+// the total paid for them when they are separated.
+func componentsLabel(result LeaseResultExport) string {
+	if result.ComponentsCombined {
+		return "Combined with the lease component (IFRS 16.15)"
+	}
+	if len(result.NonLeasePayments) == 0 {
+		return "None"
+	}
+	var total money.Amount
+	for _, p := range result.NonLeasePayments {
+		total += p.Amount
+	}
+	return fmt.Sprintf("Separated, %s expensed over the term", total)
+}
+
+// rateSourceLabel describes where the discount rate of a lease came from: entered with the
+// lease, or built from the yield curves with the rates added up.
+func rateSourceLabel(source *lease.RateProvenance) string {
+	if source == nil {
+		return "Entered"
+	}
+	label := fmt.Sprintf("%s curve of %s at %.1f months (%s): %.4f%% risk-free + %.4f%% spread",
+		source.Currency, source.CurveDate.Format("2006-01-02"), source.TenorMonths, source.TenorBasis,
+		source.RiskFreeRate*100, source.CreditSpread*100)
+	if source.Entity != "" {
+		label += fmt.Sprintf(" (%s)", source.Entity)
+	}
+	if source.SecurityAdjustment != 0 {
+		label += fmt.Sprintf(" %+.4f%% security", source.SecurityAdjustment*100)
+	}
+	return label
+}
+
+// exemptionLabel describes a recognition exemption for the export.
+func exemptionLabel(exemption string) string {
+	switch calculation.Exemption(exemption) {
+	case calculation.ShortTermExemption:
+		return "Short-term lease"
+	case calculation.LowValueExemption:
+		return "Low-value asset"
+	default:
+		return exemption
+	}
+}
+
+// depreciationMethodLabel 返回折旧方法的中文名称,默认为直线法
+func depreciationMethodLabel(method string) string {
+	switch lease.DepreciationMethod(method) {
+	case lease.UnitsOfProduction:
+		return "工作量法"
+	case lease.DiminishingBalance:
+		return "余额递减法"
+	default:
+		return "直线法"
+	}
+}
+
+// cellValue converts a money amount to a number for a cell; other values are written as they are.
+func cellValue(v interface{}) interface{} {
+	if amount, ok := v.(money.Amount); ok {
+		return amount.Float64()
+	}
+	return v
+}
@@ -1,9 +1,15 @@
 package export
 
 import (
+	"bytes"
 	"ifrs16_calculator/internal/calculation"
+	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/xuri/excelize/v2"
 )
 
 func TestExportToExcel(t *testing.T) {
@@ -13,29 +19,33 @@ func TestExportToExcel(t *testing.T) {
 			LeaseID:          "TEST001",
 			StartDate:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
 			EndDate:          time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
-			PaymentAmount:    1000.00,
+			PaymentAmount:    money.FromFloat(1000.00),
 			PaymentFrequency: "Monthly",
 			DiscountRate:     0.05,
-			InitialLiability: 11681.22,
-			InitialRoUAsset:  11681.22,
+			InitialLiability: money.FromFloat(11681.22),
+			InitialRoUAsset:  money.FromFloat(11681.22),
+			RoUAssetComponents: &calculation.RoUAssetComponents{
+				LeaseLiability: money.FromFloat(11681.22),
+				Total:          money.FromFloat(11681.22),
+			},
 			LiabilitySchedule: []calculation.AmortizationEntry{
 				{
 					Period:             1,
 					Date:               time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
-					OpeningBalance:     11681.22,
-					Payment:            1000.00,
-					InterestExpense:    48.67,
-					PrincipalRepayment: 951.33,
-					ClosingBalance:     10729.89,
+					OpeningBalance:     money.FromFloat(11681.22),
+					Payment:            money.FromFloat(1000.00),
+					InterestExpense:    money.FromFloat(48.67),
+					PrincipalRepayment: money.FromFloat(951.33),
+					ClosingBalance:     money.FromFloat(10729.89),
 				},
 				{
 					Period:             2,
 					Date:               time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
-					OpeningBalance:     10729.89,
-					Payment:            1000.00,
-					InterestExpense:    44.71,
-					PrincipalRepayment: 955.29,
-					ClosingBalance:     9774.60,
+					OpeningBalance:     money.FromFloat(10729.89),
+					Payment:            money.FromFloat(1000.00),
+					InterestExpense:    money.FromFloat(44.71),
+					PrincipalRepayment: money.FromFloat(955.29),
+					ClosingBalance:     money.FromFloat(9774.60),
 				},
 				// Add more entries as needed
 			},
@@ -43,16 +53,16 @@ func TestExportToExcel(t *testing.T) {
 				{
 					Period:         1,
 					Date:           time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
-					OpeningBalance: 11681.22,
-					Depreciation:   973.44,
-					ClosingBalance: 10707.78,
+					OpeningBalance: money.FromFloat(11681.22),
+					Depreciation:   money.FromFloat(973.44),
+					ClosingBalance: money.FromFloat(10707.78),
 				},
 				{
 					Period:         2,
 					Date:           time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
-					OpeningBalance: 10707.78,
-					Depreciation:   973.44,
-					ClosingBalance: 9734.34,
+					OpeningBalance: money.FromFloat(10707.78),
+					Depreciation:   money.FromFloat(973.44),
+					ClosingBalance: money.FromFloat(9734.34),
 				},
 				// Add more entries as needed
 			},
@@ -78,3 +88,212 @@ func TestExportToExcel(t *testing.T) {
 	// but that's more complex and might be overkill for a basic test.
 	// The main validation here is that we get bytes back without errors.
 }
+
+func TestExportToExcelExemptLeases(t *testing.T) {
+	results := []LeaseResultExport{
+		{
+			LeaseID:          "TEST001",
+			StartDate:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:          time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+			PaymentAmount:    money.FromFloat(1000.00),
+			PaymentFrequency: "Monthly",
+			DiscountRate:     0.05,
+			InitialLiability: money.FromFloat(33365.18),
+			InitialRoUAsset:  money.FromFloat(33365.18),
+		},
+		{
+			LeaseID:          "TEST002",
+			AssetClass:       "IT Equipment",
+			Exemption:        string(calculation.LowValueExemption),
+			StartDate:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:          time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			PaymentAmount:    money.FromFloat(50.00),
+			PaymentFrequency: "Monthly",
+			ExpenseSchedule: []calculation.AmortizationEntry{
+				{Period: 1, Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Expense: money.FromFloat(25.00), ClosingBalance: money.FromFloat(25.00)},
+				{Period: 2, Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), OpeningBalance: money.FromFloat(25.00), Expense: money.FromFloat(25.00), Payment: money.FromFloat(50.00)},
+			},
+		},
+	}
+
+	excelBytes, err := ExportToExcel(results)
+	if err != nil {
+		t.Fatalf("Error exporting results: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(excelBytes))
+	if err != nil {
+		t.Fatalf("Error reading exported file: %v", err)
+	}
+	defer f.Close()
+
+	// The recognised lease is in the main table and the exempt lease in its own table below it
+	cells := map[string]string{
+		"A2": "TEST001",
+		"A3": "",
+		"A4": "Exempt Leases (IFRS 16.5-8)",
+		"A6": "TEST002",
+		"C6": "Low-value asset",
+		"H6": "50",
+	}
+	for cell, want := range cells {
+		got, err := f.GetCellValue("Summary", cell)
+		if err != nil {
+			t.Fatalf("GetCellValue(%s) error = %v", cell, err)
+		}
+		if strings.TrimSpace(got) != want {
+			t.Errorf("Summary %s = %q, want %q", cell, got, want)
+		}
+	}
+
+	if got, _ := f.GetCellValue("Lease_TEST002", "A1"); got != "Lease Details" {
+		t.Errorf("Exempt lease sheet starts with %q, want the lease details", got)
+	}
+}
+
+func TestExportToExcelLessorLeases(t *testing.T) {
+	entry := calculation.AmortizationEntry{Period: 1, Date: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		OpeningBalance: money.FromFloat(35300.00), Income: money.FromFloat(150.00), ClosingBalance: money.FromFloat(35450.00)}
+	results := []LeaseResultExport{
+		{
+			LeaseID:          "TEST001",
+			StartDate:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:          time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+			PaymentAmount:    money.FromFloat(1000.00),
+			PaymentFrequency: "Monthly",
+			DiscountRate:     0.05,
+		},
+		{
+			LeaseID:               "TEST002",
+			Role:                  string(lease.LessorRole),
+			Classification:        string(lease.FinanceLease),
+			Indicators:            []string{"IFRS 16.63(a) ownership transfers to the lessee by the end of the lease term"},
+			StartDate:             time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:               time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+			PaymentAmount:         money.FromFloat(1000.00),
+			PaymentFrequency:      "Monthly",
+			DiscountRate:          0.0512,
+			NetInvestment:         money.FromFloat(35300.00),
+			NetInvestmentSchedule: []calculation.AmortizationEntry{entry},
+			HeadLeaseID:           "TEST001",
+			SubleasedRoUAsset:     money.FromFloat(34000.00),
+		},
+	}
+
+	excelBytes, err := ExportToExcel(results)
+	if err != nil {
+		t.Fatalf("Error exporting results: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(excelBytes))
+	if err != nil {
+		t.Fatalf("Error reading exported file: %v", err)
+	}
+	defer f.Close()
+
+	// The lessor lease, a sublease of the other, is listed in its own table below the recognised leases
+	cells := map[string]string{
+		"A2": "TEST001",
+		"A4": "Lessor Leases (IFRS 16.61-97)",
+		"A6": "TEST002",
+		"C6": "Finance lease",
+		"I6": "35,300.00",
+		"K6": "TEST001",
+	}
+	for cell, want := range cells {
+		got, err := f.GetCellValue("Summary", cell)
+		if err != nil {
+			t.Fatalf("GetCellValue(%s) error = %v", cell, err)
+		}
+		if strings.TrimSpace(got) != want {
+			t.Errorf("Summary %s = %q, want %q", cell, got, want)
+		}
+	}
+
+	rows, err := f.GetRows("Lease_TEST002")
+	if err != nil {
+		t.Fatalf("GetRows() error = %v", err)
+	}
+	var found bool
+	for _, row := range rows {
+		found = found || (len(row) > 0 && row[0] == "Net Investment Schedule")
+	}
+	if !found {
+		t.Errorf("Lessor lease sheet has no net investment schedule")
+	}
+}
+
+func TestExportToExcelTransition(t *testing.T) {
+	results := []LeaseResultExport{
+		{
+			LeaseID:          "TEST001",
+			AssetClass:       "Property",
+			StartDate:        time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:          time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+			PaymentAmount:    money.FromFloat(1000.00),
+			PaymentFrequency: "Monthly",
+			DiscountRate:     0.05,
+			Transition: &calculation.TransitionAdjustment{
+				Method:                 calculation.ModifiedRetrospectiveAsIf,
+				InitialApplicationDate: "2024-01-01",
+				DiscountRate:           0.05,
+				Commitment:             money.FromFloat(37000.00),
+				Discounting:            money.FromFloat(2638.90),
+				OpeningLiability:       money.FromFloat(34361.10),
+				OpeningRoUAsset:        money.FromFloat(32526.30),
+				AccruedLeasePayments:   money.FromFloat(986.86),
+				RetainedEarnings:       money.FromFloat(-847.94),
+			},
+		},
+		{
+			LeaseID:          "TEST002",
+			AssetClass:       "IT Equipment",
+			Exemption:        string(calculation.LowValueExemption),
+			StartDate:        time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:          time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+			PaymentAmount:    money.FromFloat(100.00),
+			PaymentFrequency: "Monthly",
+			Transition: &calculation.TransitionAdjustment{
+				Method:                 calculation.ModifiedRetrospectiveAsIf,
+				InitialApplicationDate: "2024-01-01",
+				DiscountRate:           0.05,
+				Exemption:              calculation.LowValueExemption,
+				Commitment:             money.FromFloat(2500.00),
+				ExemptPayments:         money.FromFloat(2500.00),
+			},
+		},
+	}
+
+	excelBytes, err := ExportToExcel(results)
+	if err != nil {
+		t.Fatalf("Error exporting results: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(excelBytes))
+	if err != nil {
+		t.Fatalf("Error reading exported file: %v", err)
+	}
+	defer f.Close()
+
+	// Each lease's adjustment, then the commitments reconciled to the opening liabilities
+	cells := map[string]string{
+		"B2":  "2024-01-01",
+		"A6":  "TEST001",
+		"C6":  "Modified, RoU as if always applied",
+		"H6":  "-847.94",
+		"C7":  "Low-value asset",
+		"E8":  "34,361.10",
+		"B11": "39,500.00",
+		"B14": "-2,500.00",
+		"B15": "-2,638.90",
+		"A16": "Lease liabilities at 2024-01-01",
+		"B16": "34,361.10",
+		"B17": "5.00%",
+	}
+	for cell, want := range cells {
+		got, err := f.GetCellValue("Transition", cell)
+		if err != nil {
+			t.Fatalf("GetCellValue(%s) error = %v", cell, err)
+		}
+		if strings.TrimSpace(got) != want {
+			t.Errorf("Transition %s = %q, want %q", cell, got, want)
+		}
+	}
+}
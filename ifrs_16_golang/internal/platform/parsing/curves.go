@@ -0,0 +1,168 @@
+package parsing
+
+import (
+	"encoding/csv"
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseRateCurveCSV reads yield curves and spreads from CSV data with the columns Component,
+// Name, Currency, Date, Tenor, Rate and an optional Unit, e.g. "RiskFree,,USD,2024-01-01,5Y,4.1%".
+// Component is RiskFree, Spread (Name is the entity) or Security (Name is the asset class);
+// Currency may be blank for a spread or adjustment that applies in every currency. The rows of
+// a curve share its component, name, currency and date. Tenor is a number of months or years,
+// such as "6M" or "5Y", and may be blank for a flat curve. Curve points and spreads are often
+// below 1%, so a rate is never guessed to be a decimal or a percentage: it carries a % or bp
+// suffix ("0.5%", "50bp"), or the Unit column says how to read it (Decimal, Percent or BP). A
+// header row is recognised by its unparseable date and skipped. Each series in the returned
+// table is sorted by date.
+func ParseRateCurveCSV(reader io.Reader) (lease.RateCurves, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+	csvReader.FieldsPerRecord = -1 // The Unit column is optional
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading yield curve CSV: %w", err)
+	}
+
+	curves := lease.RateCurves{}
+	for i, record := range records {
+		lineNum := i + 1
+		if len(record) < 6 {
+			return nil, fmt.Errorf("line %d: expected Component, Name, Currency, Date, Tenor and Rate columns, got %d", lineNum, len(record))
+		}
+
+		date, err := parseDateValue(record[3])
+		if err != nil {
+			if i == 0 {
+				continue // Header row
+			}
+			return nil, fmt.Errorf("line %d: invalid date '%s': %w", lineNum, record[3], err)
+		}
+		component, err := parseRateComponent(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		key := lease.RateCurveKey{
+			Component: component,
+			Name:      strings.TrimSpace(record[1]),
+			Currency:  strings.ToUpper(strings.TrimSpace(record[2])),
+		}
+		switch {
+		case component == lease.RiskFreeRate:
+			key.Name = "" // A currency has one risk-free curve
+			if key.Currency == "" {
+				return nil, fmt.Errorf("line %d: a risk-free curve needs its currency", lineNum)
+			}
+		case key.Name == "":
+			return nil, fmt.Errorf("line %d: a %s curve needs the entity or asset class it applies to", lineNum, component)
+		}
+		tenor, err := parseTenor(record[4])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		unit := ""
+		if len(record) > 6 {
+			unit = record[6]
+		}
+		rate, err := parseCurveRate(record[5], unit)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		series := curves[key]
+		j := sort.Search(len(series), func(j int) bool { return !series[j].Date.Before(date) })
+		if j == len(series) || !series[j].Date.Equal(date) {
+			series = append(series, lease.RateCurve{})
+			copy(series[j+1:], series[j:])
+			series[j] = lease.RateCurve{Date: date}
+		}
+		for _, p := range series[j].Points {
+			if p.TenorMonths == tenor {
+				return nil, fmt.Errorf("line %d: tenor %s is given twice for the curve of %s", lineNum, strings.TrimSpace(record[4]), date.Format(dateLayout))
+			}
+		}
+		series[j].Points = append(series[j].Points, lease.CurvePoint{TenorMonths: tenor, Rate: rate})
+		curves[key] = series
+	}
+
+	for _, series := range curves {
+		for _, curve := range series {
+			points := curve.Points
+			sort.Slice(points, func(i, j int) bool {
+				return points[i].TenorMonths < points[j].TenorMonths
+			})
+		}
+	}
+
+	return curves, nil
+}
+
+// parseRateComponent parses the component of a yield curve row.
+func parseRateComponent(value string) (lease.RateComponent, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "riskfree", "risk-free", "risk free":
+		return lease.RiskFreeRate, nil
+	case "spread", "creditspread", "credit spread":
+		return lease.CreditSpread, nil
+	case "security", "securityadjustment", "security adjustment":
+		return lease.SecurityAdjustment, nil
+	default:
+		return "", fmt.Errorf("unsupported curve component: %s (expected RiskFree, Spread or Security)", value)
+	}
+}
+
+// parseCurveRate parses the rate of a curve point into a decimal from its % or bp suffix or,
+// for a bare number, from the unit given for it.
+func parseCurveRate(value, unit string) (float64, error) {
+	trimmed := strings.ToLower(strings.TrimSpace(value))
+	unit = strings.ToLower(strings.TrimSpace(unit))
+	switch {
+	case strings.HasSuffix(trimmed, "%"):
+		trimmed, unit = strings.TrimSuffix(trimmed, "%"), "percent"
+	case strings.HasSuffix(trimmed, "bp"):
+		trimmed, unit = strings.TrimSuffix(trimmed, "bp"), "bp"
+	}
+	v, err := parseFloatValue(strings.TrimSpace(trimmed))
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate '%s': %w", value, err)
+	}
+	switch unit {
+	case "decimal":
+		return v, nil
+	case "percent", "%":
+		return v / 100, nil
+	case "bp", "bps":
+		return v / 10000, nil
+	case "":
+		return 0, fmt.Errorf("rate '%s' has no unit: write it as a percentage (e.g. 0.5%%) or in basis points (e.g. 50bp), or give the Unit column", value)
+	default:
+		return 0, fmt.Errorf("unsupported rate unit: %s (expected Decimal, Percent or BP)", unit)
+	}
+}
+
+// parseTenor parses a tenor such as "18", "6M" or "5Y" into months; a blank tenor is zero.
+func parseTenor(value string) (float64, error) {
+	trimmed := strings.ToUpper(strings.TrimSpace(value))
+	if trimmed == "" {
+		return 0, nil
+	}
+	months := 1.0
+	switch {
+	case strings.HasSuffix(trimmed, "Y"):
+		months = 12
+		trimmed = strings.TrimSuffix(trimmed, "Y")
+	case strings.HasSuffix(trimmed, "M"):
+		trimmed = strings.TrimSuffix(trimmed, "M")
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(trimmed), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid tenor '%s' (expected months or years, e.g. 6M or 5Y)", value)
+	}
+	return n * months, nil
+}
@@ -0,0 +1,118 @@
+package parsing
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRateCurveCSV(t *testing.T) {
+	riskFree := lease.RateCurveKey{Component: lease.RiskFreeRate, Currency: "USD"}
+	spread := lease.RateCurveKey{Component: lease.CreditSpread, Name: "Acme Ltd", Currency: "USD"}
+	security := lease.RateCurveKey{Component: lease.SecurityAdjustment, Name: "Property"}
+
+	tests := []struct {
+		name    string
+		csv     string
+		want    lease.RateCurves
+		wantErr bool
+	}{
+		{
+			name: "Header, unsorted tenors and dates",
+			csv: `Component,Name,Currency,Date,Tenor,Rate,Unit
+RiskFree,SOFR,usd,2024-01-01,5Y,4%
+RiskFree,,USD,2024-01-01,12,0.045,Decimal
+RiskFree,,USD,2023-01-01,1Y,4,Percent
+Spread,Acme Ltd,USD,2024-01-01,6M,150bp
+Security,Property,,2024-01-01,,-0.5%`,
+			want: lease.RateCurves{
+				riskFree: {
+					{Date: parseDate("2023-01-01"), Points: []lease.CurvePoint{{TenorMonths: 12, Rate: 0.04}}},
+					{Date: parseDate("2024-01-01"), Points: []lease.CurvePoint{{TenorMonths: 12, Rate: 0.045}, {TenorMonths: 60, Rate: 0.04}}},
+				},
+				spread: {
+					{Date: parseDate("2024-01-01"), Points: []lease.CurvePoint{{TenorMonths: 6, Rate: 0.015}}},
+				},
+				security: {
+					{Date: parseDate("2024-01-01"), Points: []lease.CurvePoint{{TenorMonths: 0, Rate: -0.005}}},
+				},
+			},
+		},
+		{
+			name: "Rates of 1% and below",
+			csv: `RiskFree,,USD,2024-01-01,1M,0.5%
+RiskFree,,USD,2024-01-01,3M,1%
+RiskFree,,USD,2024-01-01,6M,1,Percent
+Spread,Acme Ltd,USD,2024-01-01,1Y,0.5,Percent
+Spread,Acme Ltd,USD,2024-01-01,5Y,75bp
+Spread,Acme Ltd,USD,2024-01-01,10Y,0.01,Decimal`,
+			want: lease.RateCurves{
+				riskFree: {
+					{Date: parseDate("2024-01-01"), Points: []lease.CurvePoint{{TenorMonths: 1, Rate: 0.005}, {TenorMonths: 3, Rate: 0.01}, {TenorMonths: 6, Rate: 0.01}}},
+				},
+				spread: {
+					{Date: parseDate("2024-01-01"), Points: []lease.CurvePoint{{TenorMonths: 12, Rate: 0.005}, {TenorMonths: 60, Rate: 0.0075}, {TenorMonths: 120, Rate: 0.01}}},
+				},
+			},
+		},
+		{
+			name:    "Sub-1% spread without a unit",
+			csv:     "Spread,Acme Ltd,USD,2024-01-01,1Y,0.5",
+			wantErr: true,
+		},
+		{
+			name:    "Rate of 1 without a unit",
+			csv:     "RiskFree,,USD,2024-01-01,1Y,1",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown unit",
+			csv:     "RiskFree,,USD,2024-01-01,1Y,1,Permille",
+			wantErr: true,
+		},
+		{
+			name:    "Risk-free curve without currency",
+			csv:     "RiskFree,,,2024-01-01,1Y,4.5%",
+			wantErr: true,
+		},
+		{
+			name:    "Spread without entity",
+			csv:     "Spread,,USD,2024-01-01,1Y,1.5%",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown component",
+			csv:     "Swap,,USD,2024-01-01,1Y,4.5%",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid tenor",
+			csv:     "RiskFree,,USD,2024-01-01,5W,4.5%",
+			wantErr: true,
+		},
+		{
+			name:    "Tenor given twice",
+			csv:     "RiskFree,,USD,2024-01-01,1Y,4.5%\nRiskFree,,USD,2024-01-01,12M,4.6%",
+			wantErr: true,
+		},
+		{
+			name:    "Missing rate column",
+			csv:     "RiskFree,,USD,2024-01-01,1Y",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRateCurveCSV(strings.NewReader(tt.csv))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package parsing
+
+import (
+	"encoding/csv"
+	"fmt"
+	"ifrs16_calculator/internal/lease"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ParseIndexCSV reads published index values from CSV data with the columns Index, Date
+// and Value, e.g. "CPI,2024-12-01,104.2". A header row is recognised by its unparseable
+// date and skipped. Each series in the returned table is sorted by date.
+func ParseIndexCSV(reader io.Reader) (lease.IndexTable, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading index CSV: %w", err)
+	}
+
+	table := lease.IndexTable{}
+	for i, record := range records {
+		lineNum := i + 1
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: expected Index, Date and Value columns, got %d", lineNum, len(record))
+		}
+
+		name := strings.TrimSpace(record[0])
+		date, err := parseDateValue(record[1])
+		if err != nil {
+			if i == 0 {
+				continue // Header row
+			}
+			return nil, fmt.Errorf("line %d: invalid date '%s': %w", lineNum, record[1], err)
+		}
+		value, err := parseFloatValue(record[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid index value '%s': %w", lineNum, record[2], err)
+		}
+		if name == "" || value <= 0 {
+			return nil, fmt.Errorf("line %d: index name and a positive value are required", lineNum)
+		}
+
+		table[name] = append(table[name], lease.IndexValue{Date: date, Value: value})
+	}
+
+	for name := range table {
+		series := table[name]
+		sort.SliceStable(series, func(i, j int) bool {
+			return series[i].Date.Before(series[j].Date)
+		})
+	}
+
+	return table, nil
+}
@@ -0,0 +1,69 @@
+package parsing
+
+import (
+	"ifrs16_calculator/internal/lease"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIndexCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    lease.IndexTable
+		wantErr bool
+	}{
+		{
+			name: "Header and unsorted series",
+			csv: `Index,Date,Value
+CPI,2025-12-01,110.5
+CPI,2024-12-01,104
+RPI,2024-12-01,360.2`,
+			want: lease.IndexTable{
+				"CPI": {
+					{Date: parseDate("2024-12-01"), Value: 104},
+					{Date: parseDate("2025-12-01"), Value: 110.5},
+				},
+				"RPI": {
+					{Date: parseDate("2024-12-01"), Value: 360.2},
+				},
+			},
+		},
+		{
+			name: "Without header",
+			csv:  "CPI,2024-12-01,104",
+			want: lease.IndexTable{
+				"CPI": {{Date: parseDate("2024-12-01"), Value: 104}},
+			},
+		},
+		{
+			name:    "Invalid date after the first row",
+			csv:     "CPI,2024-12-01,104\nCPI,December,105",
+			wantErr: true,
+		},
+		{
+			name:    "Non-positive value",
+			csv:     "CPI,2024-12-01,0",
+			wantErr: true,
+		},
+		{
+			name:    "Missing value column",
+			csv:     "CPI,2024-12-01",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseIndexCSV(strings.NewReader(tt.csv))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
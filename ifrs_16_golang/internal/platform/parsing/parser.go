@@ -4,8 +4,12 @@ import (
 	"encoding/csv"
 	"fmt"
 	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
 	"io"
 	"log"
+	"math"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -18,6 +22,8 @@ const dateLayout = "2006-01-02" // Define a standard date format for parsing (YY
 // Config options for parsing
 type ParseConfig struct {
 	SkipHeader bool
+	// DeriveDiscountRates lets DiscountRate be left blank, to be built from yield curves
+	DeriveDiscountRates bool
 	// Add other options like required columns, custom date formats etc.
 }
 
@@ -33,6 +39,43 @@ func parseFloatValue(value string) (float64, error) {
 	return strconv.ParseFloat(strings.TrimSpace(value), 64)
 }
 
+// parseAmountValue parses a string into a money amount, exactly to the cent
+func parseAmountValue(value string) (money.Amount, error) {
+	return money.Parse(value)
+}
+
+// parseBoolValue parses a yes/no flag such as "Yes", "N", "true" or "1"
+func parseBoolValue(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "yes", "y", "true", "1":
+		return true, nil
+	case "no", "n", "false", "0":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid yes/no value '%s'", value)
+	}
+}
+
+// parsePercentValue parses a rate or percentage into a decimal: "5%" or "0.05" for 0.05.
+// Without a % suffix the value is a decimal, and one of 1 or more in magnitude is rejected
+// rather than guessed to be a percentage, since "1" could mean 1% or 100%. (Only the
+// DiscountRate column keeps reading values above 1 as percentages.)
+func parsePercentValue(value string) (float64, error) {
+	trimmed := strings.TrimSpace(value)
+	percent := strings.HasSuffix(trimmed, "%")
+	v, err := parseFloatValue(strings.TrimSuffix(trimmed, "%"))
+	if err != nil {
+		return 0, err
+	}
+	if percent {
+		return v / 100.0, nil
+	}
+	if math.Abs(v) >= 1.0 {
+		return 0, fmt.Errorf("ambiguous rate '%s': write a percentage with %% (e.g. 5%%) or a decimal (e.g. 0.05)", trimmed)
+	}
+	return v, nil
+}
+
 // ParseLeasesFromFile reads lease data from the provided reader based on the file type.
 // It requires the file content to be fully available (e.g., read into memory or a temp file)
 // especially for XLSX files due to library constraints.
@@ -75,15 +118,17 @@ func ParseCSV(reader io.Reader, config ParseConfig) ([]lease.Lease, error) {
 	csvReader := csv.NewReader(reader)
 	csvReader.TrimLeadingSpace = true
 
-	// Skip header row if configured
+	// Skip header row if configured, keeping its names to locate optional columns
+	var columnMap map[string]int
 	if config.SkipHeader {
-		_, err := csvReader.Read()
+		header, err := csvReader.Read()
 		if err == io.EOF {
 			return []lease.Lease{}, nil // Empty file is valid if we expect a header
 		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to read csv header: %w", err)
 		}
+		columnMap = headerColumnMap(header)
 	}
 	// TODO: Optionally read and validate header against expected columns
 
@@ -122,18 +167,26 @@ func ParseCSV(reader io.Reader, config ParseConfig) ([]lease.Lease, error) {
 			continue
 		}
 
-		l, err := parseRecordToLease(record, lineNum)
+		l, err := parseRecordToLease(record, lineNum, config)
 		if err != nil {
 			// Option: Collect errors and continue? For now, fail fast.
 			return nil, fmt.Errorf("error parsing line %d: %w", lineNum, err)
 		}
+		if err := parseOptionalColumns(&l, record, columnMap); err != nil {
+			return nil, fmt.Errorf("error parsing line %d: %w", lineNum, err)
+		}
 		leases = append(leases, l)
 	}
 
 	return leases, nil
 }
 
+// paymentsSheetName is the optional XLSX sheet holding irregular payment calendars.
+const paymentsSheetName = "Payments"
+
 // ParseXLSX parses lease data from an opened excelize File object.
+// Leases are read from the first sheet. A lease with the Irregular payment frequency takes
+// its payments from the rows of the "Payments" sheet carrying its ID.
 func ParseXLSX(f *excelize.File, config ParseConfig) ([]lease.Lease, error) {
 	sheetName := f.GetSheetName(0) // Attempts to get the first sheet by index
 	if sheetName == "" {
@@ -150,9 +203,11 @@ func ParseXLSX(f *excelize.File, config ParseConfig) ([]lease.Lease, error) {
 	}
 
 	startRowIndex := 0
+	var columnMap map[string]int
 	if config.SkipHeader {
 		if len(rows) > 0 {
 			// TODO: Optionally validate header row (rows[0])
+			columnMap = headerColumnMap(rows[0])
 			startRowIndex = 1
 		} else {
 			return []lease.Lease{}, nil // Only header existed, or empty file
@@ -186,19 +241,104 @@ func ParseXLSX(f *excelize.File, config ParseConfig) ([]lease.Lease, error) {
 			row = append(row, make([]string, expectedCols-len(row))...)
 		}
 
-		l, err := parseRecordToLease(row, lineNum)
+		l, err := parseRecordToLease(row, lineNum, config)
 		if err != nil {
 			// Option: Collect errors and continue? For now, fail fast.
 			return nil, fmt.Errorf("error parsing excel row %d: %w", lineNum, err)
 		}
+		if err := parseOptionalColumns(&l, row, columnMap); err != nil {
+			return nil, fmt.Errorf("error parsing excel row %d: %w", lineNum, err)
+		}
 		leases = append(leases, l)
 	}
 
+	if err := attachPaymentSchedules(f, leases); err != nil {
+		return nil, err
+	}
+
 	return leases, nil
 }
 
+// attachPaymentSchedules reads the Payments sheet, if the workbook has one, and sets the
+// payment schedule of each irregular lease from it. The sheet has the columns LeaseID, Date
+// and Amount; a header row is recognised by its unparseable date and skipped.
+func attachPaymentSchedules(f *excelize.File, leases []lease.Lease) error {
+	sheetName := ""
+	for _, name := range f.GetSheetList() {
+		if strings.EqualFold(strings.TrimSpace(name), paymentsSheetName) {
+			sheetName = name
+			break
+		}
+	}
+
+	schedules := map[string][]lease.ScheduledPayment{}
+	if sheetName != "" {
+		rows, err := f.GetRows(sheetName)
+		if err != nil {
+			return fmt.Errorf("failed to get rows from sheet '%s': %w", sheetName, err)
+		}
+
+		for i, row := range rows {
+			lineNum := i + 1
+			if len(row) < 3 {
+				row = append(row, make([]string, 3-len(row))...)
+			}
+			id := strings.TrimSpace(row[0])
+			if id == "" && strings.TrimSpace(row[1]) == "" && strings.TrimSpace(row[2]) == "" {
+				continue // Empty row
+			}
+
+			date, err := parseDateValue(row[1])
+			if err != nil {
+				if i == 0 {
+					continue // Header row
+				}
+				return fmt.Errorf("error parsing %s row %d: invalid date '%s': %w", sheetName, lineNum, row[1], err)
+			}
+			amount, err := parseAmountValue(row[2])
+			if err != nil {
+				return fmt.Errorf("error parsing %s row %d: invalid amount '%s': %w", sheetName, lineNum, row[2], err)
+			}
+			if id == "" || amount < 0 {
+				return fmt.Errorf("error parsing %s row %d: a lease ID and a non-negative amount are required", sheetName, lineNum)
+			}
+
+			schedules[id] = append(schedules[id], lease.ScheduledPayment{Date: date, Amount: amount})
+		}
+	}
+
+	for i := range leases {
+		l := &leases[i]
+		payments, ok := schedules[l.ID]
+		delete(schedules, l.ID)
+
+		if l.PaymentFrequency != lease.Irregular {
+			if ok {
+				return fmt.Errorf("lease %s has rows on the %s sheet but its payment frequency is %s, not %s",
+					l.ID, paymentsSheetName, l.PaymentFrequency, lease.Irregular)
+			}
+			continue
+		}
+		if !ok {
+			return fmt.Errorf("lease %s has an %s payment frequency but no rows on the %s sheet",
+				l.ID, lease.Irregular, paymentsSheetName)
+		}
+
+		sort.SliceStable(payments, func(i, j int) bool {
+			return payments[i].Date.Before(payments[j].Date)
+		})
+		l.PaymentSchedule = payments
+	}
+
+	for id := range schedules {
+		return fmt.Errorf("%s sheet refers to unknown lease %s", paymentsSheetName, id)
+	}
+
+	return nil
+}
+
 // parseRecordToLease converts a string slice (from CSV/Excel row) into a Lease struct.
-func parseRecordToLease(record []string, lineNum int) (lease.Lease, error) {
+func parseRecordToLease(record []string, lineNum int, config ParseConfig) (lease.Lease, error) {
 	var l lease.Lease
 	var err error
 
@@ -239,41 +379,37 @@ func parseRecordToLease(record []string, lineNum int) (lease.Lease, error) {
 		return l, fmt.Errorf("invalid EndDate format '%s' (expected %s): %w", record[2], dateLayout, err)
 	}
 
-	// Parse PaymentAmount
-	if record[3] == "" {
-		return l, fmt.Errorf("missing required field: PaymentAmount")
+	// Parse and Validate PaymentFrequency
+	if record[4] == "" {
+		return l, fmt.Errorf("missing required field: PaymentFrequency")
 	}
-	l.PaymentAmount, err = strconv.ParseFloat(record[3], 64)
+	l.PaymentFrequency, l.PaymentIntervalMonths, err = parsePaymentFrequency(record[4])
 	if err != nil {
-		return l, fmt.Errorf("invalid PaymentAmount '%s': %w", record[3], err)
+		return l, fmt.Errorf("invalid PaymentFrequency '%s' (expected Weekly, Fortnightly, Monthly, BiMonthly, Quarterly, SemiAnnually, Annually, Every N Months or Irregular)", record[4])
 	}
+	irregular := l.PaymentFrequency == lease.Irregular
 
-	// Parse and Validate PaymentFrequency
-	if record[4] == "" {
-		return l, fmt.Errorf("missing required field: PaymentFrequency")
+	// Parse PaymentAmount (an irregular lease takes its payments from a payment schedule)
+	if record[3] == "" && !irregular {
+		return l, fmt.Errorf("missing required field: PaymentAmount")
 	}
-	freq := lease.PaymentFrequency(record[4])
-	switch freq {
-	case lease.Monthly, lease.Quarterly, lease.Annually:
-		l.PaymentFrequency = freq
-	default:
-		// Case-insensitive check might be good here
-		lowerFreq := strings.ToLower(record[4])
-		switch lease.PaymentFrequency(strings.Title(lowerFreq)) {
-		case lease.Monthly, lease.Quarterly, lease.Annually:
-			l.PaymentFrequency = lease.PaymentFrequency(strings.Title(lowerFreq))
-		default:
-			return l, fmt.Errorf("invalid PaymentFrequency '%s' (expected Monthly, Quarterly, or Annually)", record[4])
+	if record[3] != "" {
+		l.PaymentAmount, err = parseAmountValue(record[3])
+		if err != nil {
+			return l, fmt.Errorf("invalid PaymentAmount '%s': %w", record[3], err)
 		}
 	}
 
-	// Parse DiscountRate
-	if record[5] == "" {
+	// Parse DiscountRate, which may be left blank when it is built from yield curves
+	deriveRate := record[5] == "" && config.DeriveDiscountRates
+	if record[5] == "" && !deriveRate {
 		return l, fmt.Errorf("missing required field: DiscountRate")
 	}
-	l.DiscountRate, err = strconv.ParseFloat(record[5], 64)
-	if err != nil {
-		return l, fmt.Errorf("invalid DiscountRate '%s': %w", record[5], err)
+	if !deriveRate {
+		l.DiscountRate, err = strconv.ParseFloat(record[5], 64)
+		if err != nil {
+			return l, fmt.Errorf("invalid DiscountRate '%s': %w", record[5], err)
+		}
 	}
 	// Handle percentage input? Assume decimal for now.
 	// if l.DiscountRate > 1 { /* maybe user entered 5 for 5% */ log warning or error? }
@@ -282,17 +418,519 @@ func parseRecordToLease(record []string, lineNum int) (lease.Lease, error) {
 	if l.EndDate.Before(l.StartDate) {
 		return l, fmt.Errorf("EndDate (%s) cannot be before StartDate (%s)", l.EndDate.Format(dateLayout), l.StartDate.Format(dateLayout))
 	}
-	if l.PaymentAmount <= 0 {
-		return l, fmt.Errorf("PaymentAmount must be positive (got %.2f)", l.PaymentAmount)
+	if l.PaymentAmount <= 0 && !(irregular && l.PaymentAmount == 0) {
+		return l, fmt.Errorf("PaymentAmount must be positive (got %s)", l.PaymentAmount)
 	}
-	if l.DiscountRate <= 0 {
+	if l.DiscountRate <= 0 && !deriveRate {
 		return l, fmt.Errorf("DiscountRate must be positive (got %.4f)", l.DiscountRate)
 	}
 
 	return l, nil
 }
 
-// Add function to parse extra payments
+// headerColumnMap indexes header names to their column positions.
+func headerColumnMap(header []string) map[string]int {
+	columnMap := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			columnMap[name] = i
+		}
+	}
+	return columnMap
+}
+
+// parseOptionalColumns fills in the optional lease fields that are located by header name
+// rather than by position. Missing columns and empty cells leave the defaults untouched.
+func parseOptionalColumns(l *lease.Lease, row []string, columnMap map[string]int) error {
+	// Parse initial direct cost if present
+	if idcIdx, ok := columnMap["InitialDirectCost"]; ok && idcIdx < len(row) {
+		if row[idcIdx] != "" {
+			idc, err := parseAmountValue(row[idcIdx])
+			if err != nil {
+				return fmt.Errorf("invalid initial direct cost: %w", err)
+			}
+			l.InitialDirectCost = idc
+		}
+	}
+
+	// Parse the remaining RoU asset components if present
+	amountColumns := []struct {
+		column string
+		label  string
+		target *money.Amount
+	}{
+		{"LeaseIncentives", "lease incentives", &l.LeaseIncentives},
+		{"PrepaidRent", "prepaid rent", &l.PrepaidRent},
+		{"RestorationCost", "restoration cost", &l.RestorationCost},
+		{"PurchaseOptionPrice", "purchase option price", &l.PurchaseOptionPrice},
+		{"UnderlyingAssetValue", "underlying asset value", &l.UnderlyingAssetValue},
+		{"LeaseStandAlonePrice", "lease stand-alone price", &l.LeaseStandAlonePrice},
+		{"FairValue", "fair value", &l.FairValue},
+		{"CarryingAmount", "carrying amount", &l.CarryingAmount},
+		{"UnguaranteedResidualValue", "unguaranteed residual value", &l.UnguaranteedResidualValue},
+		{"SalePrice", "sale price", &l.SalePrice},
+	}
+	for _, col := range amountColumns {
+		if idx, ok := columnMap[col.column]; ok && idx < len(row) && row[idx] != "" {
+			amount, err := parseAmountValue(row[idx])
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", col.label, err)
+			}
+			*col.target = amount
+		}
+	}
+
+	// Parse the asset class and currency the recognition exemptions are assessed on if present
+	if idx, ok := columnMap["AssetClass"]; ok && idx < len(row) {
+		l.AssetClass = strings.TrimSpace(row[idx])
+	}
+	if idx, ok := columnMap["Currency"]; ok && idx < len(row) {
+		l.Currency = strings.ToUpper(strings.TrimSpace(row[idx]))
+	}
+	if idx, ok := columnMap["Entity"]; ok && idx < len(row) {
+		l.Entity = strings.TrimSpace(row[idx])
+	}
+	if idx, ok := columnMap["TransitionDiscountRate"]; ok && idx < len(row) && strings.TrimSpace(row[idx]) != "" {
+		rate, err := parsePercentValue(row[idx])
+		if err != nil || rate <= 0 {
+			return fmt.Errorf("invalid TransitionDiscountRate '%s'", row[idx])
+		}
+		l.TransitionDiscountRate = rate
+	}
+
+	// Parse the pre-tax rate for discounting the restoration provision if present
+	if rateIdx, ok := columnMap["RestorationDiscountRate"]; ok && rateIdx < len(row) && row[rateIdx] != "" {
+		rate, err := parseFloatValue(row[rateIdx])
+		if err != nil {
+			return fmt.Errorf("invalid restoration discount rate: %w", err)
+		}
+		// Check if rate is provided as percentage and convert to decimal
+		if rate > 1.0 {
+			rate = rate / 100.0
+		}
+		l.RestorationDiscountRate = rate
+	}
+
+	// Parse residual value if present
+	if rvIdx, ok := columnMap["ResidualValue"]; ok && rvIdx < len(row) {
+		if row[rvIdx] != "" {
+			rv, err := parseAmountValue(row[rvIdx])
+			if err != nil {
+				return fmt.Errorf("invalid residual value: %w", err)
+			}
+			l.ResidualValue = rv
+		}
+	}
+
+	// Parse re-estimates of the amount payable under the residual value guarantee if present
+	if idx, ok := columnMap["ResidualValueReviews"]; ok && idx < len(row) && row[idx] != "" {
+		reviews, err := parseResidualValueReviews(row[idx])
+		if err != nil {
+			return fmt.Errorf("invalid residual value reviews: %w", err)
+		}
+		l.Modifications = append(l.Modifications, reviews...)
+	}
+
+	// Parse the non-lease components paid for with the regular payments if present
+	if idx, ok := columnMap["NonLeaseComponents"]; ok && idx < len(row) && row[idx] != "" {
+		components, err := parseNonLeaseComponents(row[idx])
+		if err != nil {
+			return fmt.Errorf("invalid non-lease components: %w", err)
+		}
+		l.NonLeaseComponents = components
+	}
+
+	// Parse payment timing if present (defaults to payments in arrears)
+	if timingIdx, ok := columnMap["PaymentTiming"]; ok && timingIdx < len(row) {
+		if row[timingIdx] != "" {
+			timing, err := parsePaymentTiming(row[timingIdx])
+			if err != nil {
+				return err
+			}
+			l.PaymentTiming = timing
+		}
+	}
+
+	// Parse the day-count convention and rate basis if present
+	if dcIdx, ok := columnMap["DayCount"]; ok && dcIdx < len(row) && row[dcIdx] != "" {
+		dayCount, err := parseDayCountConvention(row[dcIdx])
+		if err != nil {
+			return err
+		}
+		l.DayCount = dayCount
+	}
+	if basisIdx, ok := columnMap["RateBasis"]; ok && basisIdx < len(row) && row[basisIdx] != "" {
+		basis, err := parseRateBasis(row[basisIdx])
+		if err != nil {
+			return err
+		}
+		l.RateBasis = basis
+	}
+
+	// Parse extra payments if present
+	if epIdx, ok := columnMap["ExtraPayments"]; ok && epIdx < len(row) {
+		if row[epIdx] != "" {
+			extraPayments, err := parseExtraPayments(row[epIdx])
+			if err != nil {
+				return fmt.Errorf("invalid extra payments: %w", err)
+			}
+			l.ExtraPayments = extraPayments
+		}
+	}
+
+	// Parse lease modifications if present
+	if modIdx, ok := columnMap["Modifications"]; ok && modIdx < len(row) {
+		if row[modIdx] != "" {
+			modifications, err := parseModifications(row[modIdx])
+			if err != nil {
+				return fmt.Errorf("invalid modifications: %w", err)
+			}
+			l.Modifications = modifications
+		}
+	}
+
+	// Parse extension and termination options, and later changes in their assessment, if present
+	if idx, ok := columnMap["Options"]; ok && idx < len(row) && row[idx] != "" {
+		options, err := parseOptions(row[idx])
+		if err != nil {
+			return fmt.Errorf("invalid options: %w", err)
+		}
+		l.Options = options
+	}
+	if idx, ok := columnMap["OptionReassessments"]; ok && idx < len(row) && row[idx] != "" {
+		reassessments, err := parseOptionReassessments(row[idx])
+		if err != nil {
+			return fmt.Errorf("invalid option reassessments: %w", err)
+		}
+		l.Modifications = append(l.Modifications, reassessments...)
+	}
+
+	// Parse RoU asset impairments if present
+	if idx, ok := columnMap["Impairments"]; ok && idx < len(row) && row[idx] != "" {
+		impairments, err := parseImpairments(row[idx])
+		if err != nil {
+			return fmt.Errorf("invalid impairments: %w", err)
+		}
+		l.Impairments = impairments
+	}
+
+	// Parse the interval of an "every N months" frequency if present
+	if idx, ok := columnMap["PaymentIntervalMonths"]; ok && idx < len(row) && strings.TrimSpace(row[idx]) != "" {
+		months, err := strconv.Atoi(strings.TrimSpace(row[idx]))
+		if err != nil || months <= 0 {
+			return fmt.Errorf("invalid PaymentIntervalMonths '%s'", row[idx])
+		}
+		if l.PaymentFrequency == lease.EveryNMonths {
+			l.PaymentIntervalMonths = months
+		}
+	}
+
+	// Parse fixed escalation and rent-free terms if present
+	if idx, ok := columnMap["EscalationRate"]; ok && idx < len(row) && row[idx] != "" {
+		rate, err := parsePercentValue(row[idx])
+		if err != nil {
+			return fmt.Errorf("invalid escalation rate: %w", err)
+		}
+		l.EscalationRate = rate
+	}
+	monthColumns := []struct {
+		column string
+		target *int
+	}{
+		{"EscalationMonths", &l.EscalationMonths},
+		{"RentFreeMonths", &l.RentFreeMonths},
+		{"UsefulLifeMonths", &l.UsefulLifeMonths},
+	}
+	for _, col := range monthColumns {
+		if idx, ok := columnMap[col.column]; ok && idx < len(row) && strings.TrimSpace(row[idx]) != "" {
+			months, err := strconv.Atoi(strings.TrimSpace(row[idx]))
+			if err != nil || months < 0 {
+				return fmt.Errorf("invalid %s '%s'", col.column, row[idx])
+			}
+			*col.target = months
+		}
+	}
+
+	// Parse the ownership flags that decide the depreciation period if present
+	flagColumns := []struct {
+		column string
+		target *bool
+	}{
+		{"PurchaseOptionReasonablyCertain", &l.PurchaseOptionReasonablyCertain},
+		{"OwnershipTransfer", &l.OwnershipTransfer},
+		{"SpecialisedAsset", &l.SpecialisedAsset},
+		{"LesseeBearsCancellationLosses", &l.LesseeBearsCancellationLosses},
+		{"LesseeBearsResidualValueRisk", &l.LesseeBearsResidualValueRisk},
+		{"BargainSecondaryPeriod", &l.BargainSecondaryPeriod},
+	}
+	for _, col := range flagColumns {
+		if idx, ok := columnMap[col.column]; ok && idx < len(row) && strings.TrimSpace(row[idx]) != "" {
+			flag, err := parseBoolValue(row[idx])
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", col.column, err)
+			}
+			*col.target = flag
+		}
+	}
+
+	// Parse whether the entity is the lessee or the lessor, and a lessor's classification, if present
+	if idx, ok := columnMap["Role"]; ok && idx < len(row) && strings.TrimSpace(row[idx]) != "" {
+		role, err := parseRole(row[idx])
+		if err != nil {
+			return err
+		}
+		l.Role = role
+	}
+	if idx, ok := columnMap["Classification"]; ok && idx < len(row) && strings.TrimSpace(row[idx]) != "" {
+		classification, err := parseClassification(row[idx])
+		if err != nil {
+			return err
+		}
+		l.Classification = classification
+	}
+
+	// Parse the head lease of a sublease and the share of its underlying asset sublet if present
+	if idx, ok := columnMap["HeadLeaseID"]; ok && idx < len(row) {
+		l.HeadLeaseID = strings.TrimSpace(row[idx])
+	}
+	if idx, ok := columnMap["SubleasedShare"]; ok && idx < len(row) && strings.TrimSpace(row[idx]) != "" {
+		share, err := parsePercentValue(row[idx])
+		if err != nil || share <= 0 || share > 1 {
+			return fmt.Errorf("invalid SubleasedShare '%s'", row[idx])
+		}
+		l.SubleasedShare = share
+	}
+
+	// Parse the depreciation method and the inputs it depends on if present
+	if idx, ok := columnMap["DepreciationMethod"]; ok && idx < len(row) && strings.TrimSpace(row[idx]) != "" {
+		method, err := parseDepreciationMethod(row[idx])
+		if err != nil {
+			return err
+		}
+		l.DepreciationMethod = method
+	}
+	if idx, ok := columnMap["DiminishingBalanceRate"]; ok && idx < len(row) && row[idx] != "" {
+		rate, err := parsePercentValue(row[idx])
+		if err != nil {
+			return fmt.Errorf("invalid diminishing balance rate: %w", err)
+		}
+		l.DiminishingBalanceRate = rate
+	}
+	if idx, ok := columnMap["UsageForecast"]; ok && idx < len(row) && row[idx] != "" {
+		forecast, err := parseUsageForecast(row[idx])
+		if err != nil {
+			return fmt.Errorf("invalid usage forecast: %w", err)
+		}
+		l.UsageForecast = forecast
+	}
+
+	// Parse stepped rents if present
+	if idx, ok := columnMap["PaymentSteps"]; ok && idx < len(row) && row[idx] != "" {
+		steps, err := parsePaymentSteps(row[idx])
+		if err != nil {
+			return fmt.Errorf("invalid payment steps: %w", err)
+		}
+		l.PaymentSteps = steps
+	}
+
+	// Parse index-linked payment terms if an index is named
+	if nameIdx, ok := columnMap["IndexName"]; ok && nameIdx < len(row) && strings.TrimSpace(row[nameIdx]) != "" {
+		link, err := parseIndexLink(row, columnMap)
+		if err != nil {
+			return err
+		}
+		l.IndexLink = link
+	}
+
+	return nil
+}
+
+// parseIndexLink reads the index columns of a row. The review frequency defaults to annual
+// and the floor and cap are only set when their columns are filled in.
+func parseIndexLink(row []string, columnMap map[string]int) (*lease.IndexLink, error) {
+	cell := func(column string) string {
+		if idx, ok := columnMap[column]; ok && idx < len(row) {
+			return strings.TrimSpace(row[idx])
+		}
+		return ""
+	}
+
+	link := &lease.IndexLink{
+		IndexName:    cell("IndexName"),
+		ReviewMonths: 12,
+	}
+
+	baseValue, err := parseFloatValue(cell("IndexBaseValue"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid index base value: %w", err)
+	}
+	link.BaseValue = baseValue
+
+	if value := cell("IndexReviewMonths"); value != "" {
+		months, err := strconv.Atoi(value)
+		if err != nil || months <= 0 {
+			return nil, fmt.Errorf("invalid index review months '%s'", value)
+		}
+		link.ReviewMonths = months
+	}
+
+	if value := cell("IndexFloor"); value != "" {
+		floor, err := parsePercentValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index floor: %w", err)
+		}
+		link.Floor = &floor
+	}
+
+	if value := cell("IndexCap"); value != "" {
+		capValue, err := parsePercentValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index cap: %w", err)
+		}
+		link.Cap = &capValue
+	}
+
+	return link, nil
+}
+
+// everyNMonthsPattern matches custom frequencies such as "Every 4 Months" or "4M".
+var everyNMonthsPattern = regexp.MustCompile(`^(?:every)?(\d+)(?:m|months?)$`)
+
+// parsePaymentFrequency maps a frequency label or alias onto a PaymentFrequency. For an
+// "every N months" frequency it also returns N; intervals matching a named frequency
+// (e.g. every 3 months) are normalised to that frequency.
+func parsePaymentFrequency(value string) (lease.PaymentFrequency, int, error) {
+	normalized := strings.ToLower(strings.Join(strings.Fields(value), ""))
+	normalized = strings.NewReplacer("-", "", "_", "").Replace(normalized)
+
+	switch normalized {
+	case "weekly", "week", "w":
+		return lease.Weekly, 0, nil
+	case "fortnightly", "fortnight", "biweekly", "f":
+		return lease.Fortnightly, 0, nil
+	case "monthly", "month", "m":
+		return lease.Monthly, 0, nil
+	case "bimonthly", "everytwomonths":
+		return lease.BiMonthly, 0, nil
+	case "quarterly", "quarter", "q":
+		return lease.Quarterly, 0, nil
+	case "semiannually", "semiannual", "halfyearly", "biannually":
+		return lease.SemiAnnually, 0, nil
+	case "annually", "annual", "yearly", "year", "a", "y":
+		return lease.Annually, 0, nil
+	case "irregular", "schedule", "custom":
+		// The payments come from an explicit payment schedule
+		return lease.Irregular, 0, nil
+	case "everynmonths":
+		// The interval comes from the PaymentIntervalMonths column
+		return lease.EveryNMonths, 0, nil
+	}
+
+	if match := everyNMonthsPattern.FindStringSubmatch(normalized); match != nil {
+		months, err := strconv.Atoi(match[1])
+		if err == nil && months > 0 {
+			switch months {
+			case 1:
+				return lease.Monthly, 0, nil
+			case 2:
+				return lease.BiMonthly, 0, nil
+			case 3:
+				return lease.Quarterly, 0, nil
+			case 6:
+				return lease.SemiAnnually, 0, nil
+			case 12:
+				return lease.Annually, 0, nil
+			default:
+				return lease.EveryNMonths, months, nil
+			}
+		}
+	}
+
+	return "", 0, fmt.Errorf("invalid payment frequency: %s", strings.TrimSpace(value))
+}
+
+// parsePaymentTiming maps a payment timing label onto Advance or Arrears.
+func parsePaymentTiming(value string) (lease.PaymentTiming, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "advance", "in advance", "beginning", "start", "due":
+		return lease.Advance, nil
+	case "arrears", "in arrears", "end", "":
+		return lease.Arrears, nil
+	default:
+		return "", fmt.Errorf("invalid payment timing '%s' (expected Advance or Arrears)", value)
+	}
+}
+
+// parseDayCountConvention maps a day-count label such as "ACT/365F" or "30/360" onto a
+// DayCountConvention.
+func parseDayCountConvention(value string) (lease.DayCountConvention, error) {
+	normalized := strings.ToLower(strings.Join(strings.Fields(value), ""))
+	normalized = strings.NewReplacer("actual", "act", "-", "/", "_", "/").Replace(normalized)
+
+	switch normalized {
+	case "act/365f", "act/365", "act/365fixed", "a365f":
+		return lease.Actual365Fixed, nil
+	case "act/360", "a360":
+		return lease.Actual360, nil
+	case "30/360", "bondbasis":
+		return lease.Thirty360, nil
+	case "act/act", "act/actisda", "act/acticma":
+		return lease.ActualActual, nil
+	default:
+		return "", fmt.Errorf("invalid day count '%s' (expected Actual/365F, Actual/360, 30/360 or Actual/Actual)", value)
+	}
+}
+
+// parseRateBasis maps a rate basis label onto Nominal or Effective.
+func parseRateBasis(value string) (lease.RateBasis, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "nominal", "apr", "periodic":
+		return lease.NominalRate, nil
+	case "effective", "effective annual", "ear", "aer":
+		return lease.EffectiveRate, nil
+	default:
+		return "", fmt.Errorf("invalid rate basis '%s' (expected Nominal or Effective)", value)
+	}
+}
+
+// parseDepreciationMethod maps a depreciation method label onto a DepreciationMethod.
+func parseDepreciationMethod(value string) (lease.DepreciationMethod, error) {
+	switch strings.ToLower(strings.Join(strings.Fields(value), "")) {
+	case "straightline", "straight-line", "sl":
+		return lease.StraightLine, nil
+	case "unitsofproduction", "units-of-production", "uop", "usage":
+		return lease.UnitsOfProduction, nil
+	case "diminishingbalance", "diminishing-balance", "decliningbalance", "declining-balance", "db":
+		return lease.DiminishingBalance, nil
+	default:
+		return "", fmt.Errorf("invalid depreciation method '%s' (expected StraightLine, UnitsOfProduction or DiminishingBalance)", value)
+	}
+}
+
+// parseRole maps a role label onto a Role.
+func parseRole(value string) (lease.Role, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "lessee":
+		return lease.LesseeRole, nil
+	case "lessor":
+		return lease.LessorRole, nil
+	default:
+		return "", fmt.Errorf("invalid role '%s' (expected Lessee or Lessor)", value)
+	}
+}
+
+// parseClassification maps a lessor's classification label onto a Classification.
+func parseClassification(value string) (lease.Classification, error) {
+	switch strings.ToLower(strings.Join(strings.Fields(value), "")) {
+	case "finance", "financelease", "finance-lease":
+		return lease.FinanceLease, nil
+	case "operating", "operatinglease", "operating-lease":
+		return lease.OperatingLease, nil
+	default:
+		return "", fmt.Errorf("invalid classification '%s' (expected Finance or Operating)", value)
+	}
+}
 
 // parseExtraPayments parses the extra payments data from string format
 func parseExtraPayments(input string) ([]lease.ExtraPayment, error) {
@@ -302,16 +940,17 @@ func parseExtraPayments(input string) ([]lease.ExtraPayment, error) {
 
 	var extraPayments []lease.ExtraPayment
 
-	// Format expected: "DATE1:AMOUNT1;DATE2:AMOUNT2"
+	// Format expected: "DATE1:AMOUNT1;DATE2:AMOUNT2[:TYPE[:VARIABLE]]"
+	// e.g. "2025-01-01:5000;2025-06-30:12000:TerminationPenalty;2025-12-31:800:Other:Variable"
 	pairs := strings.Split(input, ";")
 
 	for _, pair := range pairs {
-		if pair == "" {
+		if strings.TrimSpace(pair) == "" {
 			continue
 		}
 
 		parts := strings.Split(pair, ":")
-		if len(parts) != 2 {
+		if len(parts) < 2 || len(parts) > 4 {
 			return nil, fmt.Errorf("invalid extra payment format: %s", pair)
 		}
 
@@ -323,7 +962,7 @@ func parseExtraPayments(input string) ([]lease.ExtraPayment, error) {
 			return nil, fmt.Errorf("invalid date in extra payment: %s", err)
 		}
 
-		amount, err := parseFloatValue(amountStr)
+		amount, err := parseAmountValue(amountStr)
 		if err != nil {
 			return nil, fmt.Errorf("invalid amount in extra payment: %s", err)
 		}
@@ -333,12 +972,437 @@ func parseExtraPayments(input string) ([]lease.ExtraPayment, error) {
 			Amount: amount,
 		}
 
+		if len(parts) >= 3 {
+			paymentType, err := parseExtraPaymentType(parts[2])
+			if err != nil {
+				return nil, err
+			}
+			extraPayment.Type = paymentType
+		}
+
+		if len(parts) == 4 {
+			switch strings.ToLower(strings.TrimSpace(parts[3])) {
+			case "variable", "v", "expensed":
+				extraPayment.Variable = true
+			case "fixed", "f", "":
+				extraPayment.Variable = false
+			default:
+				return nil, fmt.Errorf("invalid extra payment treatment '%s' (expected Fixed or Variable)", parts[3])
+			}
+		}
+
 		extraPayments = append(extraPayments, extraPayment)
 	}
 
 	return extraPayments, nil
 }
 
+// parsePaymentSteps parses stepped rents from string format.
+func parsePaymentSteps(input string) ([]lease.PaymentStep, error) {
+	var steps []lease.PaymentStep
+
+	// Format expected: "START_DATE:END_DATE:AMOUNT;..." with END_DATE blank for an open step
+	// e.g. "2024-01-01:2025-01-01:5000;2025-01-01::5500"
+	for _, entry := range strings.Split(input, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid payment step format: %s", entry)
+		}
+
+		startDate, err := parseDateValue(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid start date in payment step: %s", err)
+		}
+		step := lease.PaymentStep{StartDate: startDate}
+
+		if strings.TrimSpace(parts[1]) != "" {
+			if step.EndDate, err = parseDateValue(parts[1]); err != nil {
+				return nil, fmt.Errorf("invalid end date in payment step: %s", err)
+			}
+			if !step.EndDate.After(step.StartDate) {
+				return nil, fmt.Errorf("payment step ending %s does not end after it starts", parts[1])
+			}
+		}
+
+		if step.Amount, err = parseAmountValue(parts[2]); err != nil {
+			return nil, fmt.Errorf("invalid amount in payment step: %s", err)
+		}
+		if step.Amount < 0 {
+			return nil, fmt.Errorf("payment step amount cannot be negative: %s", step.Amount)
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// parseUsageForecast parses a usage forecast from string format, in date order.
+func parseUsageForecast(input string) ([]lease.UsageEstimate, error) {
+	var forecast []lease.UsageEstimate
+
+	// Format expected: "DATE:UNITS;..." with each estimate covering the units used since the
+	// previous one, e.g. "2024-12-31:1800;2025-12-31:2400"
+	for _, entry := range strings.Split(input, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid usage estimate format: %s", entry)
+		}
+
+		date, err := parseDateValue(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date in usage estimate: %s", err)
+		}
+		units, err := parseFloatValue(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid units in usage estimate: %s", err)
+		}
+		if units < 0 {
+			return nil, fmt.Errorf("usage estimate units cannot be negative: %.2f", units)
+		}
+
+		forecast = append(forecast, lease.UsageEstimate{Date: date, Units: units})
+	}
+
+	sort.SliceStable(forecast, func(i, j int) bool {
+		return forecast[i].Date.Before(forecast[j].Date)
+	})
+	return forecast, nil
+}
+
+// parseModifications parses lease modifications from string format. Blank fields leave the
+// corresponding term unchanged.
+func parseModifications(input string) ([]lease.Modification, error) {
+	var modifications []lease.Modification
+
+	// Format expected: "EFFECTIVE_DATE:PAYMENT:END_DATE:RATE:SCOPE_CHANGE;..."
+	// e.g. "2025-07-01:5500:::;2026-01-01::2028-12-31:6%:-25%"
+	for _, entry := range strings.Split(input, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) > 5 {
+			return nil, fmt.Errorf("invalid modification format: %s", entry)
+		}
+		for len(parts) < 5 {
+			parts = append(parts, "")
+		}
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+
+		effectiveDate, err := parseDateValue(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid effective date in modification: %s", err)
+		}
+		m := lease.Modification{EffectiveDate: effectiveDate}
+
+		if parts[1] != "" {
+			if m.NewPaymentAmount, err = parseAmountValue(parts[1]); err != nil {
+				return nil, fmt.Errorf("invalid payment in modification: %s", err)
+			}
+		}
+		if parts[2] != "" {
+			if m.NewEndDate, err = parseDateValue(parts[2]); err != nil {
+				return nil, fmt.Errorf("invalid end date in modification: %s", err)
+			}
+		}
+		if parts[3] != "" {
+			if m.RevisedDiscountRate, err = parsePercentValue(parts[3]); err != nil {
+				return nil, fmt.Errorf("invalid discount rate in modification: %s", err)
+			}
+		}
+		if parts[4] != "" {
+			if m.ScopeChangePercent, err = parsePercentValue(parts[4]); err != nil {
+				return nil, fmt.Errorf("invalid scope change in modification: %s", err)
+			}
+		}
+
+		modifications = append(modifications, m)
+	}
+
+	return modifications, nil
+}
+
+// parseOptions parses extension and termination options from string format. The exercise
+// window, payment and penalty may be left blank.
+func parseOptions(input string) ([]lease.Option, error) {
+	var options []lease.Option
+
+	// Format expected: "TYPE:EXERCISE_FROM:EXERCISE_TO:END_DATE:PAYMENT:PENALTY:REASONABLY_CERTAIN;..."
+	// e.g. "Extension:2026-01-01:2026-06-30:2029-12-31:5500::yes;Termination::2025-06-30:2025-12-31::12000:no"
+	for _, entry := range strings.Split(input, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 7 {
+			return nil, fmt.Errorf("invalid option format: %s", entry)
+		}
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+
+		optionType, err := parseOptionType(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		o := lease.Option{Type: optionType}
+
+		if parts[1] != "" {
+			if o.ExerciseStart, err = parseDateValue(parts[1]); err != nil {
+				return nil, fmt.Errorf("invalid exercise window start in option: %s", err)
+			}
+		}
+		if parts[2] != "" {
+			if o.ExerciseEnd, err = parseDateValue(parts[2]); err != nil {
+				return nil, fmt.Errorf("invalid exercise window end in option: %s", err)
+			}
+		}
+		if o.EndDate, err = parseDateValue(parts[3]); err != nil {
+			return nil, fmt.Errorf("invalid end date in option: %s", err)
+		}
+		if parts[4] != "" {
+			if o.PaymentAmount, err = parseAmountValue(parts[4]); err != nil {
+				return nil, fmt.Errorf("invalid payment in option: %s", err)
+			}
+		}
+		if parts[5] != "" {
+			if o.Penalty, err = parseAmountValue(parts[5]); err != nil {
+				return nil, fmt.Errorf("invalid penalty in option: %s", err)
+			}
+		}
+		if o.ReasonablyCertain, err = parseBoolValue(parts[6]); err != nil {
+			return nil, fmt.Errorf("invalid reasonably certain flag in option: %s", err)
+		}
+
+		options = append(options, o)
+	}
+
+	return options, nil
+}
+
+// parseOptionType maps an option type label onto one of the known option types.
+func parseOptionType(value string) (lease.OptionType, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "extension", "extend", "renewal", "renew":
+		return lease.ExtensionOption, nil
+	case "termination", "terminate", "break":
+		return lease.TerminationOption, nil
+	default:
+		return "", fmt.Errorf("invalid option type '%s' (expected Extension or Termination)", value)
+	}
+}
+
+// parseOptionReassessments parses changes in the assessment of options from string format.
+// Each becomes an OptionReassessment modification; options are numbered from 1 in the order
+// of the Options column.
+func parseOptionReassessments(input string) ([]lease.Modification, error) {
+	var reassessments []lease.Modification
+
+	// Format expected: "DATE:OPTION:REASONABLY_CERTAIN:REVISED_RATE;..."
+	// e.g. "2026-03-01:1:yes:5.5%"
+	for _, entry := range strings.Split(input, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("invalid option reassessment format: %s", entry)
+		}
+
+		date, err := parseDateValue(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date in option reassessment: %s", err)
+		}
+		option, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || option < 1 {
+			return nil, fmt.Errorf("invalid option number in option reassessment: %s", parts[1])
+		}
+		certain, err := parseBoolValue(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid reasonably certain flag in option reassessment: %s", err)
+		}
+		rate, err := parsePercentValue(parts[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid discount rate in option reassessment: %s", err)
+		}
+
+		assessment := "not reasonably certain"
+		if certain {
+			assessment = "reasonably certain"
+		}
+		reassessments = append(reassessments, lease.Modification{
+			Type:                    lease.OptionReassessment,
+			EffectiveDate:           date,
+			RevisedDiscountRate:     rate,
+			Option:                  option,
+			OptionReasonablyCertain: certain,
+			Description:             fmt.Sprintf("Option %d %s", option, assessment),
+		})
+	}
+
+	return reassessments, nil
+}
+
+// parseResidualValueReviews parses re-estimates of the amount expected to be payable under a
+// residual value guarantee from string format. Each becomes a ResidualValueReview modification.
+func parseResidualValueReviews(input string) ([]lease.Modification, error) {
+	var reviews []lease.Modification
+
+	// Format expected: "DATE:AMOUNT;..." e.g. "2026-06-30:2500;2027-06-30:0"
+	for _, entry := range strings.Split(input, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid residual value review format: %s", entry)
+		}
+
+		date, err := parseDateValue(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date in residual value review: %s", err)
+		}
+		amount, err := parseAmountValue(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount in residual value review: %s", err)
+		}
+
+		reviews = append(reviews, lease.Modification{
+			Type:             lease.ResidualValueReview,
+			EffectiveDate:    date,
+			NewResidualValue: &amount,
+			Description:      fmt.Sprintf("Residual value guarantee expected to pay %s", amount),
+		})
+	}
+
+	return reviews, nil
+}
+
+// parseNonLeaseComponents parses the non-lease components of the regular payments from string
+// format. Each gives either the amount of every payment for the component or its stand-alone
+// price.
+func parseNonLeaseComponents(input string) ([]lease.NonLeaseComponent, error) {
+	var components []lease.NonLeaseComponent
+
+	// Format expected: "DESCRIPTION:AMOUNT:STAND_ALONE_PRICE;..."
+	// e.g. "Service charge:250:;Maintenance:100:" or "Service charge::3000"
+	for _, entry := range strings.Split(input, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid non-lease component format: %s", entry)
+		}
+
+		component := lease.NonLeaseComponent{Description: strings.TrimSpace(parts[0])}
+		if strings.TrimSpace(parts[1]) != "" {
+			amount, err := parseAmountValue(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid amount in non-lease component: %s", err)
+			}
+			component.Amount = amount
+		}
+		if strings.TrimSpace(parts[2]) != "" {
+			price, err := parseAmountValue(parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid stand-alone price in non-lease component: %s", err)
+			}
+			component.StandAlonePrice = price
+		}
+		if component.Amount == 0 && component.StandAlonePrice == 0 {
+			return nil, fmt.Errorf("non-lease component needs an amount or a stand-alone price: %s", entry)
+		}
+
+		components = append(components, component)
+	}
+
+	return components, nil
+}
+
+// parseImpairments parses RoU asset impairments from string format. Each entry gives either
+// the impairment loss (negative for a reversal) or the recoverable amount.
+func parseImpairments(input string) ([]lease.Impairment, error) {
+	var impairments []lease.Impairment
+
+	// Format expected: "DATE:LOSS:RECOVERABLE_AMOUNT;..."
+	// e.g. "2025-06-30::40000;2026-06-30:-2500"
+	for _, entry := range strings.Split(input, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("invalid impairment format: %s", entry)
+		}
+		for len(parts) < 3 {
+			parts = append(parts, "")
+		}
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		if (parts[1] == "") == (parts[2] == "") {
+			return nil, fmt.Errorf("impairment %s needs either a loss or a recoverable amount", entry)
+		}
+
+		date, err := parseDateValue(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date in impairment: %s", err)
+		}
+		impairment := lease.Impairment{Date: date}
+
+		if parts[1] != "" {
+			if impairment.Loss, err = parseAmountValue(parts[1]); err != nil {
+				return nil, fmt.Errorf("invalid loss in impairment: %s", err)
+			}
+		}
+		if parts[2] != "" {
+			recoverable, err := parseAmountValue(parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid recoverable amount in impairment: %s", err)
+			}
+			impairment.RecoverableAmount = &recoverable
+		}
+
+		impairments = append(impairments, impairment)
+	}
+
+	return impairments, nil
+}
+
+// parseExtraPaymentType maps a payment type label onto one of the known extra payment types.
+func parseExtraPaymentType(value string) (lease.ExtraPaymentType, error) {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(value), " ", "")) {
+	case "", "other":
+		return lease.OtherPayment, nil
+	case "prepayment", "prepaid", "advance":
+		return lease.Prepayment, nil
+	case "terminationpenalty", "termination", "penalty":
+		return lease.TerminationPenalty, nil
+	case "keymoney", "key":
+		return lease.KeyMoney, nil
+	default:
+		return "", fmt.Errorf("invalid extra payment type '%s' (expected Prepayment, TerminationPenalty, KeyMoney or Other)", value)
+	}
+}
+
 // parseLeaseFromRow converts a row of string values into a Lease struct.
 func parseLeaseFromRow(row []string, columnMap map[string]int) (lease.Lease, error) {
 	l := lease.Lease{}
@@ -376,7 +1440,7 @@ func parseLeaseFromRow(row []string, columnMap map[string]int) (lease.Lease, err
 	}
 
 	if paymentAmountIdx, ok := columnMap["PaymentAmount"]; ok && paymentAmountIdx < len(row) {
-		paymentAmount, err := parseFloatValue(row[paymentAmountIdx])
+		paymentAmount, err := parseAmountValue(row[paymentAmountIdx])
 		if err != nil {
 			return l, fmt.Errorf("invalid payment amount: %w", err)
 		}
@@ -384,21 +1448,13 @@ func parseLeaseFromRow(row []string, columnMap map[string]int) (lease.Lease, err
 	}
 
 	if paymentFreqIdx, ok := columnMap["PaymentFrequency"]; ok && paymentFreqIdx < len(row) {
-		freqStr := strings.ToLower(strings.TrimSpace(row[paymentFreqIdx]))
-		var freq lease.PaymentFrequency
-
-		switch freqStr {
-		case "monthly", "month", "m":
-			freq = lease.Monthly
-		case "quarterly", "quarter", "q":
-			freq = lease.Quarterly
-		case "annually", "annual", "yearly", "year", "a", "y":
-			freq = lease.Annually
-		default:
-			return l, fmt.Errorf("invalid payment frequency: %s", freqStr)
+		freq, interval, err := parsePaymentFrequency(row[paymentFreqIdx])
+		if err != nil {
+			return l, err
 		}
 
 		l.PaymentFrequency = freq
+		l.PaymentIntervalMonths = interval
 	}
 
 	if discountRateIdx, ok := columnMap["DiscountRate"]; ok && discountRateIdx < len(row) {
@@ -416,37 +1472,8 @@ func parseLeaseFromRow(row []string, columnMap map[string]int) (lease.Lease, err
 		l.DiscountRate = rate
 	}
 
-	// Parse initial direct cost if present
-	if idcIdx, ok := columnMap["InitialDirectCost"]; ok && idcIdx < len(row) {
-		if row[idcIdx] != "" {
-			idc, err := parseFloatValue(row[idcIdx])
-			if err != nil {
-				return l, fmt.Errorf("invalid initial direct cost: %w", err)
-			}
-			l.InitialDirectCost = idc
-		}
-	}
-
-	// Parse residual value if present
-	if rvIdx, ok := columnMap["ResidualValue"]; ok && rvIdx < len(row) {
-		if row[rvIdx] != "" {
-			rv, err := parseFloatValue(row[rvIdx])
-			if err != nil {
-				return l, fmt.Errorf("invalid residual value: %w", err)
-			}
-			l.ResidualValue = rv
-		}
-	}
-
-	// Parse extra payments if present
-	if epIdx, ok := columnMap["ExtraPayments"]; ok && epIdx < len(row) {
-		if row[epIdx] != "" {
-			extraPayments, err := parseExtraPayments(row[epIdx])
-			if err != nil {
-				return l, fmt.Errorf("invalid extra payments: %w", err)
-			}
-			l.ExtraPayments = extraPayments
-		}
+	if err := parseOptionalColumns(&l, row, columnMap); err != nil {
+		return l, err
 	}
 
 	return l, nil
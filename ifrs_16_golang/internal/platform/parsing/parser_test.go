@@ -2,6 +2,7 @@ package parsing
 
 import (
 	"ifrs16_calculator/internal/lease"
+	"ifrs16_calculator/internal/money"
 	"strings"
 	"testing"
 	"time"
@@ -19,6 +20,10 @@ func parseDate(s string) time.Time {
 	return t
 }
 
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
 func TestParseCSV(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -40,7 +45,7 @@ L002,2023-02-01,2026-01-31,10000,Quarterly,0.045`,
 					ID:               "L001",
 					StartDate:        parseDate("2023-01-01"),
 					EndDate:          parseDate("2027-12-31"),
-					PaymentAmount:    5000,
+					PaymentAmount:    5000 * money.Unit,
 					PaymentFrequency: lease.Monthly,
 					DiscountRate:     0.05,
 				},
@@ -48,7 +53,7 @@ L002,2023-02-01,2026-01-31,10000,Quarterly,0.045`,
 					ID:               "L002",
 					StartDate:        parseDate("2023-02-01"),
 					EndDate:          parseDate("2026-01-31"),
-					PaymentAmount:    10000,
+					PaymentAmount:    10000 * money.Unit,
 					PaymentFrequency: lease.Quarterly,
 					DiscountRate:     0.045,
 				},
@@ -67,7 +72,7 @@ L002,2023-02-01,2026-01-31,10000,Quarterly,0.045`,
 					ID:               "L001",
 					StartDate:        parseDate("2023-01-01"),
 					EndDate:          parseDate("2027-12-31"),
-					PaymentAmount:    5000,
+					PaymentAmount:    5000 * money.Unit,
 					PaymentFrequency: lease.Monthly,
 					DiscountRate:     0.05,
 				},
@@ -75,7 +80,7 @@ L002,2023-02-01,2026-01-31,10000,Quarterly,0.045`,
 					ID:               "L002",
 					StartDate:        parseDate("2023-02-01"),
 					EndDate:          parseDate("2026-01-31"),
-					PaymentAmount:    10000,
+					PaymentAmount:    10000 * money.Unit,
 					PaymentFrequency: lease.Quarterly,
 					DiscountRate:     0.045,
 				},
@@ -111,7 +116,431 @@ L002,2023-02-01,2026-01-31,10000,Quarterly,0.045`,
 					ID:               "L001",
 					StartDate:        parseDate("2023-01-01"),
 					EndDate:          parseDate("2027-12-31"),
-					PaymentAmount:    5000,
+					PaymentAmount:    5000 * money.Unit,
+					PaymentFrequency: lease.Monthly,
+					DiscountRate:     0.05,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Optional extra payments column located by header",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,ExtraPayments
+L001,2023-01-01,2027-12-31,5000,Monthly,0.05,2023-01-01:20000:KeyMoney;2024-06-30:1500:Other:Variable`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:               "L001",
+					StartDate:        parseDate("2023-01-01"),
+					EndDate:          parseDate("2027-12-31"),
+					PaymentAmount:    5000 * money.Unit,
+					PaymentFrequency: lease.Monthly,
+					DiscountRate:     0.05,
+					ExtraPayments: []lease.ExtraPayment{
+						{Date: parseDate("2023-01-01"), Amount: 20000 * money.Unit, Type: lease.KeyMoney},
+						{Date: parseDate("2024-06-30"), Amount: 1500 * money.Unit, Type: lease.OtherPayment, Variable: true},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Optional RoU asset component columns",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,InitialDirectCost,LeaseIncentives,PrepaidRent,RestorationCost,RestorationDiscountRate
+L001,2023-01-01,2027-12-31,5000,Monthly,0.05,1200,3000,,8000,4.5`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:                "L001",
+					StartDate:         parseDate("2023-01-01"),
+					EndDate:           parseDate("2027-12-31"),
+					PaymentAmount:     5000 * money.Unit,
+					PaymentFrequency:  lease.Monthly,
+					DiscountRate:      0.05,
+					InitialDirectCost: 1200 * money.Unit,
+					LeaseIncentives:   3000 * money.Unit,
+					RestorationCost:   8000 * money.Unit,
+					// Percentages above 1 are converted to decimals
+					RestorationDiscountRate: 0.045,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Optional index link columns",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,IndexName,IndexBaseValue,IndexReviewMonths,IndexFloor,IndexCap
+L001,2023-01-01,2027-12-31,5000,Monthly,0.05,CPI,112.4,,0%,4%
+L002,2023-01-01,2027-12-31,5000,Monthly,0.05,,,,,`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:               "L001",
+					StartDate:        parseDate("2023-01-01"),
+					EndDate:          parseDate("2027-12-31"),
+					PaymentAmount:    5000 * money.Unit,
+					PaymentFrequency: lease.Monthly,
+					DiscountRate:     0.05,
+					IndexLink: &lease.IndexLink{
+						IndexName:    "CPI",
+						BaseValue:    112.4,
+						ReviewMonths: 12,
+						Floor:        floatPtr(0),
+						Cap:          floatPtr(0.04),
+					},
+				},
+				{
+					ID:               "L002",
+					StartDate:        parseDate("2023-01-01"),
+					EndDate:          parseDate("2027-12-31"),
+					PaymentAmount:    5000 * money.Unit,
+					PaymentFrequency: lease.Monthly,
+					DiscountRate:     0.05,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Optional escalation and rent-free columns",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,EscalationRate,EscalationMonths,RentFreeMonths,PaymentSteps
+L001,2023-01-01,2027-12-31,5000,Monthly,0.05,3%,,6,
+L002,2023-01-01,2027-12-31,5000,Monthly,0.05,,,,2023-01-01:2025-01-01:5000;2025-01-01::5500`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:               "L001",
+					StartDate:        parseDate("2023-01-01"),
+					EndDate:          parseDate("2027-12-31"),
+					PaymentAmount:    5000 * money.Unit,
+					PaymentFrequency: lease.Monthly,
+					DiscountRate:     0.05,
+					EscalationRate:   0.03,
+					RentFreeMonths:   6,
+				},
+				{
+					ID:               "L002",
+					StartDate:        parseDate("2023-01-01"),
+					EndDate:          parseDate("2027-12-31"),
+					PaymentAmount:    5000 * money.Unit,
+					PaymentFrequency: lease.Monthly,
+					DiscountRate:     0.05,
+					PaymentSteps: []lease.PaymentStep{
+						{StartDate: parseDate("2023-01-01"), EndDate: parseDate("2025-01-01"), Amount: 5000 * money.Unit},
+						{StartDate: parseDate("2025-01-01"), Amount: 5500 * money.Unit},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Optional purchase option and useful life columns",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,PurchaseOptionPrice,PurchaseOptionReasonablyCertain,OwnershipTransfer,UsefulLifeMonths
+L001,2023-01-01,2027-12-31,5000,Monthly,0.05,25000,Yes,,120
+L002,2023-01-01,2027-12-31,5000,Monthly,0.05,,,true,96`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:                              "L001",
+					StartDate:                       parseDate("2023-01-01"),
+					EndDate:                         parseDate("2027-12-31"),
+					PaymentAmount:                   5000 * money.Unit,
+					PaymentFrequency:                lease.Monthly,
+					DiscountRate:                    0.05,
+					PurchaseOptionPrice:             25000 * money.Unit,
+					PurchaseOptionReasonablyCertain: true,
+					UsefulLifeMonths:                120,
+				},
+				{
+					ID:                "L002",
+					StartDate:         parseDate("2023-01-01"),
+					EndDate:           parseDate("2027-12-31"),
+					PaymentAmount:     5000 * money.Unit,
+					PaymentFrequency:  lease.Monthly,
+					DiscountRate:      0.05,
+					OwnershipTransfer: true,
+					UsefulLifeMonths:  96,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Optional asset class, currency and underlying asset value columns",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,AssetClass,Currency,UnderlyingAssetValue
+L001,2023-01-01,2027-12-31,50,Monthly,0.05, IT Equipment ,usd,1800`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:                   "L001",
+					AssetClass:           "IT Equipment",
+					Currency:             "USD",
+					StartDate:            parseDate("2023-01-01"),
+					EndDate:              parseDate("2027-12-31"),
+					PaymentAmount:        50 * money.Unit,
+					PaymentFrequency:     lease.Monthly,
+					DiscountRate:         0.05,
+					UnderlyingAssetValue: 1800 * money.Unit,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Entity with the discount rate left to the yield curves",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,Currency,Entity
+L001,2023-01-01,2027-12-31,5000,Monthly,,USD, Acme Ltd `,
+			config: ParseConfig{
+				SkipHeader:          true,
+				DeriveDiscountRates: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:               "L001",
+					Currency:         "USD",
+					Entity:           "Acme Ltd",
+					StartDate:        parseDate("2023-01-01"),
+					EndDate:          parseDate("2027-12-31"),
+					PaymentAmount:    5000 * money.Unit,
+					PaymentFrequency: lease.Monthly,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Rate at the date of initial application",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,TransitionDiscountRate
+L001,2023-01-01,2027-12-31,5000,Monthly,0.05,6.5%`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:                     "L001",
+					StartDate:              parseDate("2023-01-01"),
+					EndDate:                parseDate("2027-12-31"),
+					PaymentAmount:          5000 * money.Unit,
+					PaymentFrequency:       lease.Monthly,
+					DiscountRate:           0.05,
+					TransitionDiscountRate: 0.065,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Ambiguous rate at the date of initial application",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,TransitionDiscountRate
+L001,2023-01-01,2027-12-31,5000,Monthly,0.05,6.5`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "Optional lessor columns",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,Role,Classification,FairValue,CarryingAmount,UnguaranteedResidualValue,SpecialisedAsset,LesseeBearsResidualValueRisk
+L001,2023-01-01,2027-12-31,5000,Monthly,0.05,Lessor,Operating Lease,300000,240000,25000,yes,no`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:                        "L001",
+					StartDate:                 parseDate("2023-01-01"),
+					EndDate:                   parseDate("2027-12-31"),
+					PaymentAmount:             5000 * money.Unit,
+					PaymentFrequency:          lease.Monthly,
+					DiscountRate:              0.05,
+					Role:                      lease.LessorRole,
+					Classification:            lease.OperatingLease,
+					FairValue:                 300000 * money.Unit,
+					CarryingAmount:            240000 * money.Unit,
+					UnguaranteedResidualValue: 25000 * money.Unit,
+					SpecialisedAsset:          true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Invalid role",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,Role
+L001,2023-01-01,2027-12-31,5000,Monthly,0.05,Landlord`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "Optional sublease columns",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,Role,HeadLeaseID,SubleasedShare
+S001,2024-01-01,2026-12-31,1200,Monthly,0.05,Lessor,L001,25%`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:               "S001",
+					StartDate:        parseDate("2024-01-01"),
+					EndDate:          parseDate("2026-12-31"),
+					PaymentAmount:    1200 * money.Unit,
+					PaymentFrequency: lease.Monthly,
+					DiscountRate:     0.05,
+					Role:             lease.LessorRole,
+					HeadLeaseID:      "L001",
+					SubleasedShare:   0.25,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Optional sale and leaseback columns",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,SalePrice,FairValue,CarryingAmount
+L001,2024-01-01,2041-12-31,120000,Annually,0.045,2000000,1800000,1000000`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:               "L001",
+					StartDate:        parseDate("2024-01-01"),
+					EndDate:          parseDate("2041-12-31"),
+					PaymentAmount:    120000 * money.Unit,
+					PaymentFrequency: lease.Annually,
+					DiscountRate:     0.045,
+					SalePrice:        2000000 * money.Unit,
+					FairValue:        1800000 * money.Unit,
+					CarryingAmount:   1000000 * money.Unit,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Invalid subleased share",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,Role,HeadLeaseID,SubleasedShare
+S001,2024-01-01,2026-12-31,1200,Monthly,0.05,Lessor,L001,150%`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "Optional depreciation method columns",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,DepreciationMethod,DiminishingBalanceRate,UsageForecast
+L001,2023-01-01,2027-12-31,5000,Monthly,0.05,Units of Production,,2024-12-31:2400;2023-12-31:1800.5
+L002,2023-01-01,2027-12-31,5000,Monthly,0.05,DiminishingBalance,40%,`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:                 "L001",
+					StartDate:          parseDate("2023-01-01"),
+					EndDate:            parseDate("2027-12-31"),
+					PaymentAmount:      5000 * money.Unit,
+					PaymentFrequency:   lease.Monthly,
+					DiscountRate:       0.05,
+					DepreciationMethod: lease.UnitsOfProduction,
+					// Estimates are put in date order
+					UsageForecast: []lease.UsageEstimate{
+						{Date: parseDate("2023-12-31"), Units: 1800.5},
+						{Date: parseDate("2024-12-31"), Units: 2400},
+					},
+				},
+				{
+					ID:                     "L002",
+					StartDate:              parseDate("2023-01-01"),
+					EndDate:                parseDate("2027-12-31"),
+					PaymentAmount:          5000 * money.Unit,
+					PaymentFrequency:       lease.Monthly,
+					DiscountRate:           0.05,
+					DepreciationMethod:     lease.DiminishingBalance,
+					DiminishingBalanceRate: 0.4,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Invalid ownership transfer flag",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,OwnershipTransfer
+L001,2023-01-01,2027-12-31,5000,Monthly,0.05,maybe`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "New frequencies with interval column",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,PaymentIntervalMonths
+L001,2023-01-01,2027-12-31,1200,Weekly,0.05,
+L002,2023-01-01,2027-12-31,20000,EveryNMonths,0.05,4
+L003,2023-01-01,2027-12-31,30000,Semi-Annually,0.05,`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:               "L001",
+					StartDate:        parseDate("2023-01-01"),
+					EndDate:          parseDate("2027-12-31"),
+					PaymentAmount:    1200 * money.Unit,
+					PaymentFrequency: lease.Weekly,
+					DiscountRate:     0.05,
+				},
+				{
+					ID:                    "L002",
+					StartDate:             parseDate("2023-01-01"),
+					EndDate:               parseDate("2027-12-31"),
+					PaymentAmount:         20000 * money.Unit,
+					PaymentFrequency:      lease.EveryNMonths,
+					PaymentIntervalMonths: 4,
+					DiscountRate:          0.05,
+				},
+				{
+					ID:               "L003",
+					StartDate:        parseDate("2023-01-01"),
+					EndDate:          parseDate("2027-12-31"),
+					PaymentAmount:    30000 * money.Unit,
+					PaymentFrequency: lease.SemiAnnually,
+					DiscountRate:     0.05,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Day count and rate basis columns",
+			csv: `ID,StartDate,EndDate,PaymentAmount,PaymentFrequency,DiscountRate,DayCount,RateBasis
+L001,2023-01-01,2027-12-31,5000,Monthly,0.05,ACT/365F,Effective
+L002,2023-01-01,2027-12-31,5000,Monthly,0.05,,`,
+			config: ParseConfig{
+				SkipHeader: true,
+			},
+			want: []lease.Lease{
+				{
+					ID:               "L001",
+					StartDate:        parseDate("2023-01-01"),
+					EndDate:          parseDate("2027-12-31"),
+					PaymentAmount:    5000 * money.Unit,
+					PaymentFrequency: lease.Monthly,
+					DiscountRate:     0.05,
+					DayCount:         lease.Actual365Fixed,
+					RateBasis:        lease.EffectiveRate,
+				},
+				{
+					ID:               "L002",
+					StartDate:        parseDate("2023-01-01"),
+					EndDate:          parseDate("2027-12-31"),
+					PaymentAmount:    5000 * money.Unit,
 					PaymentFrequency: lease.Monthly,
 					DiscountRate:     0.05,
 				},
@@ -147,7 +576,7 @@ L002,2023-02-01,2026-01-31,10000,Quarterly,0.045`,
 		},
 		{
 			name: "Invalid payment frequency",
-			csv:  "L001,2023-01-01,2027-12-31,5000,Weekly,0.05",
+			csv:  "L001,2023-01-01,2027-12-31,5000,Daily,0.05",
 			config: ParseConfig{
 				SkipHeader: false,
 			},
@@ -174,6 +603,7 @@ func TestParseRecordToLease(t *testing.T) {
 	tests := []struct {
 		name    string
 		record  []string
+		config  ParseConfig
 		want    lease.Lease
 		wantErr bool
 	}{
@@ -184,12 +614,30 @@ func TestParseRecordToLease(t *testing.T) {
 				ID:               "L001",
 				StartDate:        parseDate("2023-01-01"),
 				EndDate:          parseDate("2027-12-31"),
-				PaymentAmount:    5000,
+				PaymentAmount:    5000 * money.Unit,
 				PaymentFrequency: lease.Monthly,
 				DiscountRate:     0.05,
 			},
 			wantErr: false,
 		},
+		{
+			name:   "Irregular record without payment amount",
+			record: []string{"L002", "2023-01-01", "2024-06-30", "", "Irregular", "0.05"},
+			want: lease.Lease{
+				ID:               "L002",
+				StartDate:        parseDate("2023-01-01"),
+				EndDate:          parseDate("2024-06-30"),
+				PaymentFrequency: lease.Irregular,
+				DiscountRate:     0.05,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Missing payment amount",
+			record:  []string{"L001", "2023-01-01", "2027-12-31", "", "Monthly", "0.05"},
+			want:    lease.Lease{},
+			wantErr: true,
+		},
 		{
 			name:    "Insufficient columns",
 			record:  []string{"L001", "2023-01-01", "2027-12-31", "5000", "Monthly"},
@@ -220,11 +668,346 @@ func TestParseRecordToLease(t *testing.T) {
 			want:    lease.Lease{},
 			wantErr: true,
 		},
+		{
+			name:    "Missing discount rate",
+			record:  []string{"L001", "2023-01-01", "2027-12-31", "5000", "Monthly", ""},
+			want:    lease.Lease{},
+			wantErr: true,
+		},
+		{
+			name:   "Discount rate left to the yield curves",
+			record: []string{"L001", "2023-01-01", "2027-12-31", "5000", "Monthly", ""},
+			config: ParseConfig{DeriveDiscountRates: true},
+			want: lease.Lease{
+				ID:               "L001",
+				StartDate:        parseDate("2023-01-01"),
+				EndDate:          parseDate("2027-12-31"),
+				PaymentAmount:    5000 * money.Unit,
+				PaymentFrequency: lease.Monthly,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Zero discount rate with yield curves",
+			record:  []string{"L001", "2023-01-01", "2027-12-31", "5000", "Monthly", "0"},
+			config:  ParseConfig{DeriveDiscountRates: true},
+			want:    lease.Lease{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRecordToLease(tt.record, 1, tt.config)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParsePaymentFrequency(t *testing.T) {
+	tests := []struct {
+		input        string
+		wantFreq     lease.PaymentFrequency
+		wantInterval int
+		wantErr      bool
+	}{
+		{input: "Weekly", wantFreq: lease.Weekly},
+		{input: "fortnightly", wantFreq: lease.Fortnightly},
+		{input: "Bi-Weekly", wantFreq: lease.Fortnightly},
+		{input: "Monthly", wantFreq: lease.Monthly},
+		{input: "BiMonthly", wantFreq: lease.BiMonthly},
+		{input: "quarterly", wantFreq: lease.Quarterly},
+		{input: "Semi-Annually", wantFreq: lease.SemiAnnually},
+		{input: "half yearly", wantFreq: lease.SemiAnnually},
+		{input: "yearly", wantFreq: lease.Annually},
+		{input: "Every 4 Months", wantFreq: lease.EveryNMonths, wantInterval: 4},
+		{input: "9M", wantFreq: lease.EveryNMonths, wantInterval: 9},
+		{input: "every 6 months", wantFreq: lease.SemiAnnually},
+		{input: "EveryNMonths", wantFreq: lease.EveryNMonths},
+		{input: "Irregular", wantFreq: lease.Irregular},
+		{input: "Every 0 Months", wantErr: true},
+		{input: "Daily", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			freq, interval, err := parsePaymentFrequency(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFreq, freq)
+			assert.Equal(t, tt.wantInterval, interval)
+		})
+	}
+}
+
+func TestParsePercentValue(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{input: "5%", want: 0.05},
+		{input: " 0.5% ", want: 0.005},
+		{input: "1%", want: 0.01},
+		{input: "100%", want: 1},
+		{input: "-25%", want: -0.25},
+		{input: "0.05", want: 0.05},
+		{input: "-0.25", want: -0.25},
+		{input: "1", wantErr: true},
+		{input: "1.5", wantErr: true},
+		{input: "5", wantErr: true},
+		{input: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parsePercentValue(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.want, got, 1e-12)
+		})
+	}
+}
+
+func TestParseDayCountConvention(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    lease.DayCountConvention
+		wantErr bool
+	}{
+		{input: "Actual/365F", want: lease.Actual365Fixed},
+		{input: "ACT/365 Fixed", want: lease.Actual365Fixed},
+		{input: "act-360", want: lease.Actual360},
+		{input: "30/360", want: lease.Thirty360},
+		{input: "Actual/Actual", want: lease.ActualActual},
+		{input: "ACT/ACT ISDA", want: lease.ActualActual},
+		{input: "Business/252", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseDayCountConvention(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseExtraPayments(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []lease.ExtraPayment
+		wantErr bool
+	}{
+		{
+			name:  "Date and amount only",
+			input: "2025-01-01:5000",
+			want: []lease.ExtraPayment{
+				{Date: parseDate("2025-01-01"), Amount: 5000 * money.Unit},
+			},
+		},
+		{
+			name:  "Labelled types and treatment",
+			input: "2025-01-01:5000:Prepayment; 2026-06-30:12000:Termination Penalty:Fixed;2025-03-31:800:Other:Variable",
+			want: []lease.ExtraPayment{
+				{Date: parseDate("2025-01-01"), Amount: 5000 * money.Unit, Type: lease.Prepayment},
+				{Date: parseDate("2026-06-30"), Amount: 12000 * money.Unit, Type: lease.TerminationPenalty},
+				{Date: parseDate("2025-03-31"), Amount: 800 * money.Unit, Type: lease.OtherPayment, Variable: true},
+			},
+		},
+		{
+			name:    "Unknown payment type",
+			input:   "2025-01-01:5000:Deposit",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown treatment",
+			input:   "2025-01-01:5000:KeyMoney:Sometimes",
+			wantErr: true,
+		},
+		{
+			name:    "Missing amount",
+			input:   "2025-01-01",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseExtraPayments(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseModifications(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []lease.Modification
+		wantErr bool
+	}{
+		{
+			name:  "Rent review only",
+			input: "2025-07-01:5500",
+			want: []lease.Modification{
+				{EffectiveDate: parseDate("2025-07-01"), NewPaymentAmount: 5500 * money.Unit},
+			},
+		},
+		{
+			name:  "Term, rate and scope with blanks",
+			input: "2025-07-01:5500:::; 2026-01-01::2028-12-31:6%:-25%",
+			want: []lease.Modification{
+				{EffectiveDate: parseDate("2025-07-01"), NewPaymentAmount: 5500 * money.Unit},
+				{EffectiveDate: parseDate("2026-01-01"), NewEndDate: parseDate("2028-12-31"), RevisedDiscountRate: 0.06, ScopeChangePercent: -0.25},
+			},
+		},
+		{
+			name:  "Decimal rate and scope",
+			input: "2026-01-01:::0.055:-0.5",
+			want: []lease.Modification{
+				{EffectiveDate: parseDate("2026-01-01"), RevisedDiscountRate: 0.055, ScopeChangePercent: -0.5},
+			},
+		},
+		{
+			name:    "Invalid effective date",
+			input:   "next year:5500",
+			wantErr: true,
+		},
+		{
+			name:    "Too many fields",
+			input:   "2025-07-01:5500:2028-12-31:6%:-25%:extra",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseModifications(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseImpairments(t *testing.T) {
+	recoverable := 40000 * money.Unit
+	tests := []struct {
+		name    string
+		input   string
+		want    []lease.Impairment
+		wantErr bool
+	}{
+		{
+			name:  "Recoverable amount then reversal",
+			input: "2025-06-30::40000; 2026-06-30:-2500",
+			want: []lease.Impairment{
+				{Date: parseDate("2025-06-30"), RecoverableAmount: &recoverable},
+				{Date: parseDate("2026-06-30"), Loss: -2500 * money.Unit},
+			},
+		},
+		{
+			name:    "Both loss and recoverable amount",
+			input:   "2025-06-30:1000:40000",
+			wantErr: true,
+		},
+		{
+			name:    "Neither loss nor recoverable amount",
+			input:   "2025-06-30",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid date",
+			input:   "mid 2025:1000",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImpairments(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []lease.Option
+		wantErr bool
+	}{
+		{
+			name:  "Extension and termination",
+			input: "Extension:2026-01-01:2026-06-30:2029-12-31:5500::yes; Break::2025-06-30:2025-12-31::12000:no",
+			want: []lease.Option{
+				{
+					Type:              lease.ExtensionOption,
+					ExerciseStart:     parseDate("2026-01-01"),
+					ExerciseEnd:       parseDate("2026-06-30"),
+					EndDate:           parseDate("2029-12-31"),
+					PaymentAmount:     5500 * money.Unit,
+					ReasonablyCertain: true,
+				},
+				{
+					Type:        lease.TerminationOption,
+					ExerciseEnd: parseDate("2025-06-30"),
+					EndDate:     parseDate("2025-12-31"),
+					Penalty:     12000 * money.Unit,
+				},
+			},
+		},
+		{
+			name:    "Missing fields",
+			input:   "Extension:2029-12-31:yes",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown type",
+			input:   "Swap::::2029-12-31:::yes",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid flag",
+			input:   "Extension:::2029-12-31:::maybe",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseRecordToLease(tt.record, 1)
+			got, err := parseOptions(tt.input)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
@@ -235,6 +1018,70 @@ func TestParseRecordToLease(t *testing.T) {
 	}
 }
 
+func TestParseOptionReassessments(t *testing.T) {
+	got, err := parseOptionReassessments("2026-03-01:1:yes:5.5%;2027-01-01:2:no:0.06")
+	assert.NoError(t, err)
+	assert.Equal(t, []lease.Modification{
+		{
+			Type:                    lease.OptionReassessment,
+			EffectiveDate:           parseDate("2026-03-01"),
+			RevisedDiscountRate:     0.055,
+			Option:                  1,
+			OptionReasonablyCertain: true,
+			Description:             "Option 1 reasonably certain",
+		},
+		{
+			Type:                lease.OptionReassessment,
+			EffectiveDate:       parseDate("2027-01-01"),
+			RevisedDiscountRate: 0.06,
+			Option:              2,
+			Description:         "Option 2 not reasonably certain",
+		},
+	}, got)
+
+	_, err = parseOptionReassessments("2026-03-01:first:yes:5.5%")
+	assert.Error(t, err)
+	_, err = parseOptionReassessments("2026-03-01:1:yes:6")
+	assert.Error(t, err)
+}
+
+func TestParseResidualValueReviews(t *testing.T) {
+	got, err := parseResidualValueReviews("2026-06-30:2500; 2027-06-30:0")
+	assert.NoError(t, err)
+	first, second := 2500*money.Unit, money.Amount(0)
+	assert.Equal(t, []lease.Modification{
+		{
+			Type:             lease.ResidualValueReview,
+			EffectiveDate:    parseDate("2026-06-30"),
+			NewResidualValue: &first,
+			Description:      "Residual value guarantee expected to pay 2500.00",
+		},
+		{
+			Type:             lease.ResidualValueReview,
+			EffectiveDate:    parseDate("2027-06-30"),
+			NewResidualValue: &second,
+			Description:      "Residual value guarantee expected to pay 0.00",
+		},
+	}, got)
+
+	_, err = parseResidualValueReviews("2026-06-30")
+	assert.Error(t, err)
+}
+
+func TestParseNonLeaseComponents(t *testing.T) {
+	got, err := parseNonLeaseComponents("Service charge:250:; Maintenance::1200")
+	assert.NoError(t, err)
+	assert.Equal(t, []lease.NonLeaseComponent{
+		{Description: "Service charge", Amount: 250 * money.Unit},
+		{Description: "Maintenance", StandAlonePrice: 1200 * money.Unit},
+	}, got)
+
+	_, err = parseNonLeaseComponents("Service charge:250")
+	assert.Error(t, err)
+	_, err = parseNonLeaseComponents("Service charge::")
+	assert.Error(t, err)
+}
+
 // Since XLSX testing would require working with actual files or mocks,
 // here we'll focus on the functionality that works with in-memory data.
 func TestParseXLSXHelper(t *testing.T) {
@@ -334,3 +1181,52 @@ func TestParseXLSXHelper(t *testing.T) {
 		assert.Contains(t, err.Error(), "invalid")
 	})
 }
+
+func TestParseXLSXPaymentsSheet(t *testing.T) {
+	newWorkbook := func() *excelize.File {
+		f := excelize.NewFile()
+		f.SetSheetRow("Sheet1", "A1", &[]interface{}{"ID", "StartDate", "EndDate", "PaymentAmount", "PaymentFrequency", "DiscountRate"})
+		f.SetSheetRow("Sheet1", "A2", &[]interface{}{"L001", "2024-01-01", "2025-06-30", "", "Irregular", "0.08"})
+		f.SetSheetRow("Sheet1", "A3", &[]interface{}{"L002", "2024-01-01", "2025-12-31", "1000", "Monthly", "0.05"})
+
+		f.NewSheet("Payments")
+		f.SetSheetRow("Payments", "A1", &[]interface{}{"LeaseID", "Date", "Amount"})
+		f.SetSheetRow("Payments", "A2", &[]interface{}{"L001", "2024-09-30", "12000"})
+		f.SetSheetRow("Payments", "A3", &[]interface{}{"L001", "2024-03-15", "5000"})
+		f.SetSheetRow("Payments", "A4", &[]interface{}{"L001", "2025-06-30", "20000"})
+		return f
+	}
+
+	t.Run("Payments are attached by lease ID in date order", func(t *testing.T) {
+		leases, err := ParseXLSX(newWorkbook(), ParseConfig{SkipHeader: true})
+		if assert.NoError(t, err) && assert.Len(t, leases, 2) {
+			assert.Equal(t, []lease.ScheduledPayment{
+				{Date: parseDate("2024-03-15"), Amount: 5000 * money.Unit},
+				{Date: parseDate("2024-09-30"), Amount: 12000 * money.Unit},
+				{Date: parseDate("2025-06-30"), Amount: 20000 * money.Unit},
+			}, leases[0].PaymentSchedule)
+			assert.Empty(t, leases[1].PaymentSchedule)
+		}
+	})
+
+	t.Run("Payments for a regular lease", func(t *testing.T) {
+		f := newWorkbook()
+		f.SetSheetRow("Payments", "A5", &[]interface{}{"L002", "2024-06-30", "500"})
+		_, err := ParseXLSX(f, ParseConfig{SkipHeader: true})
+		assert.Error(t, err)
+	})
+
+	t.Run("Payments for an unknown lease", func(t *testing.T) {
+		f := newWorkbook()
+		f.SetSheetRow("Payments", "A5", &[]interface{}{"L999", "2024-06-30", "500"})
+		_, err := ParseXLSX(f, ParseConfig{SkipHeader: true})
+		assert.Error(t, err)
+	})
+
+	t.Run("Irregular lease without payments", func(t *testing.T) {
+		f := newWorkbook()
+		f.DeleteSheet("Payments")
+		_, err := ParseXLSX(f, ParseConfig{SkipHeader: true})
+		assert.Error(t, err)
+	})
+}
@@ -200,7 +200,7 @@ document.addEventListener('DOMContentLoaded', function() {
                         <div class="alert alert-error">
                             <p>Error: ${result.error}</p>
                         </div>
-                    ` : `
+                    ` : result.exemption ? exemptResultHtml(result) : result.role === 'Lessor' ? lessorResultHtml(result) : `
                         <div class="result-summary">
                             <div class="result-row">
                                 <span class="result-label">Initial Liability:</span>
@@ -210,8 +210,88 @@ document.addEventListener('DOMContentLoaded', function() {
                                 <span class="result-label">Initial RoU Asset:</span>
                                 <span class="result-value">${formatCurrency(result.initialRoUAsset)}</span>
                             </div>
+                            ${result.rouAssetComponents ? `
+                                <div class="result-row">
+                                    <span class="result-label">&nbsp;&nbsp;Payments at/before Commencement:</span>
+                                    <span class="result-value">${formatCurrency(result.rouAssetComponents.paymentsAtCommencement)}</span>
+                                </div>
+                                <div class="result-row">
+                                    <span class="result-label">&nbsp;&nbsp;Less Lease Incentives:</span>
+                                    <span class="result-value">${formatCurrency(-result.rouAssetComponents.leaseIncentives)}</span>
+                                </div>
+                                <div class="result-row">
+                                    <span class="result-label">&nbsp;&nbsp;Initial Direct Costs:</span>
+                                    <span class="result-value">${formatCurrency(result.rouAssetComponents.initialDirectCosts)}</span>
+                                </div>
+                                <div class="result-row">
+                                    <span class="result-label">&nbsp;&nbsp;Restoration Costs:</span>
+                                    <span class="result-value">${formatCurrency(result.rouAssetComponents.restorationCosts)}</span>
+                                </div>
+                            ` : ''}
+                            ${result.saleAndLeaseback ? `
+                                <div class="result-row">
+                                    <span class="result-label">Sale and Leaseback:</span>
+                                    <span class="result-value">Sold for ${formatCurrency(result.saleAndLeaseback.salePrice)} at a fair value of ${formatCurrency(result.saleAndLeaseback.fairValue)}, carrying amount ${formatCurrency(result.saleAndLeaseback.carryingAmount)}</span>
+                                </div>
+                                <div class="result-row">
+                                    <span class="result-label">&nbsp;&nbsp;${result.saleAndLeaseback.additionalFinancing ? 'Additional Financing' : 'Prepaid Lease Payments'}:</span>
+                                    <span class="result-value">${formatCurrency(result.saleAndLeaseback.additionalFinancing || result.saleAndLeaseback.prepayment || 0)}</span>
+                                </div>
+                                <div class="result-row">
+                                    <span class="result-label">&nbsp;&nbsp;Retained Part of the Carrying Amount:</span>
+                                    <span class="result-value">${formatCurrency(result.saleAndLeaseback.rouAsset)}</span>
+                                </div>
+                                <div class="result-row">
+                                    <span class="result-label">&nbsp;&nbsp;Gain/(Loss) on Rights Transferred:</span>
+                                    <span class="result-value">${formatCurrency(result.saleAndLeaseback.gainLoss)}</span>
+                                </div>
+                            ` : ''}
+                            ${result.discountRateSource ? `
+                                <div class="result-row">
+                                    <span class="result-label">Discount Rate (Yield Curves):</span>
+                                    <span class="result-value">${(result.discountRateSource.rate * 100).toFixed(4)}%</span>
+                                </div>
+                                <div class="result-row">
+                                    <span class="result-label">&nbsp;&nbsp;${result.discountRateSource.currency} Curve of ${result.discountRateSource.curveDate.substring(0, 10)} at ${result.discountRateSource.tenorMonths.toFixed(1)} Months (${result.discountRateSource.tenorBasis}):</span>
+                                    <span class="result-value">Risk-free ${(result.discountRateSource.riskFreeRate * 100).toFixed(4)}% + Spread ${(result.discountRateSource.creditSpread * 100).toFixed(4)}%${result.discountRateSource.securityAdjustment ? ` + Security ${(result.discountRateSource.securityAdjustment * 100).toFixed(4)}%` : ''}</span>
+                                </div>
+                            ` : ''}
+                            ${result.restorationProvision ? `
+                                <div class="result-row">
+                                    <span class="result-label">Restoration Provision:</span>
+                                    <span class="result-value">${formatCurrency(result.restorationProvision)}</span>
+                                </div>
+                            ` : ''}
+                            ${result.nonLeasePayments ? `
+                                <div class="result-row">
+                                    <span class="result-label">Non-Lease Components Expensed:</span>
+                                    <span class="result-value">${formatCurrency(result.nonLeasePayments.reduce((total, p) => total + p.amount, 0))}</span>
+                                </div>
+                            ` : ''}
+                            ${result.componentsCombined ? `
+                                <div class="result-row">
+                                    <span class="result-label">Non-Lease Components:</span>
+                                    <span class="result-value">Combined with the lease (IFRS 16.15)</span>
+                                </div>
+                            ` : ''}
+                            ${(result.remeasurements || []).map(r => `
+                                <div class="result-row">
+                                    <span class="result-label">${r.reason} (${r.effectiveDate.substring(0, 10)}):</span>
+                                    <span class="result-value">Liability ${formatCurrency(r.liabilityBefore)} &rarr; ${formatCurrency(r.liabilityAfter)}, RoU ${formatCurrency(r.rouAssetBefore)} &rarr; ${formatCurrency(r.rouAssetAfter)}${r.gainLoss ? `, Gain/(Loss) ${formatCurrency(r.gainLoss)}` : ''}</span>
+                                </div>
+                            `).join('')}
+                            ${result.transition ? `
+                                <div class="result-row">
+                                    <span class="result-label">Transition (${result.transition.initialApplicationDate}):</span>
+                                    <span class="result-value">Liability ${formatCurrency(result.transition.openingLiability)}, RoU ${formatCurrency(result.transition.openingRoUAsset)} at ${(result.transition.discountRate * 100).toFixed(2)}%</span>
+                                </div>
+                                <div class="result-row">
+                                    <span class="result-label">&nbsp;&nbsp;Retained Earnings Adjustment:</span>
+                                    <span class="result-value">${formatCurrency(result.transition.retainedEarningsAdjustment)}</span>
+                                </div>
+                            ` : ''}
                             <div class="result-row">
-                                <span class="result-label">Total Periods:</span>
+                                <span class="result-label">Total Periods${result.scheduleGranularity ? ` (${result.scheduleGranularity})` : ''}:</span>
                                 <span class="result-value">${result.liabilitySchedule.length}</span>
                             </div>
                         </div>
@@ -309,6 +389,142 @@ document.addEventListener('DOMContentLoaded', function() {
     }
     
     // Function to export results to Excel
+    // Leases under a recognition exemption have a straight-line expense schedule instead of a
+    // liability and RoU asset
+    function exemptResultHtml(result) {
+        const exemption = result.exemption === 'ShortTerm' ? 'Short-term lease' : 'Low-value asset';
+        const expenseSchedule = result.expenseSchedule || [];
+        const totalExpense = expenseSchedule.reduce((total, entry) => total + (entry.expense || 0), 0);
+        return `
+            <div class="result-summary">
+                <div class="result-row">
+                    <span class="result-label">Recognition Exemption:</span>
+                    <span class="result-value">${exemption}${result.assetClass ? ` (${result.assetClass})` : ''}</span>
+                </div>
+                <div class="result-row">
+                    <span class="result-label">Total Lease Expense:</span>
+                    <span class="result-value">${formatCurrency(totalExpense)}</span>
+                </div>
+                <div class="result-row">
+                    <span class="result-label">Total Periods${result.scheduleGranularity ? ` (${result.scheduleGranularity})` : ''}:</span>
+                    <span class="result-value">${expenseSchedule.length}</span>
+                </div>
+            </div>
+
+            <div class="collapse-header">
+                <span>Lease Expense Schedule</span>
+                <span class="collapse-icon">+</span>
+            </div>
+            <div class="collapse-body">
+                <table class="table">
+                    <thead>
+                        <tr>
+                            <th>Period</th>
+                            <th>Date</th>
+                            <th>Opening Accrual</th>
+                            <th>Lease Expense</th>
+                            <th>Payment</th>
+                            <th>Closing Accrual</th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        ${expenseSchedule.map(entry => `
+                            <tr>
+                                <td>${entry.period}</td>
+                                <td>${formatDate(entry.date)}</td>
+                                <td>${formatCurrency(entry.openingBalance)}</td>
+                                <td>${formatCurrency(entry.expense)}</td>
+                                <td>${formatCurrency(entry.payment)}</td>
+                                <td>${formatCurrency(entry.closingBalance)}</td>
+                            </tr>
+                        `).join('')}
+                    </tbody>
+                </table>
+            </div>
+        `;
+    }
+
+    // Leases granted as lessor have a net investment schedule (finance lease) or straight-line
+    // income and underlying asset schedules (operating lease)
+    function lessorResultHtml(result) {
+        const finance = result.classification === 'Finance';
+        const indicators = result.classificationIndicators || [];
+        const schedule = (title, headers, entries, values) => `
+            <div class="collapse-header">
+                <span>${title}</span>
+                <span class="collapse-icon">+</span>
+            </div>
+            <div class="collapse-body">
+                <table class="table">
+                    <thead>
+                        <tr>
+                            <th>Period</th>
+                            <th>Date</th>
+                            ${headers.map(header => `<th>${header}</th>`).join('')}
+                        </tr>
+                    </thead>
+                    <tbody>
+                        ${(entries || []).map(entry => `
+                            <tr>
+                                <td>${entry.period}</td>
+                                <td>${formatDate(entry.date)}</td>
+                                ${values(entry).map(value => `<td>${formatCurrency(value)}</td>`).join('')}
+                            </tr>
+                        `).join('')}
+                    </tbody>
+                </table>
+            </div>
+        `;
+        return `
+            <div class="result-summary">
+                <div class="result-row">
+                    <span class="result-label">Lessor Classification:</span>
+                    <span class="result-value">${finance ? 'Finance lease' : 'Operating lease'}</span>
+                </div>
+                ${result.headLeaseId ? `
+                    <div class="result-row">
+                        <span class="result-label">Sublease of:</span>
+                        <span class="result-value">${result.headLeaseId}</span>
+                    </div>
+                ` : ''}
+                ${indicators.map(indicator => `
+                    <div class="result-row">
+                        <span class="result-label">Indicator:</span>
+                        <span class="result-value">${indicator}</span>
+                    </div>
+                `).join('')}
+                ${finance ? `
+                    <div class="result-row">
+                        <span class="result-label">Implicit Rate:</span>
+                        <span class="result-value">${(result.discountRate * 100).toFixed(4)}%</span>
+                    </div>
+                    <div class="result-row">
+                        <span class="result-label">Net Investment:</span>
+                        <span class="result-value">${formatCurrency(result.netInvestment)}</span>
+                    </div>
+                    <div class="result-row">
+                        <span class="result-label">Gain/(Loss) on Derecognition:</span>
+                        <span class="result-value">${formatCurrency(result.derecognitionGainLoss || 0)}</span>
+                    </div>
+                    ${result.headLeaseId ? `
+                        <div class="result-row">
+                            <span class="result-label">Head Lease RoU Asset Derecognised:</span>
+                            <span class="result-value">${formatCurrency(result.subleasedRoUAsset || 0)}</span>
+                        </div>
+                    ` : ''}
+                ` : ''}
+            </div>
+
+            ${finance
+                ? schedule('Net Investment Schedule', ['Opening Balance', 'Receipt', 'Finance Income', 'Closing Balance'],
+                    result.netInvestmentSchedule, e => [e.openingBalance, e.payment || 0, e.income || 0, e.closingBalance])
+                : schedule('Lease Income Schedule', ['Opening Accrual', 'Lease Income', 'Receipt', 'Closing Accrual'],
+                    result.incomeSchedule, e => [e.openingBalance, e.income || 0, e.payment || 0, e.closingBalance]) +
+                  (result.assetSchedule ? schedule('Underlying Asset Schedule', ['Opening Balance', 'Depreciation', 'Closing Balance'],
+                    result.assetSchedule, e => [e.openingBalance, e.depreciation || 0, e.closingBalance]) : '')}
+        `;
+    }
+
     async function exportToExcel(results) {
         try {
             const response = await fetch('/export', {